@@ -8,10 +8,14 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"extratorDeAudio/internal/extractor"
 	"extratorDeAudio/internal/handlers"
+	"extratorDeAudio/internal/storage"
+	"extratorDeAudio/internal/transcript"
 )
 
 func main() {
@@ -21,16 +25,184 @@ func main() {
 	addr := envOrDefault("APP_ADDR", ":8080")
 	uploadsDir := envOrDefault("UPLOADS_DIR", "uploads")
 	outputsDir := envOrDefault("OUTPUTS_DIR", "outputs")
+	transcriptsDir := envOrDefault("TRANSCRIPTS_DIR", outputsDir)
 	maxUploadBytes := envInt64OrDefault("MAX_UPLOAD_BYTES", 500*1024*1024)
+	uploadTimeout := envDurationOrDefault("UPLOAD_TIMEOUT", 0)
+	idleTimeout := envDurationOrDefault("HTTP_IDLE_TIMEOUT", 120*time.Second)
 	whisperBin := envOrDefault("WHISPER_BIN", "whisper-cli")
 	whisperModel := envOrDefault("WHISPER_MODEL", "/app/whisper/models/ggml-base.bin")
 	whisperLanguage := envOrDefault("WHISPER_LANGUAGE", "auto")
+	whisperModels := envMapOrDefault("WHISPER_MODELS", map[string]string{})
+	// A bad model only breaks transcriptions, so the server still starts.
+	if err := extractor.CheckModel(whisperModel); err != nil {
+		logger.Warn("WHISPER_MODEL is not usable", "path", whisperModel, "error", err)
+	}
+	for name, path := range whisperModels {
+		if err := extractor.CheckModel(path); err != nil {
+			logger.Warn("WHISPER_MODELS entry is not usable", "name", name, "path", path, "error", err)
+		}
+	}
+	fingerprint := envBoolOrDefault("FINGERPRINT_ENABLED", false)
+	progressAggregation := envOrDefault("PROGRESS_AGGREGATION", "average")
+	adminToken := envOrDefault("ADMIN_TOKEN", "")
+	apiKeys := handlers.ParseAPIKeys(envOrDefault("API_KEYS", ""))
+	uploadsPerMinute := int(envInt64OrDefault("UPLOAD_RATE_LIMIT", 0))
+	cleanupTTL := envDurationOrDefault("CLEANUP_TTL", 24*time.Hour)
+	archiveGrace := envDurationOrDefault("ARCHIVE_GRACE", 72*time.Hour)
+	robustInput := envBoolOrDefault("ROBUST_INPUT", false)
+	transcribeChunkSeconds := envInt64OrDefault("TRANSCRIBE_CHUNK_SECONDS", 0)
+	webhookWorkers := envInt64OrDefault("WEBHOOK_WORKERS", 4)
+	webhookQueueSize := envInt64OrDefault("WEBHOOK_QUEUE_SIZE", 256)
+	webhookMaxAttempts := envInt64OrDefault("WEBHOOK_MAX_ATTEMPTS", 5)
+	webhookAllowedHosts := strings.FieldsFunc(envOrDefault("WEBHOOK_ALLOWED_HOSTS", ""), func(r rune) bool { return r == ',' || r == ' ' })
+	postHookCmd := strings.Fields(envOrDefault("POST_HOOK_CMD", ""))
+	postHookTimeout := envDurationOrDefault("POST_HOOK_TIMEOUT", 2*time.Minute)
+	sendfileMode := envOrDefault("SENDFILE_MODE", "")
+	sendfilePrefix := envOrDefault("SENDFILE_PREFIX", "/internal/outputs/")
+
+	heavyJobWindow, err := handlers.ParseTimeWindow(envOrDefault("HEAVY_JOB_WINDOW", ""))
+	if err != nil {
+		logger.Error("invalid HEAVY_JOB_WINDOW", "error", err)
+		os.Exit(1)
+	}
+
+	trustedProxies, err := handlers.ParseTrustedProxies(envOrDefault("TRUSTED_PROXIES", ""))
+	if err != nil {
+		logger.Error("invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
+
+	vttCueSettings, err := transcript.ParseCueSettings(envOrDefault("VTT_CUE_SETTINGS", ""))
+	if err != nil {
+		logger.Error("invalid VTT_CUE_SETTINGS", "error", err)
+		os.Exit(1)
+	}
+
+	outputNameTemplate := envOrDefault("OUTPUT_NAME_TEMPLATE", "")
+	if err := handlers.ValidateNameTemplate(outputNameTemplate); err != nil {
+		logger.Error("invalid OUTPUT_NAME_TEMPLATE", "error", err)
+		os.Exit(1)
+	}
+
+	ffmpegThreads := int(envInt64OrDefault("FFMPEG_THREADS", 0))
+	if err := handlers.ValidateFFmpegThreads(ffmpegThreads); err != nil {
+		logger.Error("invalid FFMPEG_THREADS", "error", err)
+		os.Exit(1)
+	}
+
+	emptyTranscriptRetry := envOrDefault("EMPTY_TRANSCRIPT_RETRY", "")
+	if err := handlers.ValidateEmptyTranscriptRetry(emptyTranscriptRetry); err != nil {
+		logger.Error("invalid EMPTY_TRANSCRIPT_RETRY", "error", err)
+		os.Exit(1)
+	}
 
-	app := handlers.NewApp(logger, uploadsDir, outputsDir, maxUploadBytes, whisperBin, whisperModel, whisperLanguage)
+	overwritePolicy := envOrDefault("OVERWRITE_POLICY", "overwrite")
+	if err := handlers.ValidateOverwritePolicy(overwritePolicy); err != nil {
+		logger.Error("invalid OVERWRITE_POLICY", "error", err)
+		os.Exit(1)
+	}
+
+	enabledFormats, err := handlers.ParseEnabledFormats(envOrDefault("ENABLED_FORMATS", ""))
+	if err != nil {
+		logger.Error("invalid ENABLED_FORMATS", "error", err)
+		os.Exit(1)
+	}
+
+	allowedOrigins, err := handlers.ParseAllowedOrigins(envOrDefault("ALLOWED_ORIGINS", ""))
+	if err != nil {
+		logger.Error("invalid ALLOWED_ORIGINS", "error", err)
+		os.Exit(1)
+	}
+
+	if err := handlers.EnsureWritableDir(transcriptsDir); err != nil {
+		logger.Error("invalid TRANSCRIPTS_DIR", "error", err)
+		os.Exit(1)
+	}
+
+	var appOpts []handlers.Option
+	switch backend := envOrDefault("STORAGE_BACKEND", "local"); backend {
+	case "local":
+	case "s3":
+		s3, err := storage.NewS3(storage.S3Config{
+			Endpoint:   envOrDefault("S3_ENDPOINT", ""),
+			Region:     envOrDefault("S3_REGION", "us-east-1"),
+			Bucket:     envOrDefault("S3_BUCKET", ""),
+			AccessKey:  envOrDefault("S3_ACCESS_KEY", ""),
+			SecretKey:  envOrDefault("S3_SECRET_KEY", ""),
+			Prefix:     envOrDefault("S3_PREFIX", ""),
+			PathStyle:  envBoolOrDefault("S3_PATH_STYLE", false),
+			PresignTTL: envDurationOrDefault("S3_PRESIGN_TTL", 15*time.Minute),
+		})
+		if err != nil {
+			logger.Error("invalid s3 storage config", "error", err)
+			os.Exit(1)
+		}
+		appOpts = append(appOpts, handlers.WithStorage(s3))
+	default:
+		logger.Error("unknown storage backend", "backend", backend)
+		os.Exit(1)
+	}
+
+	app := handlers.NewApp(logger, handlers.Config{
+		UploadsDir:      uploadsDir,
+		OutputsDir:      outputsDir,
+		TranscriptsDir:  transcriptsDir,
+		MaxUploadBytes:  maxUploadBytes,
+		UploadTimeout:   uploadTimeout,
+		WhisperBin:      whisperBin,
+		WhisperModel:    whisperModel,
+		WhisperLanguage: whisperLanguage,
+		WhisperModels:   whisperModels,
+		Fingerprint:     fingerprint,
+
+		ProgressAggregation:         progressAggregation,
+		AdminToken:                  adminToken,
+		Maintenance:                 envBoolOrDefault("MAINTENANCE", false),
+		EnabledFormats:              enabledFormats,
+		AllowedOrigins:              allowedOrigins,
+		APIKeys:                     apiKeys,
+		TrustedProxies:              trustedProxies,
+		UploadsPerMinute:            uploadsPerMinute,
+		RobustInput:                 robustInput,
+		TranscribeChunkSeconds:      float64(transcribeChunkSeconds),
+		TranscribeMaxChunkSeconds:   float64(envInt64OrDefault("TRANSCRIBE_MAX_CHUNK_SECONDS", 1800)),
+		LanguageDetect:              envBoolOrDefault("LANGUAGE_DETECT", false),
+		LanguageDetectSeconds:       float64(envInt64OrDefault("LANGUAGE_DETECT_SECONDS", 30)),
+		EmptyTranscriptRetry:        emptyTranscriptRetry,
+		TranscribeMono:              envBoolOrDefault("TRANSCRIBE_MONO", true),
+		MaxTranscriptBytes:          envInt64OrDefault("MAX_TRANSCRIPT_BYTES", 50*1024*1024),
+		HeavyJobWindow:              heavyJobWindow,
+		HeavyExtractMinSeconds:      float64(envInt64OrDefault("HEAVY_EXTRACT_MIN_SECONDS", 0)),
+		VTTCueSettings:              vttCueSettings,
+		ExpiryWarning:               envDurationOrDefault("EXPIRY_WARNING", time.Hour),
+		OutputNameTemplate:          outputNameTemplate,
+		FFmpegThreads:               ffmpegThreads,
+		VerifyOutput:                envBoolOrDefault("VERIFY_OUTPUT", false),
+		OverwritePolicy:             overwritePolicy,
+		PrefetchProbe:               envBoolOrDefault("PREFETCH_PROBE", true),
+		MaxConcurrentJobs:           int(envInt64OrDefault("MAX_CONCURRENT_JOBS", 2)),
+		MaxConcurrentTranscriptions: int(envInt64OrDefault("MAX_CONCURRENT_TRANSCRIPTIONS", 1)),
+		WSMaxPerJob:                 int(envInt64OrDefault("WS_MAX_PER_JOB", 10)),
+		WSMaxConnections:            int(envInt64OrDefault("WS_MAX_CONNECTIONS", 1000)),
+		MinFreeDiskBytes:            envInt64OrDefault("MIN_FREE_DISK_BYTES", 256*1024*1024),
+		UploadSpaceFactor:           envFloatOrDefault("UPLOAD_SPACE_FACTOR", 2),
+		WebhookWorkers:              int(webhookWorkers),
+		WebhookQueueSize:            int(webhookQueueSize),
+		WebhookMaxAttempts:          int(webhookMaxAttempts),
+		WebhookAllowedHosts:         webhookAllowedHosts,
+		SendfileMode:                sendfileMode,
+		SendfilePrefix:              sendfilePrefix,
+		PostHookCmd:                 postHookCmd,
+		PostHookTimeout:             postHookTimeout,
+		MaxQueueDepth:               int(envInt64OrDefault("MAX_QUEUE_DEPTH", 20)),
+		IDFormat:                    envOrDefault("JOB_ID_FORMAT", "hex"),
+		IDPrefix:                    envOrDefault("JOB_ID_PREFIX", ""),
+	}, appOpts...)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	app.StartCleanupLoop(ctx, 30*time.Minute, 24*time.Hour)
+	app.StartCleanupLoop(ctx, 30*time.Minute, cleanupTTL, archiveGrace)
+	app.StartWebhookWorkers(ctx)
 
 	srv := &http.Server{
 		Addr:              addr,
@@ -38,7 +210,7 @@ func main() {
 		ReadHeaderTimeout: 10 * time.Second,
 		ReadTimeout:       60 * time.Second,
 		WriteTimeout:      60 * time.Second,
-		IdleTimeout:       120 * time.Second,
+		IdleTimeout:       idleTimeout,
 	}
 
 	go func() {
@@ -83,3 +255,58 @@ func envInt64OrDefault(key string, fallback int64) int64 {
 	}
 	return parsed
 }
+
+func envBoolOrDefault(key string, fallback bool) bool {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(val)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+// envMapOrDefault parses a comma-separated list of name=value pairs.
+func envMapOrDefault(key string, fallback map[string]string) map[string]string {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	parsed := make(map[string]string)
+	for _, pair := range strings.Split(val, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			continue
+		}
+		parsed[name] = value
+	}
+	return parsed
+}
+
+func envFloatOrDefault(key string, fallback float64) float64 {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+func envDurationOrDefault(key string, fallback time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(val)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
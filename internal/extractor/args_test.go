@@ -0,0 +1,292 @@
+package extractor
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// argsBefore returns the arguments preceding the first flag.
+func argsBefore(args []string, flag string) []string {
+	if i := slices.Index(args, flag); i >= 0 {
+		return args[:i]
+	}
+	return args
+}
+
+// optionValues returns the value following each occurrence of flag.
+func optionValues(args []string, flag string) []string {
+	var values []string
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == flag {
+			values = append(values, args[i+1])
+		}
+	}
+	return values
+}
+
+func TestEncodeArgsTrimWithTargetDuration(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       ExtractOptions
+		wantInputT string
+		wantSS     []string
+	}{
+		{
+			name:       "fast seek",
+			opts:       ExtractOptions{Format: "mp3", Quality: "medium", Start: 10, End: 25, TargetDuration: 30},
+			wantInputT: "15.000",
+			wantSS:     []string{"10.000"},
+		},
+		{
+			name:       "accurate seek",
+			opts:       ExtractOptions{Format: "mp3", Quality: "medium", Start: 10, End: 25, SeekMode: SeekAccurate, TargetDuration: 30},
+			wantInputT: "25.000",
+			wantSS:     []string{"10.000"},
+		},
+		{
+			name:       "end only",
+			opts:       ExtractOptions{Format: "wav", End: 5, TargetDuration: 8},
+			wantInputT: "5.000",
+		},
+	}
+	s := newTestService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := s.encodeArgs(context.Background(), "in.mp4", tt.opts)
+			input := argsBefore(args, "-i")
+			output := args[slices.Index(args, "-i")+2:]
+
+			if got := optionValues(input, "-t"); !slices.Equal(got, []string{tt.wantInputT}) {
+				t.Errorf("input -t = %v, want [%s] in %v", got, tt.wantInputT, args)
+			}
+			if got, want := optionValues(output, "-t"), formatSeconds(tt.opts.TargetDuration); !slices.Equal(got, []string{want}) {
+				t.Errorf("output -t = %v, want only the target duration in %v", got, args)
+			}
+			if got := optionValues(args, "-ss"); !slices.Equal(got, tt.wantSS) {
+				t.Errorf("-ss = %v, want %v", got, tt.wantSS)
+			}
+			af := strings.Join(optionValues(args, "-af"), ",")
+			if !strings.HasSuffix(af, "apad") {
+				t.Errorf("-af = %q, want apad last", af)
+			}
+		})
+	}
+}
+
+func TestInputArgsTrimIsInputOption(t *testing.T) {
+	args := inputArgs("in.mp4", ExtractOptions{Start: 5, End: 10, SeekMode: SeekAccurate, InputFormat: "mpegts"})
+	want := []string{"-t", "10.000", "-f", "mpegts", "-i", "in.mp4", "-ss", "5.000"}
+	if !slices.Equal(args, want) {
+		t.Errorf("inputArgs = %v, want %v", args, want)
+	}
+}
+
+func TestExtractArgsChannels(t *testing.T) {
+	formats := []string{"mp3", "wav", "aac", FormatM4A, "flac", "ogg", FormatOpus, FormatHLS}
+	channels := []struct {
+		layout string
+		want   []string
+	}{
+		{ChannelsMono, []string{"1"}},
+		{ChannelsStereo, []string{"2"}},
+		{ChannelsSource, nil},
+		{"", nil},
+	}
+	s := newTestService()
+	for _, format := range formats {
+		for _, ch := range channels {
+			t.Run(format+"/"+ch.layout, func(t *testing.T) {
+				output := "/tmp/out." + format
+				args := s.extractArgs(context.Background(), "in.mp4", output, ExtractOptions{Format: format, Quality: "medium", Channels: ch.layout})
+				if args[len(args)-1] != output {
+					t.Fatalf("output path is not last: %v", args)
+				}
+				if got := optionValues(args, "-ac"); !slices.Equal(got, ch.want) {
+					t.Fatalf("-ac = %v, want %v in %v", got, ch.want, args)
+				}
+				if ch.want != nil && slices.Index(args, "-ac") < slices.Index(args, "-i") {
+					t.Errorf("-ac is an input option: %v", args)
+				}
+			})
+		}
+	}
+}
+
+func TestExtractArgsStreamCopyKeepsLayout(t *testing.T) {
+	args := newTestService().extractArgs(context.Background(), "in.mp4", "out.mka", ExtractOptions{Format: "mka", Channels: ChannelsMono})
+	if slices.Contains(args, "-ac") {
+		t.Errorf("stream copy got -ac: %v", args)
+	}
+}
+
+func TestCodecAndQualityArgsOpus(t *testing.T) {
+	tests := []struct {
+		quality    string
+		sampleRate int
+		want       []string
+	}{
+		{"low", 0, []string{"-f", "opus", "-codec:a", "libopus", "-b:a", "32k"}},
+		{"medium", 0, []string{"-f", "opus", "-codec:a", "libopus", "-b:a", "64k"}},
+		{"", 0, []string{"-f", "opus", "-codec:a", "libopus", "-b:a", "64k"}},
+		{"high", 0, []string{"-f", "opus", "-codec:a", "libopus", "-b:a", "128k"}},
+		{"original", 0, []string{"-f", "opus", "-codec:a", "libopus", "-b:a", "128k"}},
+		{"medium", 44100, []string{"-f", "opus", "-codec:a", "libopus", "-b:a", "64k", "-ar", "48000"}},
+		{"medium", 22050, []string{"-f", "opus", "-codec:a", "libopus", "-b:a", "64k", "-ar", "24000"}},
+		{"medium", 16000, []string{"-f", "opus", "-codec:a", "libopus", "-b:a", "64k", "-ar", "16000"}},
+		{"medium", 8000, []string{"-f", "opus", "-codec:a", "libopus", "-b:a", "64k", "-ar", "8000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.quality+"/"+strconv.Itoa(tt.sampleRate), func(t *testing.T) {
+			got := codecAndQualityArgs(FormatOpus, tt.quality, ChannelsSource, tt.sampleRate)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("codecAndQualityArgs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoderTableCoversEveryQuality(t *testing.T) {
+	for format, enc := range encoders {
+		if _, ok := enc.qualities["medium"]; !ok {
+			t.Errorf("%s has no medium quality to fall back to", format)
+		}
+		for _, quality := range []string{"low", "medium", "high", "original"} {
+			typical, min, max := bitrateRange(format, quality)
+			if min <= 0 || min > typical || typical > max {
+				t.Errorf("%s/%s bitrate range = %d, %d, %d", format, quality, typical, min, max)
+			}
+			if StreamCopy(format) {
+				t.Errorf("%s reported as stream copy", format)
+			}
+			args := codecAndQualityArgs(format, quality, ChannelsSource, 0)
+			if got := optionValues(args, "-codec:a"); !slices.Equal(got, []string{enc.codec}) {
+				t.Errorf("%s/%s codec = %v, want %s", format, quality, got, enc.codec)
+			}
+		}
+	}
+}
+
+func TestOutputName(t *testing.T) {
+	tests := []struct {
+		id, format, want string
+	}{
+		{"abc", FormatOpus, "abc.opus"},
+		{"abc", ".OPUS", "abc.opus"},
+		{"abc_0", FormatOpus, "abc_0.opus"},
+		{"abc", "", "abc.mp3"},
+		{"../abc", FormatM4A, "abc.m4a"},
+	}
+	for _, tt := range tests {
+		if got := OutputName(tt.id, tt.format); got != tt.want {
+			t.Errorf("OutputName(%q, %q) = %q, want %q", tt.id, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestExtractArgsOpusOutput(t *testing.T) {
+	output := OutputName("job", FormatOpus)
+	args := newTestService().extractArgs(context.Background(), "in.mp4", output, ExtractOptions{Format: FormatOpus, Quality: "high"})
+	if args[len(args)-1] != "job.opus" {
+		t.Fatalf("output = %q, want job.opus", args[len(args)-1])
+	}
+	if got := optionValues(args, "-f"); !slices.Equal(got, []string{"opus"}) {
+		t.Errorf("-f = %v, want [opus]", got)
+	}
+	if got := optionValues(args, "-codec:a"); !slices.Equal(got, []string{"libopus"}) {
+		t.Errorf("-codec:a = %v, want [libopus]", got)
+	}
+}
+
+func TestEncodeArgsPreserveMetadata(t *testing.T) {
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"mp3", []string{"0"}},
+		{"flac", []string{"0"}},
+		{"ogg", []string{"0"}},
+		{FormatOpus, []string{"0"}},
+		{FormatM4A, []string{"0"}},
+		{"mka", []string{"0"}},
+		{"wav", nil},
+		{"aac", nil},
+		{FormatHLS, nil},
+	}
+	s := newTestService()
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			args := s.encodeArgs(context.Background(), "in.mp4", ExtractOptions{Format: tt.format, PreserveMetadata: true})
+			if got := optionValues(args, "-map_metadata"); !slices.Equal(got, tt.want) {
+				t.Errorf("-map_metadata = %v, want %v in %v", got, tt.want, args)
+			}
+			if tt.want != nil && !slices.Equal(optionValues(args, "-map_metadata:s:a"), []string{"0:s:a"}) {
+				t.Errorf("stream tags not mapped: %v", args)
+			}
+
+			off := s.encodeArgs(context.Background(), "in.mp4", ExtractOptions{Format: tt.format})
+			if slices.Contains(off, "-map_metadata") {
+				t.Errorf("tags mapped without PreserveMetadata: %v", off)
+			}
+		})
+	}
+}
+
+func TestEncodeArgsPreserveMetadataFromTagsInput(t *testing.T) {
+	for _, format := range []string{"mp3", "flac", "ogg"} {
+		opts := ExtractOptions{Format: format, PreserveMetadata: true, tagsInput: "orig.mkv", tagsStreams: "s:a:1"}
+		args := newTestService().encodeArgs(context.Background(), "norm.wav", opts)
+		if got := optionValues(args, "-i"); !slices.Equal(got, []string{"norm.wav", "orig.mkv"}) {
+			t.Errorf("%s: inputs = %v", format, got)
+		}
+		if got := optionValues(args, "-map"); !slices.Equal(got, []string{"0:a"}) {
+			t.Errorf("%s: -map = %v, want [0:a]", format, got)
+		}
+		if got := optionValues(args, "-map_metadata"); !slices.Equal(got, []string{"1"}) {
+			t.Errorf("%s: -map_metadata = %v, want [1]", format, got)
+		}
+		if got := optionValues(args, "-map_metadata:s:a"); !slices.Equal(got, []string{"1:s:a:1"}) {
+			t.Errorf("%s: -map_metadata:s:a = %v", format, got)
+		}
+	}
+}
+
+func TestChapterArgsKeepContainerOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		audio       string
+		noFaststart bool
+		wantMov     []string
+	}{
+		{"m4a keeps faststart", "out.m4a", false, []string{"+faststart"}},
+		{"m4a without faststart", "out.m4a", true, nil},
+		{"opus", "out.opus", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := chapterArgs(tt.audio, "chapters.txt", "tmp"+tt.audio, tt.noFaststart)
+			if got := optionValues(args, "-movflags"); !slices.Equal(got, tt.wantMov) {
+				t.Errorf("-movflags = %v, want %v", got, tt.wantMov)
+			}
+			if got := optionValues(args, "-map"); !slices.Equal(got, []string{"0:a", "0:v?"}) {
+				t.Errorf("-map = %v, want the audio plus any cover art", got)
+			}
+			if args[len(args)-1] != "tmp"+tt.audio {
+				t.Errorf("last arg = %q, want the temporary output", args[len(args)-1])
+			}
+		})
+	}
+}
+
+func TestStreamCopy(t *testing.T) {
+	for _, format := range []string{"", "MP3", " opus ", FormatHLS} {
+		if StreamCopy(format) {
+			t.Errorf("StreamCopy(%q) = true, want encoded", format)
+		}
+	}
+	if !StreamCopy("mka") {
+		t.Error(`StreamCopy("mka") = false, want copy`)
+	}
+}
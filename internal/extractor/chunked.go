@@ -0,0 +1,293 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"extratorDeAudio/internal/transcript"
+)
+
+// chunkCheckpoint records which chunks of a chunked transcription are done,
+// so a retry only transcribes the missing ones.
+type chunkCheckpoint struct {
+	// InputDigest is the SHA-256 of the audio the chunks came from, so a
+	// replaced file invalidates them. A content hash rather than the mtime,
+	// since with remote storage each retry downloads a fresh copy.
+	InputDigest  string  `json:"input_digest"`
+	ChunkSeconds float64 `json:"chunk_seconds"`
+	Chunks       int     `json:"chunks"`
+	// Options identifies the whisper settings; changing them invalidates the
+	// already transcribed chunks.
+	Options TranscribeOptions `json:"options"`
+	Done    []bool            `json:"done"`
+}
+
+// matches reports whether c was saved for the same input and settings as
+// other, so its finished chunks can be reused.
+func (c *chunkCheckpoint) matches(other chunkCheckpoint) bool {
+	return c.InputDigest == other.InputDigest &&
+		c.ChunkSeconds == other.ChunkSeconds && c.Chunks == other.Chunks && c.Options == other.Options
+}
+
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func checkpointPath(outputBasePath string) string {
+	return outputBasePath + ".checkpoint.json"
+}
+
+func chunkDir(outputBasePath string) string {
+	return outputBasePath + "_chunks"
+}
+
+// RemoveChunkArtifacts deletes the checkpoint and partial chunk outputs of a
+// chunked transcription.
+func RemoveChunkArtifacts(outputBasePath string) {
+	_ = os.Remove(checkpointPath(outputBasePath))
+	_ = os.RemoveAll(chunkDir(outputBasePath))
+}
+
+// TranscribeChunked transcribes the audio in chunks of chunkSeconds and merges
+// the results into outputBasePath.txt/.srt/.vtt. Finished chunks are checkpointed
+// on disk so that a retry of a failed or timed-out run of the same job resumes
+// where it stopped, as long as the input audio is unchanged. Jobs live in
+// memory, so this doesn't carry over a server restart. Audio shorter than one
+// chunk is transcribed in a single pass.
+func (s *Service) TranscribeChunked(ctx context.Context, inputAudioPath, outputBasePath string, chunkSeconds float64, opts TranscribeOptions, cb ProgressCallback) error {
+	duration, err := s.probeDuration(ctx, inputAudioPath)
+	if err != nil || chunkSeconds <= 0 || duration <= chunkSeconds {
+		return s.TranscribeAudio(ctx, inputAudioPath, outputBasePath, opts, cb)
+	}
+
+	chunks := int(math.Ceil(duration / chunkSeconds))
+	dir := chunkDir(outputBasePath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create chunk dir: %w", err)
+	}
+
+	fresh := chunkCheckpoint{ChunkSeconds: chunkSeconds, Chunks: chunks, Options: opts, Done: make([]bool, chunks)}
+	if fresh.InputDigest, err = fileDigest(inputAudioPath); err != nil {
+		return fmt.Errorf("failed to hash audio: %w", err)
+	}
+	checkpoint := loadCheckpoint(outputBasePath)
+	if checkpoint == nil || !checkpoint.matches(fresh) {
+		checkpoint = &fresh
+	}
+
+	for i := 0; i < chunks; i++ {
+		chunkBase := filepath.Join(dir, fmt.Sprintf("chunk_%04d", i))
+		if checkpoint.Done[i] && fileExists(chunkBase+".txt") && fileExists(chunkBase+".srt") {
+			continue
+		}
+
+		if cb != nil {
+			cb(chunkPercent(i, 0, chunks), "processing", fmt.Sprintf("transcrevendo parte %d de %d", i+1, chunks))
+		}
+
+		start := float64(i) * chunkSeconds
+		wavPath := chunkBase + ".wav"
+		if err := s.extractChunk(ctx, inputAudioPath, wavPath, start, chunkSeconds); err != nil {
+			return err
+		}
+
+		err := s.TranscribeAudio(ctx, wavPath, chunkBase, opts, func(percent int, status, message string) {
+			if cb != nil && status == "processing" {
+				cb(chunkPercent(i, percent, chunks), status, fmt.Sprintf("transcrevendo parte %d de %d", i+1, chunks))
+			}
+		})
+		_ = os.Remove(wavPath)
+		if err != nil {
+			return err
+		}
+
+		checkpoint.Done[i] = true
+		if err := saveCheckpoint(outputBasePath, checkpoint); err != nil {
+			s.logger.Warn("failed to save transcription checkpoint", "error", err)
+		}
+	}
+
+	if err := mergeChunks(dir, outputBasePath, chunks, chunkSeconds); err != nil {
+		return err
+	}
+	RemoveChunkArtifacts(outputBasePath)
+
+	if cb != nil {
+		cb(100, "completed", "transcrição concluída")
+	}
+	return nil
+}
+
+// chunkPercent maps progress inside chunk i to the overall percentage.
+func chunkPercent(i, percent, chunks int) int {
+	return (i*100 + percent) / chunks
+}
+
+// ExtractClip cuts [start, start+length) of the input into a 16kHz mono WAV
+// suitable for transcription. A length <= 0 runs to the end of the input.
+func (s *Service) ExtractClip(ctx context.Context, inputPath, outputPath string, start, length float64) error {
+	return s.extractChunk(ctx, inputPath, outputPath, start, length)
+}
+
+// extractChunk cuts a 16kHz mono WAV window, the input format whisper prefers.
+func (s *Service) extractChunk(ctx context.Context, inputPath, outputPath string, start, length float64) error {
+	args := []string{"-y", "-v", "error", "-ss", formatSeconds(start)}
+	if length > 0 {
+		args = append(args, "-t", formatSeconds(length))
+	}
+	args = append(args,
+		"-i", inputPath,
+		"-vn", "-ac", "1", "-ar", "16000",
+		"-codec:a", "pcm_s16le",
+		outputPath,
+	)
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	s.recordUsage(ctx, cmd)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if logOut := strings.TrimSpace(stderr.String()); logOut != "" {
+			if isDiskFullLine(logOut) {
+				return ErrDiskFull
+			}
+			return fmt.Errorf("failed to cut audio chunk: %s", compactLogLine(logOut))
+		}
+		return fmt.Errorf("failed to cut audio chunk: %w", err)
+	}
+	return nil
+}
+
+// mergeChunks joins the per-chunk outputs, shifting SRT cues by each chunk's
+// offset in the full audio.
+func mergeChunks(dir, outputBasePath string, chunks int, chunkSeconds float64) error {
+	var segments []transcript.Segment
+	var words []transcript.Word
+	var text bytes.Buffer
+	hasWords := true
+
+	for i := 0; i < chunks; i++ {
+		chunkBase := filepath.Join(dir, fmt.Sprintf("chunk_%04d", i))
+
+		f, err := os.Open(chunkBase + ".srt")
+		if err != nil {
+			return fmt.Errorf("missing chunk transcript: %w", err)
+		}
+		parsed, err := transcript.ParseSRT(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("invalid chunk transcript: %w", err)
+		}
+		offset := time.Duration(float64(i) * chunkSeconds * float64(time.Second))
+		segments = append(segments, transcript.Shift(parsed, offset)...)
+
+		if hasWords {
+			chunkWords, err := readWordsFile(WordsPath(chunkBase))
+			if err != nil {
+				hasWords = false
+			} else {
+				words = append(words, transcript.ShiftWords(chunkWords, offset)...)
+			}
+		}
+
+		data, err := os.ReadFile(chunkBase + ".txt")
+		if err != nil {
+			return fmt.Errorf("missing chunk transcript: %w", err)
+		}
+		if chunkText := strings.TrimSpace(string(data)); chunkText != "" {
+			text.WriteString(chunkText)
+			text.WriteString("\n")
+		}
+	}
+
+	srt, err := os.Create(outputBasePath + ".srt")
+	if err != nil {
+		return err
+	}
+	if err := transcript.WriteSRT(srt, segments); err != nil {
+		srt.Close()
+		return err
+	}
+	if err := srt.Close(); err != nil {
+		return err
+	}
+	vtt, err := os.Create(outputBasePath + ".vtt")
+	if err != nil {
+		return err
+	}
+	if err := transcript.WriteVTT(vtt, segments); err != nil {
+		vtt.Close()
+		return err
+	}
+	if err := vtt.Close(); err != nil {
+		return err
+	}
+	if hasWords {
+		if err := writeWordsFile(WordsPath(outputBasePath), words); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(outputBasePath+".txt", text.Bytes(), 0o644)
+}
+
+func readWordsFile(path string) ([]transcript.Word, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return transcript.ReadWords(f)
+}
+
+func loadCheckpoint(outputBasePath string) *chunkCheckpoint {
+	data, err := os.ReadFile(checkpointPath(outputBasePath))
+	if err != nil {
+		return nil
+	}
+	var checkpoint chunkCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil || len(checkpoint.Done) != checkpoint.Chunks {
+		return nil
+	}
+	return &checkpoint
+}
+
+// saveCheckpoint writes atomically so a crash never leaves a torn file.
+func saveCheckpoint(outputBasePath string, checkpoint *chunkCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	tmp := checkpointPath(outputBasePath) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, checkpointPath(outputBasePath))
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,37 @@
+package extractor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpointMatchesOnlySameInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audio.mp3")
+	if err := os.WriteFile(path, []byte("first take"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := fileDigest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := chunkCheckpoint{InputDigest: digest, ChunkSeconds: 600, Chunks: 3, Done: []bool{true, false, false}}
+
+	if !saved.matches(chunkCheckpoint{InputDigest: digest, ChunkSeconds: 600, Chunks: 3}) {
+		t.Error("checkpoint for the same input and settings should match")
+	}
+
+	if err := os.WriteFile(path, []byte("second take"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	replaced, err := fileDigest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.matches(chunkCheckpoint{InputDigest: replaced, ChunkSeconds: 600, Chunks: 3}) {
+		t.Error("checkpoint must not match a replaced input")
+	}
+	if saved.matches(chunkCheckpoint{InputDigest: digest, ChunkSeconds: 300, Chunks: 3}) {
+		t.Error("checkpoint must not match other chunk settings")
+	}
+}
@@ -0,0 +1,62 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// detectedLanguageRe matches whisper.cpp's "auto-detected language: pt (p = 0.97)".
+var detectedLanguageRe = regexp.MustCompile(`auto-detected language:\s*([a-z]{2,3})\b`)
+
+// DetectLanguage runs whisper language detection on the first seconds of
+// the audio only, so long files don't pay for a full decode just to learn
+// the language. opts.Model picks the model; the language is always auto.
+func (s *Service) DetectLanguage(ctx context.Context, inputAudioPath string, seconds float64, opts TranscribeOptions) (string, error) {
+	model := s.whisperModel
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	if model == "" {
+		return "", errors.New("whisper model is not configured")
+	}
+	if err := CheckModel(model); err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "detect-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	sample := filepath.Join(dir, "sample.wav")
+	if err := s.extractChunk(ctx, inputAudioPath, sample, 0, seconds); err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, s.whisperBin, "-m", model, "-f", sample, "-l", "auto", "-dl")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = cmd.Run()
+	s.recordUsage(ctx, cmd)
+	if err != nil {
+		if logOut := strings.TrimSpace(out.String()); logOut != "" {
+			return "", fmt.Errorf("language detection failed: %s", compactLogLine(logOut))
+		}
+		return "", fmt.Errorf("language detection failed: %w", err)
+	}
+
+	m := detectedLanguageRe.FindStringSubmatch(out.String())
+	if m == nil {
+		return "", errors.New("language detection produced no result")
+	}
+	return m[1], nil
+}
@@ -0,0 +1,53 @@
+package extractor
+
+import (
+	"context"
+	"strings"
+)
+
+// Surround downmix modes for ExtractOptions.SurroundDownmix.
+const (
+	DownmixDefault  = "default"
+	DownmixDialogue = "dialogue"
+)
+
+// dialogueMatrices fold surround layouts to stereo with the center channel at
+// full weight and the fronts at -3dB, so dialogue stays above music and
+// effects. LFE is dropped as in the standard downmix. "<" renormalizes the
+// gains to avoid clipping. Channel numbers follow ffmpeg's layout order:
+// FL FR FC LFE, then BL BR (5.1) or SL SR (5.1(side)), then SL SR (7.1).
+var dialogueMatrices = map[string]string{
+	"5.1":       "pan=stereo|FL<c2+0.707*c0+0.5*c4|FR<c2+0.707*c1+0.5*c5",
+	"5.1(side)": "pan=stereo|FL<c2+0.707*c0+0.5*c4|FR<c2+0.707*c1+0.5*c5",
+	"7.1":       "pan=stereo|FL<c2+0.707*c0+0.5*c4+0.5*c6|FR<c2+0.707*c1+0.5*c5+0.5*c7",
+}
+
+// downmix returns the filter that folds the selected stream to stereo, and
+// whether the output must be forced to two channels. Mono and stereo input,
+// single-channel extraction and an empty mode are left untouched.
+func (s *Service) downmix(ctx context.Context, inputPath string, opts ExtractOptions) (string, bool) {
+	if opts.SurroundDownmix == "" || opts.SingleChannel {
+		return "", false
+	}
+	streams, err := s.ProbeAudioStreams(ctx, inputPath)
+	if err != nil {
+		s.logger.Warn("could not probe channel layout, skipping downmix", "error", err)
+		return "", false
+	}
+	track := 0
+	if opts.SingleTrack {
+		track = opts.Track
+	}
+	if track >= len(streams) || streams[track].Channels <= 2 {
+		return "", false
+	}
+
+	layout := strings.TrimSpace(streams[track].ChannelLayout)
+	if opts.SurroundDownmix == DownmixDialogue {
+		if matrix, ok := dialogueMatrices[layout]; ok {
+			return matrix, false
+		}
+		s.logger.Info("no dialogue matrix for channel layout, using default downmix", "layout", layout)
+	}
+	return "", true
+}
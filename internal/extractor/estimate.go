@@ -0,0 +1,48 @@
+package extractor
+
+import "context"
+
+// SizeEstimate is the expected size of an extraction output. Constant bitrate
+// formats have MinBytes == MaxBytes; VBR and lossless formats get a range.
+type SizeEstimate struct {
+	DurationSeconds float64 `json:"duration_seconds"`
+	BitrateKbps     int     `json:"bitrate_kbps"`
+	Bytes           int64   `json:"bytes"`
+	MinBytes        int64   `json:"min_bytes"`
+	MaxBytes        int64   `json:"max_bytes"`
+	Exact           bool    `json:"exact"`
+}
+
+// bitrateRange returns the typical bitrate in kbps for a format and quality,
+// with the range VBR and lossless outputs fall in, from the encoder table.
+func bitrateRange(format, quality string) (typical, min, max int) {
+	_, setting, ok := lookupEncoder(format, quality)
+	if !ok {
+		// Stream copy keeps the source bitrate.
+		return 192, 96, 320
+	}
+	return setting.kbps, setting.minKbps, setting.maxKbps
+}
+
+// EstimateSize estimates the output size of extracting inputPath with the
+// given format and quality, honouring the trim window when set.
+func (s *Service) EstimateSize(ctx context.Context, inputPath, format, quality string, start, end float64) (SizeEstimate, error) {
+	total, err := s.probeDuration(ctx, inputPath)
+	if err != nil {
+		return SizeEstimate{}, err
+	}
+	duration := clipDuration(total, start, end)
+
+	typical, min, max := bitrateRange(format, quality)
+	bytesFor := func(kbps int) int64 {
+		return int64(float64(kbps) * 1000 / 8 * duration)
+	}
+	return SizeEstimate{
+		DurationSeconds: duration,
+		BitrateKbps:     typical,
+		Bytes:           bytesFor(typical),
+		MinBytes:        bytesFor(min),
+		MaxBytes:        bytesFor(max),
+		Exact:           min == max,
+	}, nil
+}
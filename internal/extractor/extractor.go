@@ -4,16 +4,23 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
+// ErrDiskFull is returned when ffmpeg fails because the output device ran out of space.
+var ErrDiskFull = errors.New("disco cheio: sem espaço para gravar o arquivo de saída")
+
 // ProgressCallback receives updates emitted by execution.
 type ProgressCallback func(percent int, status, message string)
 
@@ -23,6 +30,10 @@ type Service struct {
 	whisperBin      string
 	whisperModel    string
 	whisperLanguage string
+
+	wordsMu        sync.Mutex
+	wordsProbed    bool
+	wordsSupported bool
 }
 
 func NewService(logger *slog.Logger, whisperBin, whisperModel, whisperLanguage string) *Service {
@@ -40,20 +51,219 @@ func NewService(logger *slog.Logger, whisperBin, whisperModel, whisperLanguage s
 	}
 }
 
+// Seek modes for trimmed extractions.
+const (
+	// SeekFast places -ss before -i: ffmpeg jumps to the nearest keyframe,
+	// which is quick but may start slightly off the requested time.
+	SeekFast = "fast"
+	// SeekAccurate places -ss after -i: ffmpeg decodes from the beginning and
+	// discards audio until the exact timestamp, which is slower on long inputs.
+	SeekAccurate = "accurate"
+)
+
+// ExtractOptions describes how a single extraction is encoded.
+type ExtractOptions struct {
+	Format  string
+	Quality string
+	// Start and End trim the input, in seconds. Zero means unset.
+	Start float64
+	End   float64
+	// SeekMode is SeekFast (default) or SeekAccurate.
+	SeekMode string
+	// InputFormat forces the demuxer (-f before -i) for inputs ffmpeg can't
+	// detect on its own.
+	InputFormat string
+	// CopyTimestamps keeps the source timeline (-copyts -start_at_zero), so a
+	// trimmed clip carries its original start time.
+	CopyTimestamps bool
+	// GainDB applies a fixed volume change in decibels (volume filter).
+	GainDB float64
+	// Normalize evens out loudness with single-pass loudnorm. Ignored for
+	// stream copy, which can't be filtered.
+	Normalize bool
+	// SingleChannel keeps only the source channel at index Channel (0-based),
+	// downmixed to a mono output.
+	SingleChannel bool
+	Channel       int
+	// SingleTrack extracts the audio stream at index Track (0-based among
+	// audio streams, -map 0:a:<Track>) instead of ffmpeg's default pick.
+	SingleTrack bool
+	Track       int
+	// RobustInput first converts inputs that fail the probe (or the first
+	// extraction attempt) into an intermediate WAV, then extracts from it.
+	RobustInput bool
+	// SurroundDownmix folds surround input (5.1, 7.1) to stereo: DownmixDefault
+	// uses ffmpeg's standard matrix, DownmixDialogue a matrix that favors the
+	// center (dialogue) channel. Empty keeps the source channel layout.
+	SurroundDownmix string
+	// PreserveMetadata carries the source's global and audio stream tags
+	// (artist, title, date...) into outputs whose container can hold them.
+	PreserveMetadata bool
+	// Channels sets the output layout: ChannelsMono, ChannelsStereo, or
+	// ChannelsSource/empty to keep the source's. Ignored with SingleChannel,
+	// which is always mono.
+	Channels string
+	// SampleRate resamples the output (-ar), in Hz. Zero keeps the source
+	// rate.
+	SampleRate int
+	// NoFaststart leaves the index (moov atom) of MP4-based outputs at the
+	// end instead of moving it to the front; see containers.
+	NoFaststart bool
+	// Threads caps ffmpeg's encoder threads (-threads). Zero lets ffmpeg pick
+	// based on the available cores.
+	Threads int
+	// TargetDuration makes the output exactly this long, in seconds: longer
+	// audio is cut (-t) and shorter audio padded with silence (apad). Stream
+	// copy can only be cut.
+	TargetDuration float64
+	// Overwrite is the policy for an output that already exists:
+	// OverwriteAlways (default when empty), OverwriteSkip or OverwriteFail.
+	Overwrite string
+	// SourceDuration is the input's length in seconds when the caller
+	// already probed it (e.g. right after the upload); zero probes it again
+	// for progress.
+	SourceDuration float64
+
+	// tagsInput, when set, is read as a second input only for its tags:
+	// the normalized WAV intermediate has none, so PreserveMetadata takes
+	// them from the original file. tagsInputFormat is its -f and
+	// tagsStreams the audio stream specifier to copy stream tags from.
+	tagsInput       string
+	tagsInputFormat string
+	tagsStreams     string
+}
+
 // ExtractAudio runs ffmpeg and reports progress using callback.
-func (s *Service) ExtractAudio(ctx context.Context, inputPath, outputPath, format, quality string, cb ProgressCallback) error {
-	duration, err := s.probeDuration(ctx, inputPath)
+func (s *Service) ExtractAudio(ctx context.Context, inputPath, outputPath string, opts ExtractOptions, cb ProgressCallback) error {
+	reuse, err := s.existingOutput(ctx, inputPath, outputPath, opts)
 	if err != nil {
-		s.logger.Warn("could not probe duration, progress will be coarse", "error", err)
+		return err
+	}
+	if reuse {
+		if cb != nil {
+			cb(100, "completed", "arquivo existente reaproveitado")
+		}
+		return nil
 	}
 
-	args := []string{"-y", "-i", inputPath, "-vn"}
-	args = append(args, codecAndQualityArgs(format, quality)...)
+	if opts.RobustInput && s.needsNormalization(ctx, inputPath, opts.InputFormat) {
+		s.logger.Info("input failed probe, normalizing before extraction", "input", inputPath)
+		err = s.extractNormalized(ctx, inputPath, outputPath, opts, cb)
+	} else {
+		err = s.extract(ctx, inputPath, outputPath, opts, cb)
+		if err != nil && opts.RobustInput && ctx.Err() == nil && !errors.Is(err, ErrDiskFull) {
+			s.logger.Warn("extraction failed, retrying from normalized input", "input", inputPath, "error", err)
+			err = s.extractNormalized(ctx, inputPath, outputPath, opts, cb)
+		}
+	}
+	if err == nil {
+		s.recordSettings(ctx, inputPath, outputPath, opts)
+	}
+	return err
+}
+
+// needsNormalization runs a quick probe of the first audio stream and reports
+// whether ffprobe could not identify it.
+func (s *Service) needsNormalization(ctx context.Context, inputPath, inputFormat string) bool {
+	args := []string{"-v", "error"}
+	if inputFormat != "" {
+		args = append(args, "-f", inputFormat)
+	}
 	args = append(args,
-		"-progress", "pipe:1",
-		"-nostats",
-		outputPath,
+		"-select_streams", "a:0",
+		"-show_entries", "stream=codec_name",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		inputPath,
 	)
+	out, err := exec.CommandContext(ctx, "ffprobe", args...).Output()
+	return err != nil || strings.TrimSpace(string(out)) == ""
+}
+
+// extractNormalized converts the input into a PCM WAV intermediate, tolerating
+// corrupt packets, and extracts from that file instead.
+func (s *Service) extractNormalized(ctx context.Context, inputPath, outputPath string, opts ExtractOptions, cb ProgressCallback) error {
+	if cb != nil {
+		cb(0, "processing", "convertendo entrada para formato compatível")
+	}
+
+	intermediate := outputPath + ".normalized.wav"
+	defer os.Remove(intermediate)
+
+	args := []string{"-y", "-v", "error", "-err_detect", "ignore_err", "-fflags", "+genpts+discardcorrupt"}
+	if opts.InputFormat != "" {
+		args = append(args, "-f", opts.InputFormat)
+	}
+	// The default track is optional so an input without audio fails later
+	// with the usual message; an explicit track must exist.
+	trackMap := "0:a:0?"
+	if opts.SingleTrack {
+		trackMap = fmt.Sprintf("0:a:%d", opts.Track)
+	}
+	args = append(args, "-i", inputPath, "-vn", "-map", trackMap, "-codec:a", "pcm_s16le", "-f", "wav", intermediate)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	s.recordUsage(ctx, cmd)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logOut := strings.TrimSpace(stderr.String())
+		if isDiskFullLine(logOut) {
+			return ErrDiskFull
+		}
+		if opts.SingleTrack && strings.Contains(logOut, "matches no streams") {
+			return fmt.Errorf("a faixa de áudio %d não existe no arquivo", opts.Track)
+		}
+		if logOut != "" {
+			return fmt.Errorf("input normalization failed: %s", compactLogLine(logOut))
+		}
+		return fmt.Errorf("input normalization failed: %w", err)
+	}
+
+	if opts.PreserveMetadata && HoldsMetadata(opts.Format) {
+		opts.tagsInput = inputPath
+		opts.tagsInputFormat = opts.InputFormat
+		opts.tagsStreams = "s:a"
+		if opts.SingleTrack {
+			opts.tagsStreams = fmt.Sprintf("s:a:%d", opts.Track)
+		}
+	}
+	opts.InputFormat = ""
+	opts.SingleTrack = false
+	return s.extract(ctx, intermediate, outputPath, opts, cb)
+}
+
+// extract runs a single ffmpeg extraction pass.
+func (s *Service) extract(ctx context.Context, inputPath, outputPath string, opts ExtractOptions, cb ProgressCallback) error {
+	return s.runFFmpeg(ctx, inputPath, s.extractArgs(ctx, inputPath, outputPath, opts), opts, 1, filepath.Base(outputPath), cb)
+}
+
+// runFFmpeg runs an extraction command line built from opts, which writes
+// outputs files, and turns ffmpeg's -progress output into cb calls. name
+// identifies the output in errors.
+func (s *Service) runFFmpeg(ctx context.Context, inputPath string, args []string, opts ExtractOptions, outputs int, name string, cb ProgressCallback) error {
+	duration := opts.SourceDuration
+	if duration <= 0 {
+		var err error
+		if duration, err = s.probeDuration(ctx, inputPath); err != nil {
+			s.logger.Warn("could not probe duration, progress will be coarse", "error", err)
+		}
+	}
+	// Progress is measured against the output timeline, which starts at zero
+	// for both seek modes, so only the clip length matters.
+	duration = clipDuration(duration, opts.Start, opts.End)
+	// Stream copy can't pad, so a shorter clip keeps its own length.
+	if opts.TargetDuration > 0 && (!StreamCopy(opts.Format) || duration == 0 || opts.TargetDuration < duration) {
+		duration = opts.TargetDuration
+	}
+	var expectedBytes int64
+	if duration <= 0 {
+		// total_size counts every output of the run.
+		expectedBytes = s.expectedOutputBytes(ctx, inputPath, opts) * int64(outputs)
+	}
 
 	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
 	stdout, err := cmd.StdoutPipe()
@@ -74,13 +284,25 @@ func (s *Service) ExtractAudio(ctx context.Context, inputPath, outputPath, forma
 	}
 
 	stderrScanner := bufio.NewScanner(stderr)
+	stderrDone := make(chan struct{})
 	var lastErrLine string
+	var diskFull, missingTrack, exists bool
 	go func() {
+		defer close(stderrDone)
 		for stderrScanner.Scan() {
 			line := strings.TrimSpace(stderrScanner.Text())
 			if line != "" {
 				lastErrLine = line
 			}
+			if isDiskFullLine(line) {
+				diskFull = true
+			}
+			if strings.Contains(line, "matches no streams") {
+				missingTrack = true
+			}
+			if strings.Contains(line, "already exists. Exiting") {
+				exists = true
+			}
 		}
 	}()
 
@@ -96,6 +318,10 @@ func (s *Service) ExtractAudio(ctx context.Context, inputPath, outputPath, forma
 			if duration > 0 {
 				if outMs, convErr := strconv.ParseFloat(msStr, 64); convErr == nil {
 					currentSeconds := outMs / 1_000_000.0
+					if opts.CopyTimestamps {
+						// Output timestamps follow the source timeline.
+						currentSeconds -= opts.Start
+					}
 					ratio := currentSeconds / duration
 					if ratio < 0 {
 						ratio = 0
@@ -110,6 +336,17 @@ func (s *Service) ExtractAudio(ctx context.Context, inputPath, outputPath, forma
 				}
 			}
 		}
+		if duration <= 0 && strings.HasPrefix(line, "total_size=") {
+			// No duration to measure against: fall back to bytes written.
+			if size, convErr := strconv.ParseInt(strings.TrimPrefix(line, "total_size="), 10, 64); convErr == nil {
+				if p := byteProgress(size, expectedBytes); p > progress {
+					progress = p
+					if cb != nil {
+						cb(progress, "processing", "extraindo áudio (progresso estimado)")
+					}
+				}
+			}
+		}
 		if strings.HasPrefix(line, "progress=end") {
 			progress = 100
 			if cb != nil {
@@ -122,10 +359,22 @@ func (s *Service) ExtractAudio(ctx context.Context, inputPath, outputPath, forma
 		return fmt.Errorf("failed while reading ffmpeg output: %w", err)
 	}
 
-	if err := cmd.Wait(); err != nil {
+	<-stderrDone
+	err = cmd.Wait()
+	s.recordUsage(ctx, cmd)
+	if err != nil {
 		if errors.Is(ctx.Err(), context.Canceled) {
 			return ctx.Err()
 		}
+		if diskFull || errors.Is(err, syscall.ENOSPC) {
+			return ErrDiskFull
+		}
+		if missingTrack && opts.SingleTrack {
+			return fmt.Errorf("a faixa de áudio %d não existe no arquivo", opts.Track)
+		}
+		if exists {
+			return fmt.Errorf("%w: %s", ErrOutputExists, name)
+		}
 		if lastErrLine != "" {
 			return fmt.Errorf("ffmpeg failed: %s", lastErrLine)
 		}
@@ -138,29 +387,186 @@ func (s *Service) ExtractAudio(ctx context.Context, inputPath, outputPath, forma
 	return nil
 }
 
-// TranscribeAudio runs local whisper.cpp (`whisper-cli`) and creates .txt and .srt files.
-func (s *Service) TranscribeAudio(ctx context.Context, inputAudioPath, outputBasePath string, cb ProgressCallback) error {
-	if s.whisperModel == "" {
+// extractArgs builds the full ffmpeg command line of an extraction pass,
+// ending with the output path.
+func (s *Service) extractArgs(ctx context.Context, inputPath, outputPath string, opts ExtractOptions) []string {
+	args := []string{overwriteFlag(opts.Overwrite)}
+	args = append(args, s.encodeArgs(ctx, inputPath, opts)...)
+	args = append(args, containerArgs(opts.Format, opts.NoFaststart)...)
+	if strings.EqualFold(opts.Format, FormatHLS) {
+		args = append(args, "-hls_segment_filename", HLSSegmentPattern(filepath.Dir(outputPath)))
+	}
+	return append(args,
+		"-progress", "pipe:1",
+		"-nostats",
+		outputPath,
+	)
+}
+
+// encodeArgs builds the ffmpeg input, filter, codec and trim arguments of an
+// extraction, everything but the output file and its muxer options.
+func (s *Service) encodeArgs(ctx context.Context, inputPath string, opts ExtractOptions) []string {
+	return append(sourceArgs(inputPath, opts), s.outputArgs(ctx, inputPath, opts)...)
+}
+
+// outputArgs is the output side of encodeArgs: stream selection, filters,
+// codec and trim of one output file.
+func (s *Service) outputArgs(ctx context.Context, inputPath string, opts ExtractOptions) []string {
+	args := selectArgs(opts)
+	args = append(args, "-vn")
+	filters := audioFilters(opts)
+	downmixFilter, stereo := s.downmix(ctx, inputPath, opts)
+	if downmixFilter != "" {
+		filters = append([]string{downmixFilter}, filters...)
+	}
+	if opts.Normalize {
+		if StreamCopy(opts.Format) {
+			s.logger.Info("stream copy cannot be filtered, not normalizing loudness", "format", opts.Format)
+		} else {
+			filters = append(filters, loudnormFilter)
+			opts.SampleRate = s.loudnormSampleRate(ctx, inputPath, opts)
+		}
+	}
+	if opts.TargetDuration > 0 {
+		if StreamCopy(opts.Format) {
+			s.logger.Info("stream copy cannot be padded, only trimming to target duration", "format", opts.Format)
+		} else {
+			// apad runs last so padding follows every other filter; -t below
+			// stops the otherwise endless silence.
+			filters = append(filters, "apad")
+		}
+	}
+	if len(filters) > 0 {
+		args = append(args, "-af", strings.Join(filters, ","))
+	}
+	channels := opts.Channels
+	if opts.SingleChannel {
+		channels = ChannelsSource
+	} else if stereo && (channels == "" || channels == ChannelsSource) {
+		channels = ChannelsStereo
+	}
+	args = append(args, codecAndQualityArgs(opts.Format, opts.Quality, channels, opts.SampleRate)...)
+	if opts.Threads > 0 {
+		args = append(args, "-threads", strconv.Itoa(opts.Threads))
+	}
+	if opts.PreserveMetadata {
+		switch {
+		case !HoldsMetadata(opts.Format):
+			s.logger.Info("output format cannot hold tags, not preserving metadata", "format", opts.Format)
+		case opts.tagsInput != "":
+			args = append(args, "-map_metadata", "1", "-map_metadata:s:a", "1:"+opts.tagsStreams)
+		default:
+			args = append(args, "-map_metadata", "0", "-map_metadata:s:a", "0:s:a")
+		}
+	}
+	if opts.TargetDuration > 0 {
+		args = append(args, "-t", formatSeconds(opts.TargetDuration))
+	}
+	return args
+}
+
+// Fingerprint computes a chromaprint (AcoustID) fingerprint of an audio file.
+// It prefers `fpcalc` when installed and falls back to ffmpeg's chromaprint muxer.
+func (s *Service) Fingerprint(ctx context.Context, audioPath string) (string, error) {
+	var cmd *exec.Cmd
+	if fpcalc, err := exec.LookPath("fpcalc"); err == nil {
+		cmd = exec.CommandContext(ctx, fpcalc, "-plain", audioPath)
+	} else {
+		cmd = exec.CommandContext(ctx, "ffmpeg",
+			"-v", "error",
+			"-i", audioPath,
+			"-map", "0:a:0",
+			"-f", "chromaprint",
+			"-fp_format", "base64",
+			"-",
+		)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	s.recordUsage(ctx, cmd)
+	if err != nil {
+		if logOut := strings.TrimSpace(stderr.String()); logOut != "" {
+			return "", fmt.Errorf("fingerprint failed: %s", compactLogLine(logOut))
+		}
+		return "", fmt.Errorf("fingerprint failed: %w", err)
+	}
+
+	fingerprint := strings.TrimSpace(string(out))
+	if fingerprint == "" {
+		return "", errors.New("empty fingerprint output")
+	}
+	return fingerprint, nil
+}
+
+// TranscribeOptions tunes a single whisper run. Empty fields fall back to the
+// service defaults.
+type TranscribeOptions struct {
+	Model    string `json:"model,omitempty"`
+	Language string `json:"language,omitempty"`
+	// Translate adds -tr: whisper recognizes the speech in Language (or the
+	// detected one) and writes the TXT/SRT/VTT in English.
+	Translate bool `json:"translate,omitempty"`
+	// Prompt is passed as whisper's initial prompt to bias vocabulary.
+	Prompt string `json:"prompt,omitempty"`
+	// WordTimestamps additionally writes <base>.words.json with per-word
+	// timings when the whisper build supports it.
+	WordTimestamps bool `json:"word_timestamps,omitempty"`
+}
+
+// TranscribeAudio runs local whisper.cpp (`whisper-cli`) and creates .txt, .srt and .vtt files.
+func (s *Service) TranscribeAudio(ctx context.Context, inputAudioPath, outputBasePath string, opts TranscribeOptions, cb ProgressCallback) error {
+	model := s.whisperModel
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	if model == "" {
 		return errors.New("whisper model is not configured")
 	}
+	if err := CheckModel(model); err != nil {
+		return err
+	}
+	language := s.whisperLanguage
+	if opts.Language != "" {
+		language = opts.Language
+	}
 
 	if cb != nil {
 		cb(1, "processing", "iniciando transcrição")
 	}
 
 	args := []string{
-		"-m", s.whisperModel,
+		"-m", model,
 		"-f", inputAudioPath,
 		"-of", outputBasePath,
 		"-otxt",
 		"-osrt",
-		"-l", s.whisperLanguage,
+		"-ovtt",
+		"-l", language,
+	}
+	if opts.Translate {
+		args = append(args, "-tr")
+	}
+	if opts.Prompt != "" {
+		args = append(args, "--prompt", opts.Prompt)
+	}
+	words := opts.WordTimestamps && s.supportsWordTimestamps()
+	if words {
+		args = append(args, "-ojf")
+	}
+
+	// Progress follows the segment timestamps whisper prints as it goes,
+	// measured against the audio length.
+	duration, err := s.probeDuration(ctx, inputAudioPath)
+	if err != nil {
+		s.logger.Warn("could not probe audio duration, transcription progress will be estimated", "error", err)
 	}
 
 	cmd := exec.CommandContext(ctx, s.whisperBin, args...)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	cmd.Stdout = &stderr
+	stderr := &whisperLog{}
+	cmd.Stderr = stderr
+	cmd.Stdout = stderr
 
 	if err := cmd.Start(); err != nil {
 		return fmt.Errorf("failed to start whisper-cli: %w", err)
@@ -168,10 +574,13 @@ func (s *Service) TranscribeAudio(ctx context.Context, inputAudioPath, outputBas
 
 	done := make(chan error, 1)
 	go func() {
-		done <- cmd.Wait()
+		err := cmd.Wait()
+		s.recordUsage(ctx, cmd)
+		done <- err
 	}()
 
 	progress := 5
+	started := time.Now()
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
@@ -188,13 +597,30 @@ func (s *Service) TranscribeAudio(ctx context.Context, inputAudioPath, outputBas
 				}
 				return fmt.Errorf("whisper-cli failed: %w", err)
 			}
+			if words {
+				// Word timings are an extra; the transcript is still usable
+				// without them.
+				if err := writeWords(outputBasePath); err != nil {
+					s.logger.Warn("failed to write word timestamps", "error", err)
+				}
+			}
 			if cb != nil {
 				cb(100, "completed", "transcrição concluída")
 			}
 			return nil
 		case <-ticker.C:
-			if progress < 90 {
-				progress += 7
+			position, seen := stderr.position()
+			switch {
+			case seen && duration > 0:
+				if p := whisperPercent(position, duration); p > progress {
+					progress = p
+				}
+			case time.Since(started) >= whisperFallbackDelay:
+				// No timestamps to go by (or no duration): keep the bar
+				// moving with the old estimate.
+				if progress < 90 {
+					progress += 7
+				}
 			}
 			if cb != nil {
 				cb(progress, "processing", "transcrevendo áudio")
@@ -203,6 +629,11 @@ func (s *Service) TranscribeAudio(ctx context.Context, inputAudioPath, outputBas
 	}
 }
 
+// isDiskFullLine reports whether an ffmpeg stderr line signals ENOSPC.
+func isDiskFullLine(line string) bool {
+	return strings.Contains(strings.ToLower(line), "no space left on device")
+}
+
 func compactLogLine(v string) string {
 	lines := strings.Split(v, "\n")
 	for i := len(lines) - 1; i >= 0; i-- {
@@ -217,6 +648,68 @@ func compactLogLine(v string) string {
 	return "unknown whisper error"
 }
 
+// AudioStream describes one audio stream of a media file.
+type AudioStream struct {
+	Index         int    `json:"index"`
+	Codec         string `json:"codec"`
+	Channels      int    `json:"channels"`
+	ChannelLayout string `json:"channel_layout,omitempty"`
+	SampleRate    int    `json:"sample_rate,omitempty"`
+	// Language is the stream's language tag (e.g. "por", "eng"), if any.
+	Language string `json:"language,omitempty"`
+}
+
+// ProbeAudioStreams lists the audio streams of a file using ffprobe. Index is
+// the position among audio streams, as used by `-map 0:a:<index>`.
+func (s *Service) ProbeAudioStreams(ctx context.Context, inputPath string) ([]AudioStream, error) {
+	cmd := exec.CommandContext(ctx,
+		"ffprobe",
+		"-v", "error",
+		"-select_streams", "a",
+		"-show_entries", "stream=codec_name,channels,channel_layout,sample_rate:stream_tags=language",
+		"-of", "json",
+		inputPath,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe error: %w", err)
+	}
+
+	var probe struct {
+		Streams []struct {
+			CodecName     string `json:"codec_name"`
+			Channels      int    `json:"channels"`
+			ChannelLayout string `json:"channel_layout"`
+			SampleRate    string `json:"sample_rate"`
+			Tags          struct {
+				Language string `json:"language"`
+			} `json:"tags"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return nil, fmt.Errorf("invalid ffprobe output: %w", err)
+	}
+
+	streams := make([]AudioStream, 0, len(probe.Streams))
+	for i, st := range probe.Streams {
+		sampleRate, _ := strconv.Atoi(st.SampleRate)
+		streams = append(streams, AudioStream{
+			Index:         i,
+			Codec:         st.CodecName,
+			Channels:      st.Channels,
+			ChannelLayout: st.ChannelLayout,
+			SampleRate:    sampleRate,
+			Language:      st.Tags.Language,
+		})
+	}
+	return streams, nil
+}
+
+// Duration returns the media duration in seconds as reported by ffprobe.
+func (s *Service) Duration(ctx context.Context, inputPath string) (float64, error) {
+	return s.probeDuration(ctx, inputPath)
+}
+
 func (s *Service) probeDuration(ctx context.Context, inputPath string) (float64, error) {
 	cmd := exec.CommandContext(ctx,
 		"ffprobe",
@@ -240,65 +733,279 @@ func (s *Service) probeDuration(ctx context.Context, inputPath string) (float64,
 	return dur, nil
 }
 
-func codecAndQualityArgs(format, quality string) []string {
+// inputArgs builds the -i section including trimming. Fast seeking puts -ss
+// before -i; accurate seeking puts it after. The clip end is an input -t, so
+// it limits what is read and an output -t (TargetDuration) can't override
+// it: from the seek point with fast seeking, from the start of the file
+// (up to End) with accurate seeking.
+func inputArgs(inputPath string, opts ExtractOptions) []string {
+	return append(sourceArgs(inputPath, opts), selectArgs(opts)...)
+}
+
+// sourceArgs is the input side of inputArgs, up to and including the -i
+// options; what follows applies to a single output.
+func sourceArgs(inputPath string, opts ExtractOptions) []string {
+	var args []string
+	if opts.CopyTimestamps {
+		args = append(args, "-copyts", "-start_at_zero")
+	}
+	seek := opts.Start > 0
+	if seek && opts.SeekMode != SeekAccurate {
+		args = append(args, "-ss", formatSeconds(opts.Start))
+	}
+	if opts.End > opts.Start {
+		length := opts.End - opts.Start
+		if opts.SeekMode == SeekAccurate {
+			length = opts.End
+		}
+		args = append(args, "-t", formatSeconds(length))
+	}
+	if opts.InputFormat != "" {
+		args = append(args, "-f", opts.InputFormat)
+	}
+	args = append(args, "-i", inputPath)
+	if opts.tagsInput != "" {
+		// Placed right after the main input so the output options below
+		// don't attach to it. Mapping explicitly keeps ffmpeg from picking
+		// its audio instead of the main input's.
+		if opts.tagsInputFormat != "" {
+			args = append(args, "-f", opts.tagsInputFormat)
+		}
+		args = append(args, "-i", opts.tagsInput)
+	}
+	return args
+}
+
+// selectArgs picks the audio and the accurate seek point of one output.
+func selectArgs(opts ExtractOptions) []string {
+	var args []string
+	if opts.tagsInput != "" && !opts.SingleTrack {
+		args = append(args, "-map", "0:a")
+	}
+	if opts.Start > 0 && opts.SeekMode == SeekAccurate {
+		args = append(args, "-ss", formatSeconds(opts.Start))
+	}
+	if opts.SingleTrack {
+		args = append(args, "-map", fmt.Sprintf("0:a:%d", opts.Track))
+	}
+	return args
+}
+
+// audioFilters builds the -af filter chain for an extraction.
+func audioFilters(opts ExtractOptions) []string {
+	var filters []string
+	if opts.SingleChannel {
+		filters = append(filters, fmt.Sprintf("pan=mono|c0=c%d", opts.Channel))
+	}
+	if opts.GainDB != 0 {
+		filters = append(filters, "volume="+strconv.FormatFloat(opts.GainDB, 'f', -1, 64)+"dB")
+	}
+	return filters
+}
+
+// clipDuration returns the length of the trimmed output given the full input
+// duration. It returns 0 when the length is unknown.
+func clipDuration(total, start, end float64) float64 {
+	if end > start {
+		if total > 0 && end > total {
+			end = total
+		}
+		return end - start
+	}
+	if total > start {
+		return total - start
+	}
+	return 0
+}
+
+func formatSeconds(v float64) string {
+	return strconv.FormatFloat(v, 'f', 3, 64)
+}
+
+// Output channel layouts for ExtractOptions.Channels.
+const (
+	ChannelsMono   = "mono"
+	ChannelsStereo = "stereo"
+	ChannelsSource = "source"
+)
+
+// encoderSetting is one quality level of an encoder.
+type encoderSetting struct {
+	// args are the encoder options that follow -codec:a.
+	args []string
+	// kbps is the typical output bitrate, and minKbps/maxKbps bound it for
+	// VBR and lossless formats; they feed the size and progress estimates.
+	kbps, minKbps, maxKbps int
+}
+
+// encoder describes how a format is encoded: the codec, its settings per
+// quality and any muxer options that always come with it.
+type encoder struct {
+	codec string
+	// qualities is keyed by quality; unknown qualities use "medium".
+	qualities map[string]encoderSetting
+	extra     []string
+}
+
+var aacQualities = map[string]encoderSetting{
+	"low":      {args: []string{"-b:a", "96k"}, kbps: 96, minKbps: 96, maxKbps: 96},
+	"medium":   {args: []string{"-b:a", "192k"}, kbps: 192, minKbps: 192, maxKbps: 192},
+	"high":     {args: []string{"-b:a", "320k"}, kbps: 320, minKbps: 320, maxKbps: 320},
+	"original": {args: []string{"-b:a", "384k"}, kbps: 384, minKbps: 384, maxKbps: 384},
+}
+
+// encoders is the per-format encoder table behind codecAndQualityArgs and
+// bitrateRange; formats not listed are stream copied.
+var encoders = map[string]encoder{
+	"mp3": {codec: "libmp3lame", qualities: map[string]encoderSetting{
+		"low":      {args: []string{"-b:a", "96k"}, kbps: 96, minKbps: 96, maxKbps: 96},
+		"medium":   {args: []string{"-b:a", "192k"}, kbps: 192, minKbps: 192, maxKbps: 192},
+		"high":     {args: []string{"-b:a", "320k"}, kbps: 320, minKbps: 320, maxKbps: 320},
+		"original": {args: []string{"-q:a", "0"}, kbps: 245, minKbps: 220, maxKbps: 260},
+	}},
+	// 16-bit PCM; the size depends on the source channels and rate.
+	"wav": {codec: "pcm_s16le", qualities: map[string]encoderSetting{
+		"medium": {kbps: 1411, minKbps: 705, maxKbps: 1536},
+	}},
+	"aac":     {codec: "aac", qualities: aacQualities},
+	FormatM4A: {codec: "aac", qualities: aacQualities},
+	FormatHLS: {codec: "aac", qualities: aacQualities, extra: []string{
+		"-hls_time", hlsSegmentTime,
+		"-hls_playlist_type", "vod",
+	}},
+	"flac": {codec: "flac", qualities: map[string]encoderSetting{
+		"low":      {args: []string{"-compression_level", "8"}, kbps: 900, minKbps: 600, maxKbps: 1100},
+		"medium":   {args: []string{"-compression_level", "8"}, kbps: 900, minKbps: 600, maxKbps: 1100},
+		"high":     {args: []string{"-compression_level", "12"}, kbps: 900, minKbps: 600, maxKbps: 1100},
+		"original": {args: []string{"-compression_level", "12"}, kbps: 900, minKbps: 600, maxKbps: 1100},
+	}},
+	// VBR around the target bitrate.
+	FormatOpus: {codec: "libopus", qualities: map[string]encoderSetting{
+		"low":      {args: []string{"-b:a", "32k"}, kbps: 32, minKbps: 28, maxKbps: 40},
+		"medium":   {args: []string{"-b:a", "64k"}, kbps: 64, minKbps: 56, maxKbps: 72},
+		"high":     {args: []string{"-b:a", "128k"}, kbps: 128, minKbps: 112, maxKbps: 144},
+		"original": {args: []string{"-b:a", "128k"}, kbps: 128, minKbps: 112, maxKbps: 144},
+	}},
+	"ogg": {codec: "libvorbis", qualities: map[string]encoderSetting{
+		"low":      {args: []string{"-qscale:a", "2"}, kbps: 96, minKbps: 80, maxKbps: 112},
+		"medium":   {args: []string{"-qscale:a", "5"}, kbps: 160, minKbps: 128, maxKbps: 192},
+		"high":     {args: []string{"-qscale:a", "8"}, kbps: 256, minKbps: 224, maxKbps: 320},
+		"original": {args: []string{"-qscale:a", "8"}, kbps: 256, minKbps: 224, maxKbps: 320},
+	}},
+}
+
+// lookupEncoder returns the encoder and quality setting for format, which
+// defaults to mp3. ok is false for formats that are stream copied.
+func lookupEncoder(format, quality string) (enc encoder, setting encoderSetting, ok bool) {
 	format = strings.ToLower(strings.TrimSpace(format))
-	quality = strings.ToLower(strings.TrimSpace(quality))
+	if format == "" {
+		format = "mp3"
+	}
+	enc, ok = encoders[format]
+	if !ok {
+		return encoder{}, encoderSetting{}, false
+	}
+	setting, found := enc.qualities[strings.ToLower(strings.TrimSpace(quality))]
+	if !found {
+		setting = enc.qualities["medium"]
+	}
+	return enc, setting, true
+}
+
+func codecAndQualityArgs(format, quality, channels string, sampleRate int) []string {
+	enc, setting, ok := lookupEncoder(format, quality)
+	if !ok {
+		// Stream copy can't change the layout.
+		return []string{"-codec:a", "copy"}
+	}
 
+	format = strings.ToLower(strings.TrimSpace(format))
 	if format == "" {
 		format = "mp3"
 	}
+	muxer := format
+	if c, ok := containers[format]; ok && c.muxer != "" {
+		muxer = c.muxer
+	}
+	args := []string{"-f", muxer, "-codec:a", enc.codec}
+	args = append(args, setting.args...)
+	args = append(args, enc.extra...)
+	if format == FormatOpus {
+		sampleRate = opusSampleRate(sampleRate)
+	}
 
-	args := []string{"-f", format}
+	switch channels {
+	case ChannelsMono:
+		args = append(args, "-ac", "1")
+	case ChannelsStereo:
+		args = append(args, "-ac", "2")
+	}
+	if sampleRate > 0 {
+		args = append(args, "-ar", strconv.Itoa(sampleRate))
+	}
+	return args
+}
 
-	switch format {
-	case "mp3":
-		args = append(args, "-codec:a", "libmp3lame")
-		switch quality {
-		case "low":
-			args = append(args, "-b:a", "96k")
-		case "high":
-			args = append(args, "-b:a", "320k")
-		case "original":
-			args = append(args, "-q:a", "0")
-		default:
-			args = append(args, "-b:a", "192k")
-		}
-	case "wav":
-		args = append(args, "-codec:a", "pcm_s16le")
-	case "aac":
-		args = append(args, "-codec:a", "aac")
-		switch quality {
-		case "low":
-			args = append(args, "-b:a", "96k")
-		case "high":
-			args = append(args, "-b:a", "320k")
-		case "original":
-			args = append(args, "-b:a", "384k")
-		default:
-			args = append(args, "-b:a", "192k")
-		}
-	case "flac":
-		args = append(args, "-codec:a", "flac")
-		if quality == "high" || quality == "original" {
-			args = append(args, "-compression_level", "12")
-		} else {
-			args = append(args, "-compression_level", "8")
-		}
-	case "ogg":
-		args = append(args, "-codec:a", "libvorbis")
-		switch quality {
-		case "low":
-			args = append(args, "-qscale:a", "2")
-		case "high", "original":
-			args = append(args, "-qscale:a", "8")
-		default:
-			args = append(args, "-qscale:a", "5")
+// FormatOpus is Opus in an Ogg container (.opus), the best quality per
+// kilobyte for speech.
+const FormatOpus = "opus"
+
+// opusSampleRate maps a requested rate to one libopus accepts (8, 12, 16,
+// 24 or 48kHz), rounding up so nothing is lost. Zero stays zero and lets
+// ffmpeg pick 48kHz.
+func opusSampleRate(rate int) int {
+	for _, supported := range []int{8000, 12000, 16000, 24000, 48000} {
+		if rate > 0 && rate <= supported {
+			return supported
 		}
-	default:
-		args = []string{"-codec:a", "copy"}
 	}
+	if rate > 0 {
+		return 48000
+	}
+	return 0
+}
 
-	return args
+// FormatM4A is AAC in an MP4 container, the format Apple devices and most
+// web players expect for streamed audio.
+const FormatM4A = "m4a"
+
+// container describes muxer settings that depend only on the output format.
+type container struct {
+	// muxer is the ffmpeg -f name when it differs from the format name.
+	muxer string
+	// faststart moves the MP4 index to the front (-movflags +faststart) so
+	// players can start before the download finishes. It costs a second
+	// pass over the file once encoding ends.
+	faststart bool
+}
+
+// containers is the per-format container table; formats not listed use
+// ffmpeg's muxer of the same name with its defaults.
+var containers = map[string]container{
+	FormatM4A: {muxer: "ipod", faststart: true},
+}
+
+// containerArgs returns the muxer options for format. noFaststart drops the
+// faststart flag for formats that enable it by default.
+func containerArgs(format string, noFaststart bool) []string {
+	c := containers[strings.ToLower(strings.TrimSpace(format))]
+	if c.faststart && !noFaststart {
+		return []string{"-movflags", "+faststart"}
+	}
+	return nil
+}
+
+// FormatHLS writes an HLS playlist plus AAC segments instead of one file.
+// The output path is the playlist; segments go next to it.
+const (
+	FormatHLS      = "hls"
+	HLSPlaylist    = "index.m3u8"
+	hlsSegmentTime = "10"
+)
+
+// HLSSegmentPattern is the ffmpeg pattern for segment names in dir.
+func HLSSegmentPattern(dir string) string {
+	return filepath.Join(dir, "seg_%05d.ts")
 }
 
 func OutputName(jobID, format string) string {
@@ -308,3 +1015,57 @@ func OutputName(jobID, format string) string {
 	}
 	return fmt.Sprintf("%s.%s", filepath.Base(jobID), format)
 }
+
+// HoldsMetadata reports whether the output format keeps tags. Raw ADTS AAC
+// and HLS segments have nowhere to store them, and ffmpeg only writes a few
+// RIFF INFO fields to WAV, which most players ignore.
+func HoldsMetadata(format string) bool {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "wav", "aac", FormatHLS:
+		return false
+	}
+	return true
+}
+
+// ChapterFormats are the output formats whose containers carry chapters.
+var ChapterFormats = map[string]bool{"mp3": true, "flac": true, "ogg": true, FormatM4A: true, FormatOpus: true}
+
+// EmbedChapters remuxes audioPath in place with the chapters from an
+// FFMETADATA file, copying the streams unchanged. noFaststart is the job's
+// setting, re-applied since the remux rewrites the MP4 index.
+func (s *Service) EmbedChapters(ctx context.Context, audioPath, metadataPath string, noFaststart bool) error {
+	tmp := audioPath + ".chapters" + filepath.Ext(audioPath)
+	defer os.Remove(tmp)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", chapterArgs(audioPath, metadataPath, tmp, noFaststart)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if logOut := strings.TrimSpace(stderr.String()); logOut != "" {
+			if isDiskFullLine(logOut) {
+				return ErrDiskFull
+			}
+			return fmt.Errorf("failed to embed chapters: %s", compactLogLine(logOut))
+		}
+		return fmt.Errorf("failed to embed chapters: %w", err)
+	}
+	return os.Rename(tmp, audioPath)
+}
+
+// chapterArgs builds the EmbedChapters remux into tmp. The container
+// follows the file extension, as the output name comes from OutputName.
+func chapterArgs(audioPath, metadataPath, tmp string, noFaststart bool) []string {
+	args := []string{
+		"-y", "-v", "error",
+		"-i", audioPath,
+		"-i", metadataPath,
+		// Cover art is an attached picture stream; keep it when present.
+		"-map", "0:a",
+		"-map", "0:v?",
+		"-map_metadata", "0",
+		"-map_chapters", "1",
+		"-codec", "copy",
+	}
+	args = append(args, containerArgs(strings.TrimPrefix(filepath.Ext(audioPath), "."), noFaststart)...)
+	return append(args, tmp)
+}
@@ -0,0 +1,34 @@
+package extractor
+
+import "context"
+
+// loudnormFilter is single-pass EBU R128 normalization to the usual podcast
+// target (-16 LUFS, -1.5 dBTP true peak, 11 LU range). The two-pass mode
+// measures first and is more exact, but doubles the extraction time.
+const loudnormFilter = "loudnorm=I=-16:TP=-1.5:LRA=11"
+
+// defaultLoudnormRate is used when the source rate can't be probed.
+const defaultLoudnormRate = 48000
+
+// loudnormSampleRate picks the output rate for a normalized extraction.
+// loudnorm resamples to 192kHz internally and outputs at that rate, so
+// without an explicit -ar the file would balloon; keep the source rate
+// unless the job chose one.
+func (s *Service) loudnormSampleRate(ctx context.Context, inputPath string, opts ExtractOptions) int {
+	if opts.SampleRate > 0 {
+		return opts.SampleRate
+	}
+	streams, err := s.ProbeAudioStreams(ctx, inputPath)
+	if err != nil {
+		s.logger.Warn("could not probe sample rate for loudnorm", "input", inputPath, "error", err)
+		return defaultLoudnormRate
+	}
+	track := 0
+	if opts.SingleTrack {
+		track = opts.Track
+	}
+	if track < len(streams) && streams[track].SampleRate > 0 {
+		return streams[track].SampleRate
+	}
+	return defaultLoudnormRate
+}
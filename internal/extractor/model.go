@@ -0,0 +1,39 @@
+package extractor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrInvalidModel is returned when the whisper model is not a ggml file,
+// e.g. a PyTorch .pt checkpoint, which whisper-cli rejects with a cryptic
+// message.
+var ErrInvalidModel = errors.New("modelo whisper inválido (esperado formato ggml)")
+
+// modelMagics are the leading bytes of the ggml model variants whisper.cpp
+// loads: legacy ggml, ggmf and ggjt (uint32 magics stored little-endian) and
+// GGUF.
+var modelMagics = [][]byte{[]byte("lmgg"), []byte("fmgg"), []byte("tjgg"), []byte("GGUF")}
+
+// CheckModel verifies that path exists and starts with a ggml magic.
+func CheckModel(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("modelo whisper não encontrado: %w", err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidModel, path)
+	}
+	for _, m := range modelMagics {
+		if bytes.Equal(magic, m) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidModel, path)
+}
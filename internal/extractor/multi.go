@@ -0,0 +1,106 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractTarget is one output of ExtractMulti: its path and the options that
+// shape it, e.g. SingleChannel/Channel or SingleTrack/Track.
+type ExtractTarget struct {
+	Path    string
+	Options ExtractOptions
+}
+
+// ExtractMulti writes several outputs of one input with a single ffmpeg
+// process, which reads and decodes the input once and encodes each target
+// from it. The targets must share the input side of their options (trim,
+// seek mode, input format); the first target's are used. Progress is
+// reported for the run as a whole.
+//
+// The overwrite policy applies per target, and only targets that can't be
+// reused are encoded. Under RobustInput a failed run is retried one target
+// at a time through ExtractAudio, which can normalize the input.
+func (s *Service) ExtractMulti(ctx context.Context, inputPath string, targets []ExtractTarget, cb ProgressCallback) error {
+	var pending []ExtractTarget
+	for _, t := range targets {
+		reuse, err := s.existingOutput(ctx, inputPath, t.Path, t.Options)
+		if err != nil {
+			return err
+		}
+		if !reuse {
+			pending = append(pending, t)
+		}
+	}
+	if len(pending) == 0 {
+		if cb != nil {
+			cb(100, "completed", "arquivos existentes reaproveitados")
+		}
+		return nil
+	}
+
+	opts := pending[0].Options
+	names := make([]string, len(pending))
+	for i, t := range pending {
+		names[i] = filepath.Base(t.Path)
+	}
+	// A missing track is reported by ffmpeg's own message, since the run
+	// may map several.
+	runOpts := opts
+	runOpts.SingleTrack = false
+	err := s.runFFmpeg(ctx, inputPath, s.multiArgs(ctx, inputPath, pending), runOpts, len(pending), strings.Join(names, ", "), cb)
+	if err == nil {
+		for _, t := range pending {
+			s.recordSettings(ctx, inputPath, t.Path, t.Options)
+		}
+		return nil
+	}
+	if opts.RobustInput && ctx.Err() == nil && !errors.Is(err, ErrDiskFull) {
+		s.logger.Warn("multi-output extraction failed, retrying each output", "input", inputPath, "error", err)
+		return s.extractEach(ctx, inputPath, pending, cb)
+	}
+	return err
+}
+
+// multiArgs builds one ffmpeg command line writing every target.
+func (s *Service) multiArgs(ctx context.Context, inputPath string, targets []ExtractTarget) []string {
+	opts := targets[0].Options
+	args := []string{overwriteFlag(opts.Overwrite), "-progress", "pipe:1", "-nostats"}
+	args = append(args, sourceArgs(inputPath, opts)...)
+	for _, t := range targets {
+		args = append(args, s.outputArgs(ctx, inputPath, t.Options)...)
+		args = append(args, containerArgs(t.Options.Format, t.Options.NoFaststart)...)
+		if strings.EqualFold(t.Options.Format, FormatHLS) {
+			args = append(args, "-hls_segment_filename", HLSSegmentPattern(filepath.Dir(t.Path)))
+		}
+		args = append(args, t.Path)
+	}
+	return args
+}
+
+// extractEach is the fallback of ExtractMulti: the targets are extracted in
+// turn, with cb seeing the combined progress.
+func (s *Service) extractEach(ctx context.Context, inputPath string, targets []ExtractTarget, cb ProgressCallback) error {
+	for _, t := range targets {
+		// Leftovers of the failed run would trip the overwrite policy.
+		_ = os.Remove(t.Path)
+	}
+	for i, t := range targets {
+		var scaled ProgressCallback
+		if cb != nil {
+			scaled = func(percent int, status, message string) {
+				cb((i*100+percent)/len(targets), "processing", message)
+			}
+		}
+		if err := s.ExtractAudio(ctx, inputPath, t.Path, t.Options, scaled); err != nil {
+			return err
+		}
+	}
+	if cb != nil {
+		cb(100, "completed", "extração concluída")
+	}
+	return nil
+}
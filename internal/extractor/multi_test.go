@@ -0,0 +1,76 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestMultiArgsSplitChannelsInOneRun(t *testing.T) {
+	base := ExtractOptions{Format: "mp3", Quality: "medium", Start: 5, End: 20, GainDB: 3}
+	var targets []ExtractTarget
+	for i, path := range []string{"ch0.mp3", "ch1.mp3"} {
+		opts := base
+		opts.SingleChannel = true
+		opts.Channel = i
+		targets = append(targets, ExtractTarget{Path: path, Options: opts})
+	}
+
+	args := newTestService().multiArgs(context.Background(), "in.mp4", targets)
+
+	if got := optionValues(args, "-i"); !slices.Equal(got, []string{"in.mp4"}) {
+		t.Fatalf("-i = %v, want the input read once", got)
+	}
+	if got := optionValues(argsBefore(args, "-i"), "-t"); !slices.Equal(got, []string{"15.000"}) {
+		t.Errorf("input -t = %v, want the clip shared by every output", got)
+	}
+	want := []string{"pan=mono|c0=c0,volume=3dB", "pan=mono|c0=c1,volume=3dB"}
+	if got := optionValues(args, "-af"); !slices.Equal(got, want) {
+		t.Errorf("-af = %v, want %v", got, want)
+	}
+	// Each output's options must come before its own path.
+	first, second := slices.Index(args, "ch0.mp3"), slices.Index(args, "ch1.mp3")
+	if first < 0 || second != len(args)-1 {
+		t.Fatalf("output paths out of place in %v", args)
+	}
+	if got := optionValues(args[first:], "-af"); !slices.Equal(got, want[1:]) {
+		t.Errorf("-af after the first output = %v, want only the second channel's", got)
+	}
+}
+
+func TestMultiArgsMapsEachTrack(t *testing.T) {
+	var targets []ExtractTarget
+	for i, path := range []string{"track0.flac", "track1.flac", "track2.flac"} {
+		opts := ExtractOptions{Format: "flac", SeekMode: SeekAccurate, Start: 3}
+		opts.SingleTrack = true
+		opts.Track = i
+		targets = append(targets, ExtractTarget{Path: path, Options: opts})
+	}
+
+	args := newTestService().multiArgs(context.Background(), "film.mkv", targets)
+
+	if got := optionValues(args, "-i"); !slices.Equal(got, []string{"film.mkv"}) {
+		t.Fatalf("-i = %v, want the input read once", got)
+	}
+	if got, want := optionValues(args, "-map"), []string{"0:a:0", "0:a:1", "0:a:2"}; !slices.Equal(got, want) {
+		t.Errorf("-map = %v, want %v", got, want)
+	}
+	// Accurate seeking is an output option, so every output repeats it.
+	if got := optionValues(args, "-ss"); len(got) != len(targets) {
+		t.Errorf("-ss = %v, want one per output", got)
+	}
+	for i, target := range targets {
+		end := slices.Index(args, target.Path)
+		start := 0
+		if i > 0 {
+			start = slices.Index(args, targets[i-1].Path) + 1
+		}
+		if end < start {
+			t.Fatalf("outputs out of order in %v", args)
+		}
+		if got := optionValues(args[start:end], "-map"); !slices.Equal(got, []string{fmt.Sprintf("0:a:%d", i)}) {
+			t.Errorf("output %d maps %v", i, got)
+		}
+	}
+}
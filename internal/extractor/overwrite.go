@@ -0,0 +1,151 @@
+package extractor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Overwrite policies for an output path that already exists.
+const (
+	// OverwriteAlways re-encodes over the existing file (ffmpeg -y).
+	OverwriteAlways = "overwrite"
+	// OverwriteSkip keeps an existing output produced from the same input
+	// with the same settings (see SettingsSidecar) that still passes
+	// VerifyOutput, and skips ffmpeg; anything else is re-encoded.
+	OverwriteSkip = "skip"
+	// OverwriteFail refuses to touch an existing output.
+	OverwriteFail = "fail"
+)
+
+// ErrOutputExists is returned under OverwriteFail when the output is
+// already on disk.
+var ErrOutputExists = errors.New("o arquivo de saída já existe")
+
+// ValidOverwritePolicy reports whether v is a known policy; empty means
+// OverwriteAlways.
+func ValidOverwritePolicy(v string) bool {
+	switch v {
+	case "", OverwriteAlways, OverwriteSkip, OverwriteFail:
+		return true
+	}
+	return false
+}
+
+// overwriteFlag is ffmpeg's answer to an existing output: -y overwrites, -n
+// exits with an error. Non-overwrite policies still pass -n so a file that
+// appears after the check is never clobbered.
+func overwriteFlag(policy string) string {
+	if policy == "" || policy == OverwriteAlways {
+		return "-y"
+	}
+	return "-n"
+}
+
+// SettingsSidecar is the file next to outputPath recording what produced
+// it, written after each successful extraction under OverwriteSkip.
+func SettingsSidecar(outputPath string) string {
+	return outputPath + ".settings.json"
+}
+
+// outputSettings is the content of a SettingsSidecar: a digest of the input
+// identity and extraction options, and the duration the output was
+// verified against.
+type outputSettings struct {
+	Digest   string  `json:"digest"`
+	Duration float64 `json:"duration"`
+}
+
+// settingsDigest identifies an extraction: the input's size and mtime plus
+// every exported option except the overwrite policy and the source duration
+// hint, which don't change the output.
+func settingsDigest(inputPath string, opts ExtractOptions) (string, error) {
+	info, err := os.Stat(inputPath)
+	if err != nil {
+		return "", err
+	}
+	opts.Overwrite = ""
+	opts.SourceDuration = 0
+	data, err := json.Marshal(struct {
+		Size    int64
+		ModTime int64
+		Options ExtractOptions
+	}{info.Size(), info.ModTime().UnixNano(), opts})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// recordSettings writes the SettingsSidecar of a finished output so a later
+// run under OverwriteSkip can tell whether it may be reused.
+func (s *Service) recordSettings(ctx context.Context, inputPath, outputPath string, opts ExtractOptions) {
+	if opts.Overwrite != OverwriteSkip {
+		return
+	}
+	digest, err := settingsDigest(inputPath, opts)
+	if err != nil {
+		s.logger.Warn("could not record output settings", "output", outputPath, "error", err)
+		return
+	}
+	duration, _ := s.probeDuration(ctx, outputPath)
+	data, _ := json.Marshal(outputSettings{Digest: digest, Duration: duration})
+	if err := os.WriteFile(SettingsSidecar(outputPath), data, 0o644); err != nil {
+		s.logger.Warn("could not record output settings", "output", outputPath, "error", err)
+	}
+}
+
+// existingOutput applies the overwrite policy before an extraction. It
+// reports true when a valid existing output can be kept as the result.
+func (s *Service) existingOutput(ctx context.Context, inputPath, outputPath string, opts ExtractOptions) (bool, error) {
+	if opts.Overwrite == "" || opts.Overwrite == OverwriteAlways {
+		return false, nil
+	}
+	if _, err := os.Stat(outputPath); err != nil {
+		return false, nil
+	}
+	if opts.Overwrite == OverwriteFail {
+		return false, fmt.Errorf("%w: %s", ErrOutputExists, filepath.Base(outputPath))
+	}
+
+	if err := s.reusableOutput(ctx, inputPath, outputPath, opts); err != nil {
+		s.logger.Info("existing output can't be reused, extracting again", "output", outputPath, "error", err)
+		_ = os.Remove(SettingsSidecar(outputPath))
+		if err := os.Remove(outputPath); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+	s.logger.Info("reusing existing output", "output", outputPath)
+	return true, nil
+}
+
+// reusableOutput checks that outputPath was recorded for this input and
+// options, and still has the recorded duration.
+func (s *Service) reusableOutput(ctx context.Context, inputPath, outputPath string, opts ExtractOptions) error {
+	data, err := os.ReadFile(SettingsSidecar(outputPath))
+	if err != nil {
+		return fmt.Errorf("no recorded settings: %w", err)
+	}
+	var recorded outputSettings
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return fmt.Errorf("invalid recorded settings: %w", err)
+	}
+	digest, err := settingsDigest(inputPath, opts)
+	if err != nil {
+		return err
+	}
+	if digest != recorded.Digest {
+		return errors.New("input or options changed")
+	}
+	if recorded.Duration <= 0 {
+		return errors.New("no recorded duration")
+	}
+	return s.VerifyOutput(ctx, outputPath, recorded.Duration, false)
+}
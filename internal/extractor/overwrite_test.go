@@ -0,0 +1,58 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestService() *Service {
+	return NewService(slog.New(slog.NewTextHandler(io.Discard, nil)), "", "", "")
+}
+
+func TestReusableOutputNeedsMatchingSettings(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.mp4")
+	output := filepath.Join(dir, "output.mp3")
+	if err := os.WriteFile(input, []byte("video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(output, []byte("audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestService()
+	opts := ExtractOptions{Format: "mp3", Quality: "high", Overwrite: OverwriteSkip}
+
+	if err := s.reusableOutput(context.Background(), input, output, opts); err == nil {
+		t.Fatal("output without recorded settings was reusable")
+	}
+
+	digest, err := settingsDigest(input, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(outputSettings{Digest: digest, Duration: 10})
+	if err := os.WriteFile(SettingsSidecar(output), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The policy itself is not part of the settings.
+	if same, _ := settingsDigest(input, ExtractOptions{Format: "mp3", Quality: "high"}); same != digest {
+		t.Error("digest depends on the overwrite policy")
+	}
+	changed := opts
+	changed.End = 30
+	if err := s.reusableOutput(context.Background(), input, output, changed); err == nil || err.Error() != "input or options changed" {
+		t.Errorf("changed options: err = %v", err)
+	}
+	if err := os.WriteFile(input, []byte("another video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.reusableOutput(context.Background(), input, output, opts); err == nil || err.Error() != "input or options changed" {
+		t.Errorf("changed input: err = %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// MediaInfo summarizes an input file as reported by ffprobe. Audio fields
+// describe the first audio stream and are zero when there is none.
+type MediaInfo struct {
+	Duration     float64 `json:"duration"`
+	Container    string  `json:"container"`
+	Size         int64   `json:"size,omitempty"`
+	BitRate      int64   `json:"bit_rate,omitempty"`
+	AudioCodec   string  `json:"audio_codec,omitempty"`
+	AudioBitRate int64   `json:"audio_bit_rate,omitempty"`
+	SampleRate   int     `json:"sample_rate,omitempty"`
+	Channels     int     `json:"channels,omitempty"`
+	AudioTracks  int     `json:"audio_tracks"`
+	HasVideo     bool    `json:"has_video"`
+}
+
+// ProbeInfo reads the container and stream details of inputPath with
+// `ffprobe -show_streams -show_format`.
+func (s *Service) ProbeInfo(ctx context.Context, inputPath string) (MediaInfo, error) {
+	cmd := exec.CommandContext(ctx,
+		"ffprobe",
+		"-v", "error",
+		"-show_streams",
+		"-show_format",
+		"-print_format", "json",
+		inputPath,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return MediaInfo{}, fmt.Errorf("ffprobe error: %w", err)
+	}
+
+	var probe struct {
+		Streams []struct {
+			CodecType  string `json:"codec_type"`
+			CodecName  string `json:"codec_name"`
+			SampleRate string `json:"sample_rate"`
+			Channels   int    `json:"channels"`
+			BitRate    string `json:"bit_rate"`
+		} `json:"streams"`
+		Format struct {
+			FormatName string `json:"format_name"`
+			Duration   string `json:"duration"`
+			Size       string `json:"size"`
+			BitRate    string `json:"bit_rate"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return MediaInfo{}, fmt.Errorf("invalid ffprobe output: %w", err)
+	}
+
+	// ffprobe reports numbers as strings and omits what it can't tell;
+	// unparsable values stay zero.
+	info := MediaInfo{Container: probe.Format.FormatName}
+	info.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	info.Size, _ = strconv.ParseInt(probe.Format.Size, 10, 64)
+	info.BitRate, _ = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+	for _, st := range probe.Streams {
+		switch st.CodecType {
+		case "video":
+			info.HasVideo = true
+		case "audio":
+			info.AudioTracks++
+			if info.AudioTracks > 1 {
+				continue
+			}
+			info.AudioCodec = st.CodecName
+			info.Channels = st.Channels
+			info.SampleRate, _ = strconv.Atoi(st.SampleRate)
+			info.AudioBitRate, _ = strconv.ParseInt(st.BitRate, 10, 64)
+		}
+	}
+	return info, nil
+}
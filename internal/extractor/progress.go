@@ -0,0 +1,76 @@
+package extractor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// maxByteProgress caps byte-based progress: the final size is only an
+// estimate, so the bar waits for progress=end to reach 100.
+const maxByteProgress = 99
+
+// expectedOutputBytes estimates the final output size for inputs whose
+// duration ffprobe can't report, so progress can follow ffmpeg's total_size.
+// Stream copy ends up about as large as the input's audio, approximated by
+// the input size; encoders use their target bitrate times a duration
+// estimated from the input size and container bitrate. It returns 0 when
+// there is nothing to base an estimate on.
+func (s *Service) expectedOutputBytes(ctx context.Context, inputPath string, opts ExtractOptions) int64 {
+	info, err := os.Stat(inputPath)
+	if err != nil || info.Size() <= 0 {
+		return 0
+	}
+	if StreamCopy(opts.Format) {
+		return info.Size()
+	}
+
+	inputBitRate := s.probeBitRate(ctx, inputPath)
+	if inputBitRate <= 0 {
+		return 0
+	}
+	seconds := float64(info.Size()) * 8 / float64(inputBitRate)
+	seconds = clipDuration(seconds, opts.Start, opts.End)
+
+	kbps, _, _ := bitrateRange(strings.ToLower(strings.TrimSpace(opts.Format)), strings.ToLower(strings.TrimSpace(opts.Quality)))
+	return int64(float64(kbps) * 1000 / 8 * seconds)
+}
+
+// probeBitRate returns the container bitrate in bits per second, or 0 when
+// ffprobe doesn't know it either.
+func (s *Service) probeBitRate(ctx context.Context, inputPath string) int64 {
+	out, err := exec.CommandContext(ctx,
+		"ffprobe",
+		"-v", "error",
+		"-show_entries", "format=bit_rate",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		inputPath,
+	).Output()
+	if err != nil {
+		return 0
+	}
+	bitRate, _ := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	return bitRate
+}
+
+// StreamCopy reports whether codecAndQualityArgs copies the audio stream
+// unchanged for format instead of encoding it, i.e. format has no entry in
+// the encoder table.
+func StreamCopy(format string) bool {
+	_, _, ok := lookupEncoder(format, "")
+	return !ok
+}
+
+// byteProgress converts ffmpeg's total_size into a percentage of expected.
+func byteProgress(totalSize, expected int64) int {
+	if expected <= 0 || totalSize <= 0 {
+		return 0
+	}
+	percent := int(totalSize * 100 / expected)
+	if percent > maxByteProgress {
+		percent = maxByteProgress
+	}
+	return percent
+}
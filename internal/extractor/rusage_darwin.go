@@ -0,0 +1,15 @@
+package extractor
+
+import (
+	"os"
+	"syscall"
+)
+
+// peakRSS returns the process's maximum resident set size in bytes; macOS
+// reports ru_maxrss in bytes already.
+func peakRSS(state *os.ProcessState) int64 {
+	if ru, ok := state.SysUsage().(*syscall.Rusage); ok {
+		return ru.Maxrss
+	}
+	return 0
+}
@@ -0,0 +1,15 @@
+package extractor
+
+import (
+	"os"
+	"syscall"
+)
+
+// peakRSS returns the process's maximum resident set size in bytes; Linux
+// reports ru_maxrss in kilobytes.
+func peakRSS(state *os.ProcessState) int64 {
+	if ru, ok := state.SysUsage().(*syscall.Rusage); ok {
+		return ru.Maxrss * 1024
+	}
+	return 0
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package extractor
+
+import "os"
+
+// peakRSS is unknown on platforms without a portable rusage; CPU times
+// still come from os.ProcessState.
+func peakRSS(state *os.ProcessState) int64 {
+	return 0
+}
@@ -0,0 +1,52 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// ErrNotStreamable is returned for formats that can't be written to a pipe:
+// HLS needs a directory and stream copy keeps the source container.
+var ErrNotStreamable = errors.New("format cannot be streamed")
+
+// Streamable reports whether StreamAudio supports format.
+func Streamable(format string) bool {
+	return !strings.EqualFold(format, FormatHLS) && !StreamCopy(format)
+}
+
+// StreamAudio encodes inputPath like ExtractAudio but writes the result to w
+// as it is produced, without an output file. MP4-based formats are written
+// fragmented since a pipe can't be seeked back to place the index.
+// Canceling ctx kills ffmpeg.
+func (s *Service) StreamAudio(ctx context.Context, inputPath string, w io.Writer, opts ExtractOptions) error {
+	if !Streamable(opts.Format) {
+		return ErrNotStreamable
+	}
+
+	args := []string{"-v", "error"}
+	args = append(args, s.encodeArgs(ctx, inputPath, opts)...)
+	if c := containers[strings.ToLower(strings.TrimSpace(opts.Format))]; c.faststart {
+		args = append(args, "-movflags", "+frag_keyframe+empty_moov")
+	}
+	args = append(args, "pipe:1")
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if logOut := strings.TrimSpace(stderr.String()); logOut != "" {
+			return fmt.Errorf("ffmpeg stream failed: %s", compactLogLine(logOut))
+		}
+		return fmt.Errorf("ffmpeg stream failed: %w", err)
+	}
+	return nil
+}
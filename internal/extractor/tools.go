@@ -0,0 +1,66 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// ToolStatus is the result of probing one of the external binaries the
+// service shells out to.
+type ToolStatus struct {
+	Name      string `json:"name"`
+	Path      string `json:"path,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Available bool   `json:"available"`
+	// Required tools make the instance unusable when missing; whisper only
+	// disables transcription.
+	Required bool   `json:"required"`
+	Error    string `json:"error,omitempty"`
+}
+
+// CheckTools runs ffmpeg -version, ffprobe -version and the whisper binary's
+// --help to report which are installed and their versions.
+func (s *Service) CheckTools(ctx context.Context) []ToolStatus {
+	return []ToolStatus{
+		checkTool(ctx, "ffmpeg", true, "-version"),
+		checkTool(ctx, "ffprobe", true, "-version"),
+		checkTool(ctx, s.whisperBin, false, "--help"),
+	}
+}
+
+func checkTool(ctx context.Context, name string, required bool, args ...string) ToolStatus {
+	status := ToolStatus{Name: name, Required: required}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Path = path
+
+	out, err := exec.CommandContext(ctx, path, args...).CombinedOutput()
+	// whisper-cli exits non-zero after printing its usage; output means it
+	// ran.
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && len(out) > 0) {
+		status.Error = err.Error()
+		return status
+	}
+	status.Available = true
+	status.Version = toolVersion(string(out))
+	return status
+}
+
+// toolVersion extracts the word after "version" on the first output line,
+// e.g. "6.1.1" from "ffmpeg version 6.1.1 Copyright ...".
+func toolVersion(out string) string {
+	line, _, _ := strings.Cut(out, "\n")
+	fields := strings.Fields(line)
+	for i, f := range fields {
+		if strings.EqualFold(f, "version") && i+1 < len(fields) {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
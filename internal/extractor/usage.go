@@ -0,0 +1,82 @@
+package extractor
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+// ResourceUsage totals the CPU time and peak memory of the ffmpeg/whisper
+// processes run for one job stage. PeakRSSBytes is the largest single
+// process, and is zero where the platform doesn't report it.
+type ResourceUsage struct {
+	Processes     int     `json:"processes"`
+	UserSeconds   float64 `json:"cpu_user_seconds"`
+	SystemSeconds float64 `json:"cpu_system_seconds"`
+	PeakRSSBytes  int64   `json:"peak_rss_bytes,omitempty"`
+}
+
+// UsageRecorder accumulates ResourceUsage for the processes started with a
+// context from WithUsage. It is safe for the concurrent extractions of
+// multi-output jobs.
+type UsageRecorder struct {
+	jobID string
+	stage string
+
+	mu    sync.Mutex
+	usage ResourceUsage
+}
+
+// NewUsageRecorder returns a recorder whose per-process log lines carry
+// jobID and stage.
+func NewUsageRecorder(jobID, stage string) *UsageRecorder {
+	return &UsageRecorder{jobID: jobID, stage: stage}
+}
+
+// Usage returns the totals recorded so far.
+func (r *UsageRecorder) Usage() ResourceUsage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.usage
+}
+
+type usageKey struct{}
+
+// WithUsage makes the Service record the resource usage of every process it
+// runs with ctx into r.
+func WithUsage(ctx context.Context, r *UsageRecorder) context.Context {
+	return context.WithValue(ctx, usageKey{}, r)
+}
+
+// recordUsage logs the CPU time and peak memory of a finished command and
+// adds them to the context's recorder, if any. Commands that never started
+// are skipped.
+func (s *Service) recordUsage(ctx context.Context, cmd *exec.Cmd) {
+	r, _ := ctx.Value(usageKey{}).(*UsageRecorder)
+	if r == nil || cmd.ProcessState == nil {
+		return
+	}
+	state := cmd.ProcessState
+	user := state.UserTime().Seconds()
+	system := state.SystemTime().Seconds()
+	rss := peakRSS(state)
+
+	r.mu.Lock()
+	r.usage.Processes++
+	r.usage.UserSeconds += user
+	r.usage.SystemSeconds += system
+	if rss > r.usage.PeakRSSBytes {
+		r.usage.PeakRSSBytes = rss
+	}
+	r.mu.Unlock()
+
+	s.logger.Info("process resource usage",
+		"job_id", r.jobID,
+		"stage", r.stage,
+		"process", filepath.Base(cmd.Path),
+		"cpu_user_seconds", user,
+		"cpu_system_seconds", system,
+		"peak_rss_bytes", rss,
+	)
+}
@@ -0,0 +1,78 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+)
+
+// ErrOutputInvalid wraps every failure reported by VerifyOutput.
+var ErrOutputInvalid = errors.New("áudio gerado inválido")
+
+// silenceThresholdDB is the peak level at or below which an output is
+// considered silent; real recordings peak far above it.
+const silenceThresholdDB = -90.0
+
+var maxVolumeRe = regexp.MustCompile(`max_volume:\s*(-?inf|-?[0-9.]+) dB`)
+
+// VerifyOutput checks that an extracted file is playable and plausible: its
+// duration must be close to expected (skipped when expected is zero) and,
+// with checkSilence, its peak volume must be above silence, which catches a
+// wrong or empty track. Failures wrap ErrOutputInvalid.
+func (s *Service) VerifyOutput(ctx context.Context, outputPath string, expected float64, checkSilence bool) error {
+	duration, err := s.probeDuration(ctx, outputPath)
+	if err != nil {
+		return fmt.Errorf("%w: não foi possível ler o arquivo (%v)", ErrOutputInvalid, err)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("%w: arquivo sem duração", ErrOutputInvalid)
+	}
+	// Encoders pad or trim a few frames, so allow 2% (at least a second).
+	if expected > 0 && math.Abs(duration-expected) > math.Max(1, expected*0.02) {
+		return fmt.Errorf("%w: duração de %.1fs, esperado %.1fs", ErrOutputInvalid, duration, expected)
+	}
+	if !checkSilence {
+		return nil
+	}
+
+	peak, err := s.peakVolume(ctx, outputPath)
+	if err != nil {
+		return fmt.Errorf("%w: não foi possível medir o volume (%v)", ErrOutputInvalid, err)
+	}
+	if peak <= silenceThresholdDB {
+		return fmt.Errorf("%w: o áudio está em silêncio (faixa errada ou vazia?)", ErrOutputInvalid)
+	}
+	return nil
+}
+
+// peakVolume returns the max_volume reported by ffmpeg's volumedetect
+// filter, in dB; complete silence is -Inf.
+func (s *Service) peakVolume(ctx context.Context, path string) (float64, error) {
+	cmd := exec.CommandContext(ctx,
+		"ffmpeg",
+		"-hide_banner",
+		"-nostats",
+		"-i", path,
+		"-vn",
+		"-af", "volumedetect",
+		"-f", "null",
+		"-",
+	)
+	out, err := cmd.CombinedOutput()
+	s.recordUsage(ctx, cmd)
+	if err != nil {
+		return 0, fmt.Errorf("ffmpeg volumedetect error: %w", err)
+	}
+	m := maxVolumeRe.FindSubmatch(out)
+	if m == nil {
+		return 0, errors.New("volumedetect reported no max_volume")
+	}
+	if v := string(m[1]); v == "-inf" || v == "inf" {
+		return math.Inf(-1), nil
+	}
+	return strconv.ParseFloat(string(m[1]), 64)
+}
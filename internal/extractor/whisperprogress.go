@@ -0,0 +1,91 @@
+package extractor
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// whisperFallbackDelay is how long TranscribeAudio waits for the first
+// segment timestamp before falling back to the fixed ticker.
+const whisperFallbackDelay = 8 * time.Second
+
+// whisperSegmentRe matches the end time of the segments whisper.cpp prints
+// while it works, e.g. "[00:01:02.500 --> 00:01:05.120]  text".
+var whisperSegmentRe = regexp.MustCompile(`\[\d+:\d{2}:\d{2}\.\d{3} --> (\d+):(\d{2}):(\d{2})\.(\d{3})\]`)
+
+// whisperLog collects whisper's output for error messages while tracking
+// the end time of the latest transcribed segment.
+type whisperLog struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	partial []byte
+	lastEnd float64
+	seen    bool
+}
+
+func (w *whisperLog) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.scanLine(w.partial[:i])
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *whisperLog) scanLine(line []byte) {
+	m := whisperSegmentRe.FindSubmatch(line)
+	if m == nil {
+		return
+	}
+	h, _ := strconv.Atoi(string(m[1]))
+	min, _ := strconv.Atoi(string(m[2]))
+	sec, _ := strconv.Atoi(string(m[3]))
+	ms, _ := strconv.Atoi(string(m[4]))
+	end := float64(h*3600+min*60+sec) + float64(ms)/1000
+	if end > w.lastEnd {
+		w.lastEnd = end
+	}
+	w.seen = true
+}
+
+// position returns the end of the latest segment and whether any segment
+// has been printed yet.
+func (w *whisperLog) position() (float64, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastEnd, w.seen
+}
+
+func (w *whisperLog) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+// whisperPercent maps the transcribed position onto 5-99%, leaving the ends
+// for model loading and writing the output files.
+func whisperPercent(position, duration float64) int {
+	if duration <= 0 {
+		return 0
+	}
+	ratio := position / duration
+	if ratio > 1 {
+		ratio = 1
+	}
+	percent := 5 + int(ratio*94)
+	if percent > 99 {
+		percent = 99
+	}
+	return percent
+}
@@ -0,0 +1,83 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"extratorDeAudio/internal/transcript"
+)
+
+// WordsPath is where word timestamps are stored for a transcript base path.
+func WordsPath(outputBasePath string) string {
+	return outputBasePath + ".words.json"
+}
+
+// wordsProbeTimeout bounds the whisper help probe.
+const wordsProbeTimeout = 10 * time.Second
+
+// supportsWordTimestamps reports whether the whisper build can write the
+// full JSON output (-ojf) that carries token timings. The answer comes from
+// the help text and is cached once whisper has run; a probe that fails to run
+// or times out is retried by the next transcription.
+func (s *Service) supportsWordTimestamps() bool {
+	s.wordsMu.Lock()
+	defer s.wordsMu.Unlock()
+	if s.wordsProbed {
+		return s.wordsSupported
+	}
+
+	// The probe outlives no request, so a canceled one can't poison the cache.
+	ctx, cancel := context.WithTimeout(context.Background(), wordsProbeTimeout)
+	defer cancel()
+	// whisper-cli prints its usage to stderr and may exit non-zero.
+	out, err := exec.CommandContext(ctx, s.whisperBin, "-h").CombinedOutput()
+	var exitErr *exec.ExitError
+	if ctx.Err() != nil || (err != nil && !errors.As(err, &exitErr)) {
+		s.logger.Warn("whisper help probe failed, word timestamps skipped for now", "whisper_bin", s.whisperBin, "error", err)
+		return false
+	}
+	s.wordsProbed = true
+	s.wordsSupported = hasFullJSONOutput(string(out))
+	if !s.wordsSupported {
+		s.logger.Warn("whisper build has no full JSON output, word timestamps disabled", "whisper_bin", s.whisperBin)
+	}
+	return s.wordsSupported
+}
+
+// hasFullJSONOutput reports whether a whisper help text lists -ojf.
+func hasFullJSONOutput(help string) bool {
+	return strings.Contains(help, "-ojf") || strings.Contains(help, "--output-json-full")
+}
+
+// writeWords converts whisper's <base>.json into <base>.words.json.
+func writeWords(outputBasePath string) error {
+	rawPath := outputBasePath + ".json"
+	defer os.Remove(rawPath)
+
+	f, err := os.Open(rawPath)
+	if err != nil {
+		return err
+	}
+	words, err := transcript.ParseWhisperJSON(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	return writeWordsFile(WordsPath(outputBasePath), words)
+}
+
+func writeWordsFile(path string, words []transcript.Word) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := transcript.WriteWords(out, words); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
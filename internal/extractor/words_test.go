@@ -0,0 +1,34 @@
+package extractor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWordTimestampProbeRetriesAfterFailure(t *testing.T) {
+	s := newTestService()
+	s.whisperBin = filepath.Join(t.TempDir(), "missing-whisper")
+	if s.supportsWordTimestamps() {
+		t.Fatal("missing whisper reported word timestamps")
+	}
+	if s.wordsProbed {
+		t.Fatal("a probe that could not run was cached")
+	}
+
+	// Like whisper-cli, the fake prints its usage to stderr and exits non-zero.
+	bin := filepath.Join(t.TempDir(), "whisper-cli")
+	script := "#!/bin/sh\necho '  -ojf,      --output-json-full' >&2\nexit 1\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s.whisperBin = bin
+	if !s.supportsWordTimestamps() {
+		t.Fatal("whisper with -ojf not detected")
+	}
+
+	s.whisperBin = filepath.Join(t.TempDir(), "missing-whisper")
+	if !s.supportsWordTimestamps() {
+		t.Error("successful probe was not cached")
+	}
+}
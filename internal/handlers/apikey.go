@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsKeyProtocol is the WebSocket subprotocol that carries the API key for
+// browsers, which can't set Authorization on the handshake: the client
+// offers ["apikey", "<key>"] and the server selects "apikey".
+const wsKeyProtocol = "apikey"
+
+// requireAPIKey rejects requests without a configured API key in the
+// Authorization header ("Bearer <key>") or, on WebSocket handshakes, in the
+// wsKeyProtocol subprotocol. The admin token is accepted too, since
+// admin-only upload options share that header. Without APIKeys every
+// request passes.
+func (a *App) requireAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(a.cfg.APIKeys) == 0 || a.validAPIKey(r) || a.isAdminRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="extratorDeAudio"`)
+		a.respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "chave de API ausente ou inválida"})
+	})
+}
+
+func (a *App) validAPIKey(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		if token, ok = wsProtocolKey(r); !ok {
+			return false
+		}
+	}
+	token = strings.TrimSpace(token)
+	valid := false
+	// Compare against every key so timing doesn't reveal which one matched.
+	for _, key := range a.cfg.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(key)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// wsProtocolKey returns the key offered after wsKeyProtocol in a WebSocket
+// handshake's Sec-WebSocket-Protocol list.
+func wsProtocolKey(r *http.Request) (string, bool) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		return "", false
+	}
+	protocols := websocket.Subprotocols(r)
+	for i, p := range protocols {
+		if p == wsKeyProtocol && i+1 < len(protocols) {
+			return protocols[i+1], true
+		}
+	}
+	return "", false
+}
+
+// ParseAPIKeys parses API_KEYS, a comma-separated list of accepted keys.
+func ParseAPIKeys(v string) []string {
+	var keys []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			keys = append(keys, part)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"extratorDeAudio/internal/models"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestAPIKeyGuardsJobQueries(t *testing.T) {
+	app := newTestApp(t, Config{APIKeys: []string{"segredo"}})
+	h := app.Router()
+
+	paths := []string{
+		"/api/jobs", "/api/jobs.csv", "/api/job/abc", "/job/abc/progress-series",
+		"/api/jobs/abc/transcript", "/api/jobs/abc/tracks", "/probe/abc", "/estimate/abc", "/ws/abc",
+	}
+	for _, path := range paths {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("GET %s without a key = %d, want 401", path, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/jobs", nil)
+	req.Header.Set("Authorization", "Bearer segredo")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /api/jobs with a key = %d, want 200", rec.Code)
+	}
+}
+
+func TestAPIKeyInWebSocketSubprotocol(t *testing.T) {
+	app := newTestApp(t, Config{APIKeys: []string{"segredo"}})
+	app.mu.Lock()
+	app.jobs["abc"] = &models.ExtractionJob{ID: "abc", Status: models.StatusUploaded}
+	app.mu.Unlock()
+	srv := httptest.NewServer(app.Router())
+	defer srv.Close()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/abc"
+
+	dialer := websocket.Dialer{Subprotocols: []string{wsKeyProtocol, "errada"}}
+	if _, resp, err := dialer.Dial(wsURL, nil); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("wrong key: err = %v, resp = %v, want 401", err, resp)
+	}
+
+	dialer.Subprotocols = []string{wsKeyProtocol, "segredo"}
+	conn, _, err := dialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("key in subprotocol: %v", err)
+	}
+	defer conn.Close()
+	if got := conn.Subprotocol(); got != wsKeyProtocol {
+		t.Errorf("selected subprotocol = %q, want %q", got, wsKeyProtocol)
+	}
+
+	// Only handshakes may carry the key this way.
+	req := httptest.NewRequest(http.MethodGet, "/api/jobs", nil)
+	req.Header.Set("Sec-WebSocket-Protocol", wsKeyProtocol+", segredo")
+	rec := httptest.NewRecorder()
+	app.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("subprotocol key on a plain request = %d, want 401", rec.Code)
+	}
+}
+
+func TestValidateOptionsNeedsKeyAndAdminForOutputDir(t *testing.T) {
+	app := newTestApp(t, Config{APIKeys: []string{"segredo"}, AdminToken: "admin"})
+	h := app.Router()
+
+	post := func(token, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/validate-options", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := post("", `{"format":"mp3"}`); code != http.StatusUnauthorized {
+		t.Errorf("without a key = %d, want 401", code)
+	}
+	if code := post("segredo", `{"format":"mp3"}`); code != http.StatusOK {
+		t.Errorf("with a key = %d, want 200", code)
+	}
+	if code := post("segredo", `{"output_dir":"lote"}`); code != http.StatusForbidden {
+		t.Errorf("output_dir with an API key = %d, want 403", code)
+	}
+	if code := post("admin", `{"output_dir":"lote"}`); code != http.StatusOK {
+		t.Errorf("output_dir with the admin token = %d, want 200", code)
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// zipEntry is a file added to a streamed ZIP bundle.
+type zipEntry struct {
+	Name string
+	Path string
+}
+
+// serveZip streams the given files as a ZIP attachment. Audio is stored as-is
+// because it is already compressed; text files are deflated.
+func (a *App) serveZip(w http.ResponseWriter, r *http.Request, name string, entries []zipEntry) {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+name+"\"")
+
+	zw := zip.NewWriter(w)
+	for _, entry := range entries {
+		if err := a.addZipEntry(r.Context(), zw, entry); err != nil {
+			// Headers are already sent; all we can do is stop and log.
+			a.logger.Error("failed to write zip entry", "file", entry.Path, "error", err)
+			_ = zw.Close()
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		a.logger.Error("failed to finish zip", "error", err)
+	}
+}
+
+func (a *App) addZipEntry(ctx context.Context, zw *zip.Writer, entry zipEntry) error {
+	src, modTime, err := a.openOutput(ctx, entry.Path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	header := &zip.FileHeader{Name: entry.Name, Method: zip.Store, Modified: modTime}
+	switch strings.ToLower(filepath.Ext(entry.Name)) {
+	case ".txt", ".srt", ".vtt", ".json":
+		header.Method = zip.Deflate
+	}
+
+	dst, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// downloadAll streams every ready file of a job in one ZIP: the audio
+// output(s) plus the transcripts that exist.
+func (a *App) downloadAll(w http.ResponseWriter, r *http.Request) {
+	job, ok := a.getJob(chi.URLParam(r, "id"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if job.ArchivedAt != nil {
+		http.Error(w, "arquivado", http.StatusGone)
+		return
+	}
+
+	var entries []zipEntry
+	add := func(name, path string) {
+		if name != "" && path != "" && a.outputExists(r.Context(), path) {
+			entries = append(entries, zipEntry{Name: name, Path: path})
+		}
+	}
+	if job.Status == models.StatusCompleted {
+		switch {
+		case len(job.Outputs) > 0:
+			for _, out := range job.Outputs {
+				add(out.Name, out.Path)
+			}
+		case job.Format == extractor.FormatHLS:
+			entries = append(entries, hlsEntries(job, "hls/")...)
+		default:
+			add(job.OutputName, job.OutputPath)
+		}
+	}
+	if job.TranscriptStatus == models.StatusCompleted {
+		add(job.TranscriptTXTName, job.TranscriptTXTPath)
+		add(job.TranscriptSRTName, job.TranscriptSRTPath)
+		add(job.TranscriptVTTName, job.TranscriptVTTPath)
+	}
+	if len(entries) == 0 {
+		http.Error(w, "nenhum arquivo pronto", http.StatusConflict)
+		return
+	}
+	a.serveZip(w, r, friendlyBaseName(job.InputFileName, "job")+".zip", entries)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"extratorDeAudio/internal/models"
+)
+
+const (
+	defaultMaxQueueDepth = 20
+	// queueRetryAfter is the Retry-After hint, in seconds, sent with 429s.
+	queueRetryAfter = 30
+)
+
+// queueDepthLocked counts the extractions and transcriptions waiting to
+// start, either queued or scheduled for the heavy job window, plus the
+// conversions and samples waiting for a pool slot. Callers must hold a.mu.
+func (a *App) queueDepthLocked() int {
+	depth := a.extractPool.waitingTasks() + a.transcribePool.waitingTasks()
+	for _, job := range a.jobs {
+		for _, status := range []models.JobStatus{job.Status, job.TranscriptStatus} {
+			if status == models.StatusQueued || status == models.StatusScheduled {
+				depth++
+			}
+		}
+	}
+	return depth
+}
+
+// capacity summarizes the load for /healthz, so load balancers and
+// autoscalers can route on it: stages running right now, work waiting to
+// start, the configured limit on both (omitted when disabled) and whether
+// new work is accepted.
+func (a *App) capacity() map[string]any {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	out := map[string]any{
+		"workers_active": len(a.running),
+		"queue_depth":    a.queueDepthLocked(),
+		"accepting_work": !a.maintenance.Load() && !a.queueFullLocked(),
+		"workers": map[string]any{
+			"extraction":    a.extractPool.stats(),
+			"transcription": a.transcribePool.stats(),
+		},
+	}
+	if limit := a.cfg.MaxQueueDepth; limit >= 0 {
+		if limit == 0 {
+			limit = defaultMaxQueueDepth
+		}
+		out["queue_limit"] = limit
+	}
+	return out
+}
+
+// queueFullLocked reports whether new work must be rejected: the work
+// waiting to start, as reported in capacity's queue_depth, reached
+// MaxQueueDepth. Running stages and uploads don't count. A negative
+// MaxQueueDepth disables the limit. Callers must hold a.mu.
+func (a *App) queueFullLocked() bool {
+	depth := a.cfg.MaxQueueDepth
+	if depth < 0 {
+		return false
+	}
+	if depth == 0 {
+		depth = defaultMaxQueueDepth
+	}
+	return a.queueDepthLocked() >= depth
+}
+
+// queueFull is queueFullLocked for callers that don't hold the lock.
+func (a *App) queueFull() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.queueFullLocked()
+}
+
+// rejectQueueFull answers 429 with a Retry-After so clients back off.
+func (a *App) rejectQueueFull(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", strconv.Itoa(queueRetryAfter))
+	http.Error(w, "servidor ocupado, tente novamente em instantes", http.StatusTooManyRequests)
+}
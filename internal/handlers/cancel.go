@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"extratorDeAudio/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// runningStage is the in-flight extraction or transcription of a job, which
+// POST /cancel/{id} can stop.
+type runningStage struct {
+	stage    string
+	cancel   context.CancelFunc
+	canceled bool
+}
+
+// heavyWait is a stage sleeping until the heavy job window opens; stop
+// wakes it up for good.
+type heavyWait struct {
+	gen  uint64
+	stop context.CancelFunc
+}
+
+// runKey identifies a job's stage in generations and waiting.
+func runKey(jobID, stage string) string {
+	return jobID + "/" + stage
+}
+
+// beginRun starts a new run of the job's stage and returns its generation.
+// Starting or canceling the stage again makes older runs stale, so a run
+// that was waiting for the heavy job window or a worker stops at its next
+// check instead of processing the job a second time.
+func (a *App) beginRun(jobID, stage string) uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.invalidateRunLocked(jobID, stage)
+	return a.generations[runKey(jobID, stage)]
+}
+
+// currentRunLocked reports whether gen is still the job stage's latest
+// run. Callers must hold a.mu.
+func (a *App) currentRunLocked(jobID, stage string, gen uint64) bool {
+	return a.generations[runKey(jobID, stage)] == gen
+}
+
+// invalidateRunLocked makes every run of the job's stage stale and wakes
+// the one waiting for the heavy job window. Callers must hold a.mu.
+func (a *App) invalidateRunLocked(jobID, stage string) {
+	key := runKey(jobID, stage)
+	a.generations[key]++
+	if wait, ok := a.waiting[key]; ok {
+		wait.stop()
+		delete(a.waiting, key)
+	}
+}
+
+// forgetRunsLocked drops the run bookkeeping of a removed job; its stale
+// runs stop at their next check. Callers must hold a.mu.
+func (a *App) forgetRunsLocked(jobID string) {
+	for _, stage := range []string{"extraction", "transcription"} {
+		a.invalidateRunLocked(jobID, stage)
+		delete(a.generations, runKey(jobID, stage))
+	}
+}
+
+// stageStatus returns the job's status for stage.
+func stageStatus(job *models.ExtractionJob, stage string) models.JobStatus {
+	if stage == "transcription" {
+		return job.TranscriptStatus
+	}
+	return job.Status
+}
+
+// startRun registers cancel for the job's stage. It reports false, and the
+// caller must stop, when the job is gone, was canceled while it waited in
+// the queue or gen is no longer its latest run.
+func (a *App) startRun(jobID, stage string, gen uint64, cancel context.CancelFunc) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	job, ok := a.jobs[jobID]
+	if !ok || stageStatus(job, stage) == models.StatusCanceled || !a.currentRunLocked(jobID, stage, gen) {
+		return false
+	}
+	a.running[jobID] = &runningStage{stage: stage, cancel: cancel}
+	return true
+}
+
+func (a *App) endRun(jobID string) {
+	a.mu.Lock()
+	delete(a.running, jobID)
+	a.mu.Unlock()
+}
+
+// stageCanceled reports whether the running stage was canceled, so its
+// worker reports the cancellation instead of a failure or a result.
+func (a *App) stageCanceled(jobID, stage string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	run, ok := a.running[jobID]
+	return ok && run.stage == stage && run.canceled
+}
+
+// cancelJob stops the queued, scheduled or running extraction or
+// transcription of a job. ffmpeg and whisper run under the stage's context,
+// so canceling it kills them.
+func (a *App) cancelJob(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+
+	a.mu.Lock()
+	job, ok := a.jobs[jobID]
+	if !ok {
+		a.mu.Unlock()
+		http.Error(w, "job não encontrado", http.StatusNotFound)
+		return
+	}
+	stage := ""
+	for _, s := range []string{"extraction", "transcription"} {
+		switch stageStatus(job, s) {
+		case models.StatusQueued, models.StatusScheduled, models.StatusProcessing:
+			stage = s
+		}
+	}
+	if stage == "" {
+		a.mu.Unlock()
+		http.Error(w, "nada em andamento para cancelar", http.StatusConflict)
+		return
+	}
+
+	message := "extração cancelada"
+	if stage == "transcription" {
+		message = "transcrição cancelada"
+		job.TranscriptStatus = models.StatusCanceled
+		job.TranscriptProgress = 0
+		job.TranscriptError = ""
+	} else {
+		job.Status = models.StatusCanceled
+		job.Progress = 0
+		job.Error = ""
+		job.ErrorCode = ""
+	}
+	job.ScheduledAt = nil
+	job.QueuePosition = 0
+	job.UpdatedAt = time.Now()
+	a.invalidateRunLocked(jobID, stage)
+	if run, ok := a.running[jobID]; ok && run.stage == stage {
+		run.canceled = true
+		run.cancel()
+	}
+	a.mu.Unlock()
+	a.stagePool(stage).remove(jobID)
+
+	a.logger.Info("job canceled", "job_id", jobID, "stage", stage)
+	a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: stage, Status: models.StatusCanceled, Progress: 0, Message: message})
+	a.notifyWebhook(jobID, stage+".canceled")
+	a.respondJSON(w, http.StatusOK, map[string]string{"status": "canceled", "job_id": jobID, "stage": stage})
+}
+
+// settleCanceled puts the stage back in the canceled state once its worker
+// has stopped, since progress updates racing the cancel may have overwritten
+// it, and drops partial audio outputs.
+func (a *App) settleCanceled(jobID, stage string) {
+	var partial []string
+	a.updateJob(jobID, func(j *models.ExtractionJob) {
+		if stage == "transcription" {
+			j.TranscriptStatus = models.StatusCanceled
+			j.TranscriptProgress = 0
+			j.TranscriptError = ""
+		} else {
+			j.Status = models.StatusCanceled
+			j.Progress = 0
+			j.Error = ""
+			partial = jobOutputPaths(j)
+		}
+		j.UpdatedAt = time.Now()
+	})
+	for _, path := range partial {
+		_ = os.Remove(path)
+	}
+	a.logger.Info("canceled stage stopped", "job_id", jobID, "stage", stage)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"os"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+	"extratorDeAudio/internal/transcript"
+)
+
+// addChapters derives chapters from the finished SRT, stores them on the job
+// and, for containers that support it, embeds them into the extracted audio,
+// found on disk at audioPath (see localAudio). Failures are logged only:
+// chapters never fail a transcription.
+func (a *App) addChapters(ctx context.Context, job *models.ExtractionJob, audioPath, srtPath, base string) {
+	segments, err := readSRTFile(srtPath)
+	if err != nil {
+		a.logger.Warn("chapters skipped, transcript unreadable", "job_id", job.ID, "error", err)
+		return
+	}
+	chapters := transcript.BuildChapters(segments)
+	if len(chapters) == 0 {
+		return
+	}
+
+	stored := make([]models.Chapter, len(chapters))
+	for i, ch := range chapters {
+		stored[i] = models.Chapter{Start: ch.Start.Seconds(), End: ch.End.Seconds(), Title: ch.Title}
+	}
+	a.updateJob(job.ID, func(j *models.ExtractionJob) {
+		j.Chapters = stored
+	})
+
+	if !extractor.ChapterFormats[job.Format] {
+		return
+	}
+
+	metadataPath := base + ".chapters.txt"
+	defer os.Remove(metadataPath)
+	if err := writeFileAtomic(metadataPath, func(f *os.File) error { return transcript.WriteFFMetadata(f, chapters) }); err != nil {
+		a.logger.Warn("failed to write chapters", "job_id", job.ID, "error", err)
+		return
+	}
+	if err := a.extractor.EmbedChapters(ctx, audioPath, metadataPath, job.NoFaststart); err != nil {
+		a.logger.Warn("failed to embed chapters", "job_id", job.ID, "error", err)
+		return
+	}
+	// The remote copy predates the chapters.
+	if a.remoteStorage() {
+		if err := a.putFileAs(ctx, audioPath, job.OutputPath); err != nil {
+			a.logger.Warn("failed to upload audio with chapters", "job_id", job.ID, "error", err)
+		}
+	}
+}
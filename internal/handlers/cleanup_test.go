@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"extratorDeAudio/internal/models"
+)
+
+func TestCleanupSkipsRunningJobs(t *testing.T) {
+	a := newTestApp(t, Config{})
+	stale := time.Now().Add(-48 * time.Hour)
+	jobs := map[string]*models.ExtractionJob{
+		"done":         {ID: "done", Status: models.StatusCompleted},
+		"extracting":   {ID: "extracting", Status: models.StatusProcessing},
+		"queued":       {ID: "queued", Status: models.StatusQueued},
+		"uploading":    {ID: "uploading", Status: models.StatusUploading},
+		"transcribing": {ID: "transcribing", Status: models.StatusCompleted, TranscriptStatus: models.StatusProcessing},
+	}
+	a.mu.Lock()
+	for id, job := range jobs {
+		job.UpdatedAt = stale
+		a.jobs[id] = job
+	}
+	a.mu.Unlock()
+
+	a.cleanup(24*time.Hour, 72*time.Hour)
+
+	for id := range jobs {
+		job, ok := a.getJob(id)
+		if !ok {
+			t.Fatalf("job %s was removed", id)
+		}
+		if archived := job.ArchivedAt != nil; archived != (id == "done") {
+			t.Errorf("job %s archived = %v", id, archived)
+		}
+	}
+
+	a.cleanup(24*time.Hour, 0)
+	if _, ok := a.getJob("done"); ok {
+		t.Error("expired job was kept without a grace period")
+	}
+	if _, ok := a.getJob("extracting"); !ok {
+		t.Error("running job was removed without a grace period")
+	}
+}
+
+func TestCleanupRemovesEmptyOutputDir(t *testing.T) {
+	a := newTestApp(t, Config{})
+	stale := time.Now().Add(-48 * time.Hour)
+	jobs := map[string]string{"first": "lote/ep1", "second": "lote/ep2"}
+	for id, dir := range jobs {
+		job := &models.ExtractionJob{ID: id, Status: models.StatusCompleted, OutputDir: dir, UpdatedAt: stale}
+		job.OutputPath = filepath.Join(a.jobOutputDir(job), id+".mp3")
+		if err := os.MkdirAll(filepath.Dir(job.OutputPath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(job.OutputPath, []byte("audio"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		a.mu.Lock()
+		a.jobs[id] = job
+		a.mu.Unlock()
+	}
+	a.updateJob("second", func(j *models.ExtractionJob) { j.UpdatedAt = time.Now() })
+
+	a.cleanup(24*time.Hour, 0)
+
+	if _, err := os.Stat(filepath.Join(a.outputsDir, "lote", "ep1")); !os.IsNotExist(err) {
+		t.Errorf("expired job's output dir still exists: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(a.outputsDir, "lote", "ep2", "second.mp3")); err != nil {
+		t.Errorf("live job's output was touched: %v", err)
+	}
+
+	a.cleanup(-time.Second, 0)
+	if _, err := os.Stat(filepath.Join(a.outputsDir, "lote")); !os.IsNotExist(err) {
+		t.Errorf("empty parent dir still exists: %v", err)
+	}
+	if _, err := os.Stat(a.outputsDir); err != nil {
+		t.Errorf("outputs root removed: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// contentTypes maps output formats and file extensions to the MIME type sent
+// on download. mime.TypeByExtension depends on the host's mime.types and gets
+// some audio containers wrong, which makes browsers refuse to play them.
+var contentTypes = map[string]string{
+	"mp3":  "audio/mpeg",
+	"wav":  "audio/wav",
+	"aac":  "audio/aac",
+	"m4a":  "audio/mp4",
+	"flac": "audio/flac",
+	"ogg":  "audio/ogg",
+	"opus": "audio/ogg; codecs=opus",
+	"txt":  "text/plain; charset=utf-8",
+	"srt":  "application/x-subrip; charset=utf-8",
+	"vtt":  "text/vtt; charset=utf-8",
+	"json": "application/json",
+}
+
+// contentTypeFor picks the MIME type from the job format, falling back to the
+// file name's extension (e.g. stream copy keeps the source container).
+func contentTypeFor(format, name string) string {
+	if ct, ok := contentTypes[strings.ToLower(strings.TrimSpace(format))]; ok {
+		return ct
+	}
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+	return contentTypes[ext]
+}
+
+// setContentType sets an explicit Content-Type so http.ServeFile doesn't
+// guess one. Unknown types are left to the default detection.
+func setContentType(w http.ResponseWriter, format, name string) {
+	if ct := contentTypeFor(format, name); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"extratorDeAudio/internal/models"
+	"extratorDeAudio/internal/transcript"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// convertTranscript serves the job's transcript in another format, converted
+// from the SRT in Go instead of running whisper again.
+func (a *App) convertTranscript(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+	job, ok := a.getJob(jobID)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if job.ArchivedAt != nil {
+		http.Error(w, "arquivado", http.StatusGone)
+		return
+	}
+	if job.TranscriptStatus != models.StatusCompleted {
+		http.Error(w, "transcrição ainda não está pronta", http.StatusConflict)
+		return
+	}
+
+	to := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("to")))
+	switch to {
+	case transcript.FormatSRT, transcript.FormatVTT, transcript.FormatText, transcript.FormatJSON:
+	default:
+		http.Error(w, "formato inválido (use srt, vtt, txt ou json)", http.StatusBadRequest)
+		return
+	}
+
+	// VTT cue settings default to the server's and can be overridden one
+	// by one from the query string.
+	cues := a.cfg.VTTCueSettings
+	if to == transcript.FormatVTT {
+		for _, name := range []string{"line", "position", "size", "align"} {
+			v := strings.TrimSpace(r.URL.Query().Get(name))
+			if v == "" {
+				continue
+			}
+			if err := cues.Set(name, v); err != nil {
+				http.Error(w, "configuração de legenda inválida: "+name, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	segments, err := readSRTFile(job.TranscriptSRTPath)
+	if err != nil {
+		http.Error(w, "arquivo de transcrição não encontrado", http.StatusNotFound)
+		return
+	}
+
+	var buf bytes.Buffer
+	if to == transcript.FormatVTT {
+		err = transcript.WriteStyledVTT(&buf, segments, cues)
+	} else {
+		err = transcript.Write(&buf, segments, to)
+	}
+	if err != nil {
+		a.logger.Error("transcript conversion failed", "job_id", jobID, "to", to, "error", err)
+		http.Error(w, "erro ao converter transcrição", http.StatusInternalServerError)
+		return
+	}
+
+	name := strings.TrimSuffix(job.TranscriptSRTName, filepath.Ext(job.TranscriptSRTName)) + "." + to
+	setContentType(w, "", name)
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+name+"\"")
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ParseAllowedOrigins parses ALLOWED_ORIGINS, a comma-separated list of
+// origins (scheme://host[:port]) allowed to call the API from a browser.
+// "*" allows any origin; empty allows none.
+func ParseAllowedOrigins(v string) ([]string, error) {
+	var origins []string
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if part == "*" {
+			origins = append(origins, part)
+			continue
+		}
+		origin := strings.ToLower(strings.TrimSuffix(part, "/"))
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			return nil, fmt.Errorf("invalid origin %q, want scheme://host[:port]", part)
+		}
+		origins = append(origins, origin)
+	}
+	return origins, nil
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin value for the
+// request's Origin, or "" when the origin is not allowed.
+func (a *App) allowedOrigin(origin string) string {
+	if origin == "" {
+		return ""
+	}
+	for _, allowed := range a.cfg.AllowedOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// checkWSOrigin is the WebSocket upgrader's origin check. Browsers always
+// send Origin on the handshake and CORS doesn't apply to it, so pages from
+// other sites must be on AllowedOrigins here too. Clients without Origin
+// (not browsers) and pages served by this host pass.
+func (a *App) checkWSOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return a.allowedOrigin(origin) != ""
+}
+
+// corsMiddleware echoes the Origin back only when AllowedOrigins lists it
+// (or contains "*"), and answers preflight requests itself.
+func (a *App) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := a.allowedOrigin(r.Header.Get("Origin"))
+		if origin != "*" {
+			// Responses differ per origin, so shared caches must key on it.
+			w.Header().Add("Vary", "Origin")
+		}
+		if origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", "Upload-Offset, Upload-Length")
+		}
+
+		if r.Method == http.MethodOptions {
+			if origin != "" {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+				w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PATCH, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Upload-Offset")
+				w.Header().Set("Access-Control-Max-Age", "600")
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
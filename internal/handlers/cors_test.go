@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"extratorDeAudio/internal/models"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWebSocketOriginCheck(t *testing.T) {
+	app := newTestApp(t, Config{AllowedOrigins: []string{"https://app.exemplo.com"}})
+	app.mu.Lock()
+	app.jobs["abc"] = &models.ExtractionJob{ID: "abc", Status: models.StatusUploaded}
+	app.mu.Unlock()
+	srv := httptest.NewServer(app.Router())
+	defer srv.Close()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/abc"
+
+	tests := []struct {
+		origin string
+		want   int
+	}{
+		{"https://evil.example", http.StatusForbidden},
+		{"https://app.exemplo.com", http.StatusSwitchingProtocols},
+		{srv.URL, http.StatusSwitchingProtocols},
+		{"", http.StatusSwitchingProtocols},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.origin != "" {
+			header.Set("Origin", tt.origin)
+		}
+		conn, resp, err := websocket.DefaultDialer.Dial(wsURL, header)
+		if conn != nil {
+			conn.Close()
+		}
+		if resp == nil {
+			t.Fatalf("origin %q: %v", tt.origin, err)
+		}
+		if resp.StatusCode != tt.want {
+			t.Errorf("origin %q: status = %d, want %d", tt.origin, resp.StatusCode, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"extratorDeAudio/internal/models"
+)
+
+// link is one action a client can take on a job.
+type link struct {
+	Href   string `json:"href"`
+	Method string `json:"method"`
+}
+
+// wantsJSON reports whether the client asked for a JSON answer instead of
+// the HTML redirect, via the Accept header or ?json=1.
+func wantsJSON(r *http.Request) bool {
+	if parseBool(r.URL.Query().Get("json")) {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// jobLinks lists the endpoints that act on the job.
+func jobLinks(job *models.ExtractionJob) map[string]link {
+	id := job.ID
+	return map[string]link{
+		"self":       {Href: "/api/job/" + id, Method: http.MethodGet},
+		"page":       {Href: "/job/" + id, Method: http.MethodGet},
+		"extract":    {Href: "/extract/" + id, Method: http.MethodGet},
+		"estimate":   {Href: "/estimate/" + id, Method: http.MethodGet},
+		"samples":    {Href: "/api/jobs/" + id + "/samples", Method: http.MethodPost},
+		"transcribe": {Href: "/transcribe/" + id, Method: http.MethodGet},
+		"ws":         {Href: "/ws/" + id, Method: http.MethodGet},
+		"download":   {Href: "/download/" + id, Method: http.MethodGet},
+	}
+}
+
+// respondJobCreated answers an API upload with 201 and the job's links.
+func (a *App) respondJobCreated(w http.ResponseWriter, job *models.ExtractionJob) {
+	w.Header().Set("Location", "/api/job/"+job.ID)
+	a.respondJSON(w, http.StatusCreated, map[string]any{
+		"job": map[string]any{
+			"id":              job.ID,
+			"status":          job.Status,
+			"input_file_name": job.InputFileName,
+			"format":          job.Format,
+			"quality":         job.Quality,
+			"created_at":      job.CreatedAt.Format(time.RFC3339),
+		},
+		"links": jobLinks(job),
+	})
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"extratorDeAudio/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// deleteJob purges a job right away instead of waiting for the cleanup TTL:
+// running stages are stopped, subscribers get a final "deleted" event and
+// the upload, outputs and transcripts are removed from disk.
+func (a *App) deleteJob(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+
+	a.mu.Lock()
+	job, ok := a.jobs[jobID]
+	if !ok {
+		a.mu.Unlock()
+		http.Error(w, "job não encontrado", http.StatusNotFound)
+		return
+	}
+	removed := *job
+	delete(a.jobs, jobID)
+	a.forgetRunsLocked(jobID)
+	if run, ok := a.running[jobID]; ok {
+		run.canceled = true
+		run.cancel()
+	}
+	a.mu.Unlock()
+	a.extractPool.remove(jobID)
+	a.transcribePool.remove(jobID)
+
+	a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "job", Status: models.StatusDeleted, Message: "job removido"})
+	a.closeSubscribers(jobID)
+	a.progressSeries.forget(jobID)
+	a.media.forget(jobID)
+	a.removeJobFiles(removed)
+
+	a.logger.Info("job deleted", "job_id", jobID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+)
+
+// EnsureWritableDir creates dir if needed and checks that files can be
+// created in it, so a bad mount fails at startup instead of on the first job.
+func EnsureWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	defaultMinFreeDiskBytes = 256 * 1024 * 1024
+	// defaultUploadSpaceFactor leaves room for the upload plus the audio
+	// and transcripts produced from it.
+	defaultUploadSpaceFactor = 2.0
+)
+
+// checkFreeSpace rejects an upload of size bytes (unknown when <= 0) when the
+// uploads or outputs filesystem has less free space than MinFreeDiskBytes or
+// size × UploadSpaceFactor, whichever is larger. A negative MinFreeDiskBytes
+// disables the check; a filesystem that can't be inspected doesn't block.
+func (a *App) checkFreeSpace(size int64) error {
+	minFree := a.minFreeDiskBytes()
+	if minFree < 0 {
+		return nil
+	}
+	factor := a.cfg.UploadSpaceFactor
+	if factor <= 0 {
+		factor = defaultUploadSpaceFactor
+	}
+	required := uint64(minFree)
+	if size > 0 {
+		if need := uint64(float64(size) * factor); need > required {
+			required = need
+		}
+	}
+
+	dirs := []string{a.uploadsDir}
+	if a.outputsDir != a.uploadsDir {
+		dirs = append(dirs, a.outputsDir)
+	}
+	for _, dir := range dirs {
+		free, err := freeDiskBytes(dir)
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		if err != nil {
+			a.logger.Warn("could not check free disk space", "dir", dir, "error", err)
+			continue
+		}
+		if free < required {
+			return fmt.Errorf("%s has %d bytes free, need %d", dir, free, required)
+		}
+	}
+	return nil
+}
+
+// minFreeDiskBytes is the effective MinFreeDiskBytes; negative means the
+// check is disabled.
+func (a *App) minFreeDiskBytes() int64 {
+	if a.cfg.MinFreeDiskBytes == 0 {
+		return defaultMinFreeDiskBytes
+	}
+	return a.cfg.MinFreeDiskBytes
+}
+
+// diskStatus reports each storage root for /healthz by name, with low set
+// when its free space is under the MinFreeDiskBytes floor. The directory
+// paths and byte counts are only included with detail (admin requests).
+func (a *App) diskStatus(detail bool) []map[string]any {
+	minFree := a.minFreeDiskBytes()
+	var out []map[string]any
+	seen := make(map[string]bool)
+	roots := []struct{ name, dir string }{
+		{"uploads", a.uploadsDir},
+		{"outputs", a.outputsDir},
+		{"transcripts", a.transcriptsDir},
+	}
+	for _, root := range roots {
+		if seen[root.dir] {
+			continue
+		}
+		seen[root.dir] = true
+		free, err := freeDiskBytes(root.dir)
+		if err != nil {
+			continue
+		}
+		entry := map[string]any{"name": root.name}
+		if detail {
+			entry["dir"] = root.dir
+			entry["free_bytes"] = free
+		}
+		if minFree >= 0 {
+			if detail {
+				entry["min_free_bytes"] = minFree
+			}
+			entry["low"] = free < uint64(minFree)
+		}
+		out = append(out, entry)
+	}
+	return out
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package handlers
+
+import "errors"
+
+// freeDiskBytes can't inspect the filesystem on this platform, so the
+// free-space guard is skipped.
+func freeDiskBytes(dir string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
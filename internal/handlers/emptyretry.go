@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+)
+
+// errEmptyTranscript fails transcriptions that stayed blank after the retry.
+var errEmptyTranscript = errors.New("a transcrição ficou vazia (áudio sem fala ou idioma errado?)")
+
+// minTranscriptChars is how many letters or digits a transcript needs to
+// count as non-empty.
+const minTranscriptChars = 3
+
+// whisperMarkerRe matches the markers whisper emits for non-speech, such as
+// "[BLANK_AUDIO]", "[Música]" or "(risos)".
+var whisperMarkerRe = regexp.MustCompile(`\[[^\]]*\]|\([^)]*\)`)
+
+// ValidateEmptyTranscriptRetry checks EMPTY_TRANSCRIPT_RETRY: empty
+// (disabled), "auto" or a supported language code.
+func ValidateEmptyTranscriptRetry(v string) error {
+	if _, ok := sanitizeLanguage(v); !ok {
+		return fmt.Errorf("unsupported language %q", v)
+	}
+	return nil
+}
+
+// transcriptEmpty reports whether the TXT at path holds no actual speech:
+// only whitespace, punctuation or whisper's non-speech markers.
+func transcriptEmpty(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	text := whisperMarkerRe.ReplaceAllString(string(data), "")
+	count := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			count++
+			if count >= minTranscriptChars {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// retryEmptyTranscript runs a second transcription when whisper finished
+// but wrote an empty TXT. The retry uses the EMPTY_TRANSCRIPT_RETRY
+// language, or auto-detect when that is what the first run already tried
+// with a fixed language. Without the setting, or when the retry would repeat
+// the first run, the empty transcript is kept as before. It fails with
+// errEmptyTranscript if the retry is blank too.
+func (a *App) retryEmptyTranscript(jobID, txtPath, usedLanguage string, opts extractor.TranscribeOptions, transcribe func(extractor.TranscribeOptions) error, cb extractor.ProgressCallback) error {
+	fallback := strings.ToLower(strings.TrimSpace(a.cfg.EmptyTranscriptRetry))
+	if fallback == "" {
+		return nil
+	}
+	empty, err := transcriptEmpty(txtPath)
+	if err != nil || !empty {
+		return err
+	}
+	if fallback == usedLanguage {
+		if usedLanguage == "auto" {
+			return errEmptyTranscript
+		}
+		fallback = "auto"
+	}
+
+	a.logger.Warn("empty transcript, retrying", "job_id", jobID, "language", usedLanguage, "retry_language", fallback)
+	cb(5, "processing", fmt.Sprintf("transcrição vazia com idioma %s, tentando novamente com %s", usedLanguage, fallback))
+	opts.Language = fallback
+	if err := transcribe(opts); err != nil {
+		return err
+	}
+	a.updateJob(jobID, func(j *models.ExtractionJob) {
+		j.TranscriptLanguage = fallback
+	})
+
+	empty, err = transcriptEmpty(txtPath)
+	if err != nil {
+		return err
+	}
+	if empty {
+		return errEmptyTranscript
+	}
+	return nil
+}
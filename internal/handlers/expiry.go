@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"time"
+
+	"extratorDeAudio/internal/models"
+)
+
+// defaultExpiryWarning is how long before the cleanup TTL expires a job's
+// subscribers are warned to download their files.
+const defaultExpiryWarning = time.Hour
+
+// warnExpiring broadcasts a warning to jobs that will expire within the
+// warning window, once per job until it is updated again.
+func (a *App) warnExpiring(ttl time.Duration) {
+	warning := a.cfg.ExpiryWarning
+	if warning < 0 {
+		return
+	}
+	if warning == 0 {
+		warning = defaultExpiryWarning
+	}
+	if warning > ttl/2 {
+		warning = ttl / 2
+	}
+
+	now := time.Now()
+	var events []models.ProgressEvent
+
+	a.mu.Lock()
+	for _, job := range a.jobs {
+		if job.ArchivedAt != nil || job.ScheduledAt != nil || !isJobIdle(job) {
+			continue
+		}
+		if job.ExpiryWarnedAt != nil && job.ExpiryWarnedAt.After(job.UpdatedAt) {
+			continue
+		}
+		expiresAt := job.UpdatedAt.Add(ttl)
+		if now.Before(expiresAt.Add(-warning)) {
+			continue
+		}
+		warnedAt := now
+		job.ExpiryWarnedAt = &warnedAt
+		events = append(events, models.ProgressEvent{
+			ID:        job.ID,
+			Stage:     "expiration",
+			Status:    job.Status,
+			Progress:  job.Progress,
+			Message:   "os arquivos deste job expiram às " + expiresAt.Format("15:04") + "; baixe-os antes disso",
+			Warning:   "expiring",
+			ExpiresAt: &expiresAt,
+		})
+	}
+	a.mu.Unlock()
+
+	for _, event := range events {
+		a.broadcast(event.ID, event)
+	}
+	if len(events) > 0 {
+		a.logger.Info("expiry warnings sent", "jobs", len(events))
+	}
+}
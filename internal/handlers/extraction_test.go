@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"extratorDeAudio/internal/models"
+)
+
+// uploadVideo posts a small MP4 to /upload and returns the new job ID.
+func uploadVideo(t *testing.T, h http.Handler, fields map[string]string) string {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		_ = mw.WriteField(k, v)
+	}
+	part, err := mw.CreateFormFile("video", "reuniao.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write(mp4Bytes(4096))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload?json=1", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("upload status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		Job struct {
+			ID string `json:"id"`
+		} `json:"job"`
+	}
+	decodeJSON(t, rec, &created)
+	return created.Job.ID
+}
+
+// waitExtraction waits for the job's extraction to leave the queue.
+func waitExtraction(t *testing.T, a *App, jobID string) models.ExtractionJob {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, ok := a.getJob(jobID)
+		if !ok {
+			t.Fatalf("job %s disappeared", jobID)
+		}
+		if job.Status == models.StatusCompleted || job.Status == models.StatusFailed {
+			return *job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("extraction still %s", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExtractionWithFakeExtractor(t *testing.T) {
+	fake := newFakeExtractor()
+	app := newTestApp(t, Config{}, WithExtractor(fake))
+	h := app.Router()
+
+	jobID := uploadVideo(t, h, map[string]string{"format": "opus", "quality": "high"})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/extract/"+jobID, nil))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("extract status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	job := waitExtraction(t, app, jobID)
+	if job.Status != models.StatusCompleted {
+		t.Fatalf("job status = %s (%s)", job.Status, job.Error)
+	}
+	calls := fake.extractCalls()
+	if len(calls) != 1 || calls[0].Format != "opus" || calls[0].Quality != "high" {
+		t.Fatalf("extract calls = %+v", calls)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download/"+jobID, nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != string(fake.audioData) {
+		t.Fatalf("download = %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Disposition"); got == "" {
+		t.Error("download has no Content-Disposition")
+	}
+}
+
+func TestExtractionFailureFromFakeExtractor(t *testing.T) {
+	fake := newFakeExtractor()
+	fake.extractErr = errors.New("ffmpeg explodiu")
+	app := newTestApp(t, Config{}, WithExtractor(fake))
+	h := app.Router()
+
+	jobID := uploadVideo(t, h, nil)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/extract/"+jobID, nil))
+
+	job := waitExtraction(t, app, jobID)
+	if job.Status != models.StatusFailed || job.Error == "" {
+		t.Fatalf("job = %s %q, want failed with the error", job.Status, job.Error)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download/"+jobID, nil))
+	if rec.Code == http.StatusOK {
+		t.Error("failed job served a download")
+	}
+}
+
+func TestRestartedExtractionDropsStaleArtifacts(t *testing.T) {
+	app := newTestApp(t, Config{}, WithExtractor(newFakeExtractor()))
+	h := app.Router()
+	jobID := uploadVideo(t, h, nil)
+
+	words := filepath.Join(app.transcriptsDir, jobID+"_transcript.words.json")
+	conversion := filepath.Join(app.outputsDir, jobID+"_ogg.ogg")
+	for _, path := range []string{words, conversion} {
+		if err := os.WriteFile(path, []byte("velho"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	app.updateJob(jobID, func(j *models.ExtractionJob) {
+		j.Status = models.StatusFailed
+		j.TranscriptWordsPath = words
+		j.Chapters = []models.Chapter{{Start: 0, End: 60, Title: "antigo"}}
+		j.Conversions = []models.Conversion{{Format: "ogg", Path: conversion, Status: models.StatusCompleted}}
+	})
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/extract/"+jobID, nil))
+	job := waitExtraction(t, app, jobID)
+	if job.TranscriptWordsPath != "" || job.Chapters != nil || job.Conversions != nil {
+		t.Errorf("stale artifacts kept: words %q, chapters %v, conversions %v", job.TranscriptWordsPath, job.Chapters, job.Conversions)
+	}
+	for _, path := range []string{words, conversion} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s not removed: %v", filepath.Base(path), err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"extratorDeAudio/internal/extractor"
+)
+
+var errNotFaked = errors.New("not implemented by the fake extractor")
+
+// fakeExtractor stands in for ffmpeg/whisper in handler tests. Extractions
+// write audioData to the output path; everything else reports a fixed
+// duration or errNotFaked.
+type fakeExtractor struct {
+	audioData []byte
+	duration  float64
+	// extractErr, when set, fails ExtractAudio instead.
+	extractErr error
+
+	mu       sync.Mutex
+	extracts []extractor.ExtractOptions
+}
+
+func newFakeExtractor() *fakeExtractor {
+	return &fakeExtractor{audioData: []byte("fake audio"), duration: 60}
+}
+
+// extractCalls returns the options of every ExtractAudio call so far.
+func (f *fakeExtractor) extractCalls() []extractor.ExtractOptions {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]extractor.ExtractOptions(nil), f.extracts...)
+}
+
+func (f *fakeExtractor) ExtractAudio(ctx context.Context, inputPath, outputPath string, opts extractor.ExtractOptions, cb extractor.ProgressCallback) error {
+	f.mu.Lock()
+	f.extracts = append(f.extracts, opts)
+	f.mu.Unlock()
+	if f.extractErr != nil {
+		return f.extractErr
+	}
+	if err := os.WriteFile(outputPath, f.audioData, 0o644); err != nil {
+		return err
+	}
+	if cb != nil {
+		cb(100, "completed", "extração concluída")
+	}
+	return nil
+}
+
+func (f *fakeExtractor) ExtractMulti(ctx context.Context, inputPath string, targets []extractor.ExtractTarget, cb extractor.ProgressCallback) error {
+	for _, t := range targets {
+		if err := f.ExtractAudio(ctx, inputPath, t.Path, t.Options, nil); err != nil {
+			return err
+		}
+	}
+	if cb != nil {
+		cb(100, "completed", "extração concluída")
+	}
+	return nil
+}
+
+func (f *fakeExtractor) TranscribeAudio(ctx context.Context, inputAudioPath, outputBasePath string, opts extractor.TranscribeOptions, cb extractor.ProgressCallback) error {
+	return errNotFaked
+}
+
+func (f *fakeExtractor) EstimateSize(ctx context.Context, inputPath, format, quality string, start, end float64) (extractor.SizeEstimate, error) {
+	return extractor.SizeEstimate{}, errNotFaked
+}
+
+func (f *fakeExtractor) TranscribeChunked(ctx context.Context, inputAudioPath, outputBasePath string, chunkSeconds float64, opts extractor.TranscribeOptions, cb extractor.ProgressCallback) error {
+	return errNotFaked
+}
+
+func (f *fakeExtractor) Fingerprint(ctx context.Context, audioPath string) (string, error) {
+	return "", errNotFaked
+}
+
+func (f *fakeExtractor) Duration(ctx context.Context, inputPath string) (float64, error) {
+	return f.duration, nil
+}
+
+func (f *fakeExtractor) EmbedChapters(ctx context.Context, audioPath, metadataPath string, noFaststart bool) error {
+	return errNotFaked
+}
+
+func (f *fakeExtractor) ExtractClip(ctx context.Context, inputPath, outputPath string, start, length float64) error {
+	return errNotFaked
+}
+
+func (f *fakeExtractor) ProbeAudioStreams(ctx context.Context, inputPath string) ([]extractor.AudioStream, error) {
+	return nil, errNotFaked
+}
+
+func (f *fakeExtractor) DetectLanguage(ctx context.Context, inputAudioPath string, seconds float64, opts extractor.TranscribeOptions) (string, error) {
+	return "", errNotFaked
+}
+
+func (f *fakeExtractor) ProbeInfo(ctx context.Context, inputPath string) (extractor.MediaInfo, error) {
+	return extractor.MediaInfo{}, errNotFaked
+}
+
+func (f *fakeExtractor) VerifyOutput(ctx context.Context, outputPath string, expected float64, checkSilence bool) error {
+	return nil
+}
+
+func (f *fakeExtractor) CheckTools(ctx context.Context) []extractor.ToolStatus {
+	return []extractor.ToolStatus{
+		{Name: "ffmpeg", Available: true, Required: true},
+		{Name: "ffprobe", Available: true, Required: true},
+		{Name: "whisper", Available: true},
+	}
+}
+
+func (f *fakeExtractor) StreamAudio(ctx context.Context, inputPath string, w io.Writer, opts extractor.ExtractOptions) error {
+	_, err := w.Write(f.audioData)
+	return err
+}
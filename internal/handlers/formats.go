@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"extratorDeAudio/internal/extractor"
+)
+
+// outputFormat is one entry of the output format allow-list.
+type outputFormat struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// outputFormats lists every output format the extractor supports, in the
+// order the UI offers them. ENABLED_FORMATS narrows it per deployment.
+var outputFormats = []outputFormat{
+	{ID: "mp3", Label: "MP3"},
+	{ID: "wav", Label: "WAV"},
+	{ID: "aac", Label: "AAC"},
+	{ID: extractor.FormatM4A, Label: "M4A (AAC)"},
+	{ID: "flac", Label: "FLAC"},
+	{ID: "ogg", Label: "OGG"},
+	{ID: extractor.FormatOpus, Label: "Opus"},
+	{ID: extractor.FormatHLS, Label: "HLS (streaming)"},
+}
+
+// defaultOutputFormat is used when the request names no format.
+const defaultOutputFormat = "mp3"
+
+func isOutputFormat(v string) bool {
+	for _, f := range outputFormats {
+		if f.ID == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseEnabledFormats parses ENABLED_FORMATS, a comma-separated subset of
+// the supported output formats. Empty enables all of them.
+func ParseEnabledFormats(v string) ([]string, error) {
+	var enabled []string
+	for _, part := range strings.Split(v, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		if !isOutputFormat(part) {
+			return nil, fmt.Errorf("unknown output format %q", part)
+		}
+		enabled = append(enabled, part)
+	}
+	return enabled, nil
+}
+
+// formatEnabled reports whether this deployment offers format.
+func (a *App) formatEnabled(format string) bool {
+	if len(a.cfg.EnabledFormats) == 0 {
+		return true
+	}
+	for _, f := range a.cfg.EnabledFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultFormat is mp3, or the first enabled format when mp3 is disabled.
+func (a *App) defaultFormat() string {
+	if a.formatEnabled(defaultOutputFormat) {
+		return defaultOutputFormat
+	}
+	for _, f := range outputFormats {
+		if a.formatEnabled(f.ID) {
+			return f.ID
+		}
+	}
+	return defaultOutputFormat
+}
+
+// resolveFormat validates a requested output format. Empty and unknown
+// values fall back to the default format as they always have; a known
+// format disabled by ENABLED_FORMATS is rejected.
+func (a *App) resolveFormat(v string) (string, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if !isOutputFormat(v) {
+		return a.defaultFormat(), true
+	}
+	if !a.formatEnabled(v) {
+		return "", false
+	}
+	return v, true
+}
+
+// listFormats serves the enabled output formats so the UI can build its
+// dropdown from the same allow-list the server enforces.
+func (a *App) listFormats(w http.ResponseWriter, r *http.Request) {
+	formats := make([]outputFormat, 0, len(outputFormats))
+	for _, f := range outputFormats {
+		if a.formatEnabled(f.ID) {
+			formats = append(formats, f)
+		}
+	}
+	a.respondJSON(w, http.StatusOK, map[string]any{
+		"formats": formats,
+		"default": a.defaultFormat(),
+	})
+}
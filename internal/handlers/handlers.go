@@ -2,21 +2,31 @@ package handlers
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"os"
+	pathpkg "path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"extratorDeAudio/internal/extractor"
 	"extratorDeAudio/internal/models"
+	"extratorDeAudio/internal/storage"
+	"extratorDeAudio/internal/transcript"
 	"extratorDeAudio/templates"
 
 	"github.com/a-h/templ"
@@ -27,44 +37,344 @@ import (
 
 const (
 	defaultMaxUploadBytes = 500 * 1024 * 1024
+
+	// minUploadBytesPerSecond is the slowest upload rate the default upload
+	// deadline tolerates; the deadline grows with MaxUploadBytes.
+	minUploadBytesPerSecond = 64 * 1024
+	minUploadTimeout        = 60 * time.Second
+
+	// Sendfile modes for Config.SendfileMode.
+	sendfileXAccel    = "x-accel"
+	sendfileXSendfile = "x-sendfile"
+
+	// maxPromptLength bounds the whisper initial prompt, in runes.
+	maxPromptLength = 500
+
+	// minChunkSeconds and defaultMaxChunkSeconds bound chunk_seconds; long
+	// chunks make whisper hold more audio in memory at once.
+	minChunkSeconds        = 30
+	defaultMaxChunkSeconds = 1800
+
+	// emergencyCleanupAge is how old a finished job must be to be removed
+	// when the disk fills up, regardless of the regular cleanup TTL.
+	emergencyCleanupAge = time.Hour
 )
 
+// Config holds the runtime settings used to build an App.
+type Config struct {
+	UploadsDir string
+	OutputsDir string
+	// TranscriptsDir stores transcripts apart from the audio outputs, e.g.
+	// for a longer retention or a separate backup. Empty uses OutputsDir.
+	TranscriptsDir string
+	// MaxConcurrentJobs caps the extractions running at once; the rest wait
+	// in line. MaxConcurrentTranscriptions does the same for whisper. Zero
+	// or negative means no limit.
+	MaxConcurrentJobs           int
+	MaxConcurrentTranscriptions int
+
+	// PrefetchProbe runs ffprobe as soon as an upload is saved, so its media
+	// details are ready before the extraction is requested.
+	PrefetchProbe bool
+
+	// OverwritePolicy decides what extraction does with an output that is
+	// already on disk; see extractor.OverwriteAlways and friends.
+	OverwritePolicy string
+
+	// MaxTranscriptBytes caps each transcript file; larger ones are
+	// truncated and the job flagged. Zero uses the default, negative
+	// disables the cap.
+	MaxTranscriptBytes int64
+	MaxUploadBytes     int64
+	// UploadTimeout bounds reading an upload body and overrides the server's
+	// ReadTimeout for /upload only. Zero derives it from MaxUploadBytes.
+	UploadTimeout time.Duration
+
+	WhisperBin      string
+	WhisperModel    string
+	WhisperLanguage string
+	// WhisperModels maps friendly model names to model paths that clients
+	// may pick per transcription.
+	WhisperModels map[string]string
+
+	// Fingerprint enables chromaprint fingerprinting of extracted audio.
+	Fingerprint bool
+
+	// ProgressAggregation is "average" (default) or "weighted" and controls
+	// how multi-output jobs report a single progress value.
+	ProgressAggregation string
+
+	// EnabledFormats restricts the output formats offered and accepted
+	// (ENABLED_FORMATS); empty enables all of them.
+	EnabledFormats []string
+
+	// Maintenance starts the server in read-only mode: uploads, extractions
+	// and transcriptions answer 503 until an admin turns it off.
+	Maintenance bool
+
+	// AllowedOrigins lists the browser origins allowed by CORS
+	// (ALLOWED_ORIGINS); "*" allows any and empty allows none.
+	AllowedOrigins []string
+
+	// UploadsPerMinute caps /upload requests per client IP (token bucket,
+	// bursts up to the same number). Zero uses the default of 30; negative
+	// disables the limit.
+	UploadsPerMinute int
+
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For and
+	// X-Real-IP headers name the client (TRUSTED_PROXIES). Requests from
+	// anywhere else are keyed on the socket address.
+	TrustedProxies []netip.Prefix
+
+	// APIKeys, when set, are required as a Bearer token on uploads,
+	// processing and download routes (API_KEYS).
+	APIKeys []string
+
+	// AdminToken authorizes privileged request options (Bearer token).
+	// Empty disables them.
+	AdminToken string
+
+	// RobustInput converts inputs ffmpeg can't handle directly into an
+	// intermediate format before extracting.
+	RobustInput bool
+
+	// TranscribeChunkSeconds splits long audio into chunks of this length
+	// for transcription, checkpointing finished chunks so retries resume.
+	// Zero transcribes in a single pass. Requests may pick their own length
+	// with chunk_seconds, clamped to TranscribeMaxChunkSeconds.
+	TranscribeChunkSeconds    float64
+	TranscribeMaxChunkSeconds float64
+
+	// LanguageDetect makes auto-language transcriptions first detect the
+	// language on a LanguageDetectSeconds sample and then transcribe with
+	// that language fixed. Requests override it with detect_language.
+	LanguageDetect        bool
+	LanguageDetectSeconds float64
+
+	// TranscribeMono feeds whisper a temporary 16kHz mono WAV when the
+	// extracted audio has more than one channel; the downloadable output is
+	// left untouched.
+	TranscribeMono bool
+
+	// EmptyTranscriptRetry is the language ("auto" or a code) for one more
+	// try when whisper succeeds with a blank transcript; the job then fails
+	// if it is still blank. Empty delivers blank transcripts as before.
+	EmptyTranscriptRetry string
+
+	// HeavyJobWindow holds transcriptions, and extractions of at least
+	// HeavyExtractMinSeconds of audio (0 never holds them), until the daily
+	// off-peak window opens. The zero window runs everything immediately.
+	HeavyJobWindow         TimeWindow
+	HeavyExtractMinSeconds float64
+
+	// WSMaxPerJob and WSMaxConnections cap /ws/{id} subscribers per job
+	// and in total. Zero uses the defaults; negative disables a cap.
+	WSMaxPerJob      int
+	WSMaxConnections int
+
+	// MinFreeDiskBytes and UploadSpaceFactor gate uploads on free space:
+	// the uploads/outputs filesystems need max(MinFreeDiskBytes, upload size
+	// × UploadSpaceFactor) available. Zero values use the defaults; a
+	// negative MinFreeDiskBytes disables the check.
+	MinFreeDiskBytes  int64
+	UploadSpaceFactor float64
+
+	// OutputNameTemplate names downloaded audio files, e.g.
+	// "{basename}_{quality}_{date}.{ext}". Requests may pass their own with
+	// name_template. Empty keeps "<job id>.<ext>".
+	OutputNameTemplate string
+
+	// FFmpegThreads caps the threads each ffmpeg extraction may use
+	// (-threads); 0 lets ffmpeg use every core. Requests may override it
+	// with threads.
+	FFmpegThreads int
+
+	// VerifyOutput probes every extracted file before delivering it: its
+	// duration must match the source (or clip) and it must not be silent.
+	// Costs an extra ffmpeg pass per output.
+	VerifyOutput bool
+
+	// ExpiryWarning is how long before the cleanup TTL subscribers of a job
+	// get an expiration warning. Zero uses one hour; negative disables it.
+	ExpiryWarning time.Duration
+
+	// VTTCueSettings are the default cue settings (line, position, size,
+	// align) of VTT files from /transcript/{id}/convert.
+	VTTCueSettings transcript.CueSettings
+
+	// WebhookWorkers, WebhookQueueSize and WebhookMaxAttempts size the
+	// callback delivery pool. Zero values use the defaults.
+	WebhookWorkers     int
+	WebhookQueueSize   int
+	WebhookMaxAttempts int
+	// WebhookAllowedHosts, when set, are the only callback hosts accepted,
+	// and may be internal. Otherwise callbacks must resolve to public IPs.
+	WebhookAllowedHosts []string
+
+	// SendfileMode delegates downloads to the front proxy: "x-accel" (nginx)
+	// or "x-sendfile" (Apache/lighttpd). Empty streams files from Go.
+	SendfileMode string
+	// SendfilePrefix is the internal proxy location mapped to OutputsDir,
+	// used by the x-accel mode.
+	SendfilePrefix string
+
+	// IDFormat picks the job ID scheme: "hex" (default), "friendly"
+	// (gato-azul-k3q7m2xw9pa4e) or "base32". IDPrefix is prepended to the latter two.
+	IDFormat string
+	IDPrefix string
+
+	// MaxQueueDepth caps the work waiting to start: queued or scheduled
+	// extractions and transcriptions plus conversions and samples waiting
+	// for a slot. Running stages and uploads don't count. Beyond it new work
+	// gets 429. Zero uses the default and a negative value disables the
+	// limit.
+	MaxQueueDepth int
+
+	// PostHookCmd is run, without a shell, after each completed extraction
+	// and transcription. PostHookTimeout bounds each run.
+	PostHookCmd     []string
+	PostHookTimeout time.Duration
+}
+
+// Extractor is the media backend App depends on. *extractor.Service is the
+// production implementation; tests can inject a fake through WithExtractor.
+type Extractor interface {
+	ExtractAudio(ctx context.Context, inputPath, outputPath string, opts extractor.ExtractOptions, cb extractor.ProgressCallback) error
+	ExtractMulti(ctx context.Context, inputPath string, targets []extractor.ExtractTarget, cb extractor.ProgressCallback) error
+	TranscribeAudio(ctx context.Context, inputAudioPath, outputBasePath string, opts extractor.TranscribeOptions, cb extractor.ProgressCallback) error
+	EstimateSize(ctx context.Context, inputPath, format, quality string, start, end float64) (extractor.SizeEstimate, error)
+	TranscribeChunked(ctx context.Context, inputAudioPath, outputBasePath string, chunkSeconds float64, opts extractor.TranscribeOptions, cb extractor.ProgressCallback) error
+	Fingerprint(ctx context.Context, audioPath string) (string, error)
+	Duration(ctx context.Context, inputPath string) (float64, error)
+	EmbedChapters(ctx context.Context, audioPath, metadataPath string, noFaststart bool) error
+	ExtractClip(ctx context.Context, inputPath, outputPath string, start, length float64) error
+	ProbeAudioStreams(ctx context.Context, inputPath string) ([]extractor.AudioStream, error)
+	DetectLanguage(ctx context.Context, inputAudioPath string, seconds float64, opts extractor.TranscribeOptions) (string, error)
+	ProbeInfo(ctx context.Context, inputPath string) (extractor.MediaInfo, error)
+	VerifyOutput(ctx context.Context, outputPath string, expected float64, checkSilence bool) error
+	CheckTools(ctx context.Context) []extractor.ToolStatus
+	StreamAudio(ctx context.Context, inputPath string, w io.Writer, opts extractor.ExtractOptions) error
+}
+
+// Option customizes an App built by NewApp.
+type Option func(*App)
+
+// WithExtractor replaces the ffmpeg/whisper backend, e.g. with an in-memory fake.
+func WithExtractor(e Extractor) Option {
+	return func(a *App) {
+		a.extractor = e
+	}
+}
+
 type App struct {
 	logger *slog.Logger
 
 	router    *chi.Mux
-	extractor *extractor.Service
+	extractor Extractor
 
 	uploadsDir string
 	outputsDir string
+	// transcriptsDir holds transcripts; it is outputsDir unless
+	// TranscriptsDir is configured.
+	transcriptsDir string
 
 	maxUploadBytes int64
 
+	// cfg keeps the remaining settings that handlers consult at runtime.
+	cfg Config
+
+	storage  storage.Storage
+	newJobID func() string
+	webhooks *webhookDispatcher
+	hooks    *hookRunner
+
+	progressSeries *progressSeries
+	media          *mediaCache
+
+	extractPool    *workerPool
+	transcribePool *workerPool
+	// uploadLimiter throttles /upload per client IP; nil when disabled.
+	uploadLimiter *rateLimiter
+	// streamSlots caps concurrent /stream previews.
+	streamSlots chan struct{}
+
 	mu   sync.RWMutex
 	jobs map[string]*models.ExtractionJob
 	subs map[string]map[*websocket.Conn]struct{}
+	// wsConns counts the connections in subs, for the global cap.
+	wsConns int
+	// running holds the cancelable stage of each job being processed.
+	running map[string]*runningStage
+	// generations and waiting track the runs of each job stage; see
+	// beginRun.
+	generations map[string]uint64
+	waiting     map[string]heavyWait
+	// uploadBusy marks chunked uploads with a chunk or commit in flight.
+	uploadBusy map[string]bool
+	// pending holds the idle timers of uploads not receiving data; see
+	// trackPendingLocked.
+	pending map[string]*time.Timer
+	// localCopies holds the downloads made by localAudio while in use, so
+	// vacuum leaves them alone.
+	localCopies map[string]struct{}
+
+	// maintenance puts the server in read-only mode; see writable.
+	maintenance atomic.Bool
+	// tools is the ffmpeg/ffprobe/whisper probe taken at startup.
+	tools []extractor.ToolStatus
 
 	upgrader websocket.Upgrader
 }
 
-func NewApp(logger *slog.Logger, uploadsDir, outputsDir string, maxUploadBytes int64, whisperBin, whisperModel, whisperLanguage string) *App {
+func NewApp(logger *slog.Logger, cfg Config, opts ...Option) *App {
+	maxUploadBytes := cfg.MaxUploadBytes
 	if maxUploadBytes <= 0 {
 		maxUploadBytes = defaultMaxUploadBytes
 	}
 
+	transcriptsDir := cfg.TranscriptsDir
+	if transcriptsDir == "" {
+		transcriptsDir = cfg.OutputsDir
+	}
+
 	app := &App{
 		logger:         logger,
 		router:         chi.NewRouter(),
-		extractor:      extractor.NewService(logger, whisperBin, whisperModel, whisperLanguage),
-		uploadsDir:     uploadsDir,
-		outputsDir:     outputsDir,
+		extractor:      extractor.NewService(logger, cfg.WhisperBin, cfg.WhisperModel, cfg.WhisperLanguage),
+		uploadsDir:     cfg.UploadsDir,
+		outputsDir:     cfg.OutputsDir,
+		transcriptsDir: transcriptsDir,
 		maxUploadBytes: maxUploadBytes,
+		cfg:            cfg,
+		storage:        storage.NewLocal(cfg.OutputsDir),
+		newJobID:       newIDGenerator(cfg.IDFormat, cfg.IDPrefix),
+		progressSeries: newProgressSeries(),
+		media:          newMediaCache(),
 		jobs:           make(map[string]*models.ExtractionJob),
 		subs:           make(map[string]map[*websocket.Conn]struct{}),
-		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool { return true },
-		},
+		running:        make(map[string]*runningStage),
+		uploadBusy:     make(map[string]bool),
+		generations:    make(map[string]uint64),
+		waiting:        make(map[string]heavyWait),
+		pending:        make(map[string]*time.Timer),
+		localCopies:    make(map[string]struct{}),
+		streamSlots:    make(chan struct{}, maxConcurrentStreams),
+	}
+	app.upgrader.CheckOrigin = app.checkWSOrigin
+	app.upgrader.Subprotocols = []string{wsKeyProtocol}
+
+	app.maintenance.Store(cfg.Maintenance)
+	app.extractPool = newWorkerPool("extraction", cfg.MaxConcurrentJobs, app.reportQueuePosition)
+	app.transcribePool = newWorkerPool("transcription", cfg.MaxConcurrentTranscriptions, app.reportQueuePosition)
+	app.uploadLimiter = newUploadLimiter(cfg.UploadsPerMinute)
+
+	app.webhooks = newWebhookDispatcher(app, cfg.WebhookWorkers, cfg.WebhookQueueSize, cfg.WebhookMaxAttempts)
+	app.hooks = newHookRunner(cfg.PostHookCmd, cfg.PostHookTimeout)
+
+	for _, opt := range opts {
+		opt(app)
 	}
+	app.tools = app.checkTools()
 
 	app.registerRoutes()
 	return app
@@ -76,30 +386,75 @@ func (a *App) Router() http.Handler {
 
 func (a *App) registerRoutes() {
 	a.router.Use(middleware.RequestID)
-	a.router.Use(middleware.RealIP)
+	a.router.Use(a.realIP)
 	a.router.Use(middleware.Recoverer)
 	a.router.Use(middleware.Timeout(45 * time.Minute))
 	a.router.Use(a.corsMiddleware)
 
 	a.router.Get("/", a.index)
-	a.router.Post("/upload", a.upload)
+	a.router.With(a.limitUploads, a.requireAPIKey, a.writable).Post("/upload", a.upload)
+	a.router.With(a.limitUploads, a.requireAPIKey, a.writable).Post("/api/uploads", a.reserveUpload)
+	a.router.With(a.limitUploads, a.requireAPIKey, a.writable).Post("/upload/init", a.initChunkedUpload)
+	a.router.With(a.requireAPIKey).Head("/upload/{id}", a.chunkedUploadOffset)
+	a.router.With(a.requireAPIKey, a.writable).Patch("/upload/{id}", a.appendUploadChunk)
+	a.router.With(a.requireAPIKey, a.writable).Post("/upload/{id}/commit", a.commitChunkedUpload)
+	a.router.Get("/api/formats", a.listFormats)
+	a.router.With(a.requireAPIKey).Post("/api/validate-options", a.validateOptions)
 	a.router.Get("/job/{id}", a.jobPage)
-	a.router.Get("/api/job/{id}", a.jobStatus)
-	a.router.Get("/extract/{id}", a.startExtraction)
-	a.router.Get("/transcribe/{id}", a.startTranscription)
-	a.router.Get("/download/{id}", a.download)
-	a.router.Get("/transcript/{id}", a.downloadTranscript)
-	a.router.Get("/ws/{id}", a.jobWS)
+	a.router.With(a.requireAPIKey).Delete("/job/{id}", a.deleteJob)
+	a.router.With(a.requireAPIKey, a.writable).Post("/job/{id}/restart-transcription", a.restartTranscription)
+	a.router.With(a.requireAPIKey, a.writable).Post("/job/{id}/retranscribe-range", a.retranscribeRange)
+	a.router.With(a.requireAPIKey).Get("/api/job/{id}", a.jobStatus)
+	a.router.With(a.requireAPIKey).Get("/job/{id}/progress-series", a.jobProgressSeries)
+	a.router.With(a.requireAPIKey).Get("/api/jobs", a.listJobs)
+	a.router.With(a.requireAPIKey).Get("/api/jobs.csv", a.jobsCSV)
+	a.router.With(a.requireAPIKey).Get("/api/jobs/{id}/transcript", a.transcriptSegments)
+	a.router.With(a.requireAPIKey).Get("/estimate/{id}", a.estimate)
+	a.router.With(a.requireAPIKey).Get("/api/jobs/{id}/tracks", a.listTracks)
+	a.router.With(a.requireAPIKey).Get("/probe/{id}", a.probe)
+	a.router.With(a.requireAPIKey, a.writable).Post("/api/jobs/{id}/samples", a.createSamples)
+	a.router.With(a.requireAPIKey, a.writable).Post("/api/jobs/{id}/transcode", a.transcodeOutput)
+	a.router.With(a.requireAPIKey).Get("/samples/{id}/{quality}", a.serveSample)
+	a.router.With(a.requireAPIKey, a.writable).Get("/extract/{id}", a.startExtraction)
+	a.router.With(a.requireAPIKey, a.writable).Post("/reextract/{id}", a.reextract)
+	a.router.With(a.requireAPIKey, a.writable).Get("/transcribe/{id}", a.startTranscription)
+	a.router.With(a.requireAPIKey).Post("/cancel/{id}", a.cancelJob)
+	a.router.With(a.requireAPIKey).Get("/download/{id}", a.download)
+	a.router.With(a.requireAPIKey).Get("/download-all/{id}", a.downloadAll)
+	a.router.With(a.requireAPIKey, a.writable).Get("/stream/{id}", a.streamAudio)
+	a.router.With(a.requireAPIKey).Get("/hls/{id}/{file}", a.serveHLS)
+	a.router.With(a.requireAPIKey).Get("/transcript/{id}", a.downloadTranscript)
+	a.router.With(a.requireAPIKey).Get("/transcript/{id}/convert", a.convertTranscript)
+	a.router.With(a.requireAPIKey).Get("/ws/{id}", a.jobWS)
 	a.router.Get("/healthz", a.health)
+	a.router.Post("/admin/jobs/{id}/restore", a.restoreJob)
+	a.router.Post("/admin/vacuum", a.vacuum)
+	a.router.Post("/admin/maintenance", a.setMaintenance)
 
 	staticFS := http.FileServer(http.Dir("static"))
 	a.router.Handle("/static/*", http.StripPrefix("/static/", staticFS))
 }
 
 func (a *App) health(w http.ResponseWriter, r *http.Request) {
+	code := http.StatusOK
+	admin := a.isAdminRequest(r)
+	body := map[string]any{"status": "ok", "timestamp": time.Now().Format(time.RFC3339), "tools": toolsView(a.tools, admin)}
+	if !toolsReady(a.tools) {
+		code = http.StatusServiceUnavailable
+		body["status"] = "unavailable"
+	}
+	if a.maintenance.Load() {
+		body["mode"] = "maintenance"
+	}
+	for k, v := range a.capacity() {
+		body[k] = v
+	}
+	if disk := a.diskStatus(admin); len(disk) > 0 {
+		body["disk"] = disk
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok", "timestamp": time.Now().Format(time.RFC3339)})
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
 }
 
 func (a *App) index(w http.ResponseWriter, r *http.Request) {
@@ -116,97 +471,153 @@ func (a *App) jobPage(w http.ResponseWriter, r *http.Request) {
 	a.render(w, r, templates.UploadPage(job, a.recentJobs(10)))
 }
 
-func (a *App) jobStatus(w http.ResponseWriter, r *http.Request) {
+// estimate reports the expected output size for the job's input. format and
+// quality default to the job's own settings.
+func (a *App) estimate(w http.ResponseWriter, r *http.Request) {
 	jobID := chi.URLParam(r, "id")
 	job, ok := a.getJob(jobID)
 	if !ok {
 		http.Error(w, "job não encontrado", http.StatusNotFound)
 		return
 	}
-
-	a.respondJSON(w, http.StatusOK, map[string]any{
-		"id":                   job.ID,
-		"status":               job.Status,
-		"progress":             job.Progress,
-		"error":                job.Error,
-		"download_url":         downloadURLForJob(job),
-		"transcript_status":    job.TranscriptStatus,
-		"transcript_progress":  job.TranscriptProgress,
-		"transcript_error":     job.TranscriptError,
-		"transcript_txt_url":   transcriptTXTURLForJob(job),
-		"transcript_srt_url":   transcriptSRTURLForJob(job),
-		"updated_at":           job.UpdatedAt.Format(time.RFC3339),
-	})
-}
-
-func (a *App) upload(w http.ResponseWriter, r *http.Request) {
-	r.Body = http.MaxBytesReader(w, r.Body, a.maxUploadBytes+1024)
-	if err := r.ParseMultipartForm(a.maxUploadBytes); err != nil {
-		a.logger.Warn("invalid multipart upload", "error", err)
-		http.Error(w, "upload inválido ou maior que 500MB", http.StatusBadRequest)
+	if job.ArchivedAt != nil || job.InputPath == "" {
+		http.Error(w, "arquivo de entrada indisponível", http.StatusGone)
 		return
 	}
 
-	file, header, err := r.FormFile("video")
-	if err != nil {
-		http.Error(w, "arquivo de vídeo é obrigatório", http.StatusBadRequest)
-		return
+	format := job.Format
+	if v := r.URL.Query().Get("format"); v != "" {
+		var ok bool
+		if format, ok = a.resolveFormat(v); !ok {
+			http.Error(w, "formato não está habilitado neste servidor", http.StatusBadRequest)
+			return
+		}
+	}
+	quality := job.Quality
+	if v := r.URL.Query().Get("quality"); v != "" {
+		quality = sanitizeQuality(v)
 	}
-	defer file.Close()
 
-	if header.Size > a.maxUploadBytes {
-		http.Error(w, "arquivo excede o limite de 500MB", http.StatusBadRequest)
+	est, err := a.extractor.EstimateSize(r.Context(), job.InputPath, format, quality, job.TrimStart, job.TrimEnd)
+	if err != nil {
+		a.logger.Warn("size estimate failed", "job_id", jobID, "error", err)
+		http.Error(w, "não foi possível estimar o tamanho", http.StatusUnprocessableEntity)
 		return
 	}
 
-	format := sanitizeFormat(r.FormValue("format"))
-	quality := sanitizeQuality(r.FormValue("quality"))
+	a.respondJSON(w, http.StatusOK, map[string]any{
+		"id":       job.ID,
+		"format":   format,
+		"quality":  quality,
+		"estimate": est,
+	})
+}
 
-	if err := os.MkdirAll(a.uploadsDir, 0o755); err != nil {
-		a.logger.Error("failed to ensure uploads dir", "error", err)
-		http.Error(w, "erro interno ao preparar upload", http.StatusInternalServerError)
+func (a *App) jobStatus(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+	job, ok := a.getJob(jobID)
+	if !ok {
+		http.Error(w, "job não encontrado", http.StatusNotFound)
 		return
 	}
 
-	jobID := newID()
-	safeName := sanitizeFileName(header.Filename)
-	inputPath := filepath.Join(a.uploadsDir, jobID+"_"+safeName)
+	view := jobView(job)
+	if info, ok := a.media.get(job.ID); ok {
+		view["media"] = info
+	}
+	// Polling clients must never see a cached snapshot.
+	w.Header().Set("Cache-Control", "no-store")
+	a.respondJSON(w, http.StatusOK, view)
+}
 
-	out, err := os.Create(inputPath)
+// listJobs returns the jobs as JSON, most recently updated first, with the
+// filters of parseJobFilter.
+func (a *App) listJobs(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseJobFilter(r)
 	if err != nil {
-		a.logger.Error("failed to create upload file", "error", err)
-		http.Error(w, "erro ao salvar upload", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer out.Close()
-
-	if _, err := out.ReadFrom(file); err != nil {
-		a.logger.Error("failed to persist upload", "error", err)
-		http.Error(w, "erro ao gravar arquivo", http.StatusInternalServerError)
-		return
+	jobs := a.filteredJobs(filter)
+	views := make([]map[string]any, len(jobs))
+	for i, job := range jobs {
+		views[i] = jobView(job)
 	}
+	w.Header().Set("Cache-Control", "no-store")
+	a.respondJSON(w, http.StatusOK, map[string]any{"count": len(views), "jobs": views})
+}
 
-	now := time.Now()
-	job := &models.ExtractionJob{
-		ID:                 jobID,
-		InputFileName:      safeName,
-		InputPath:          inputPath,
-		Format:             format,
-		Quality:            quality,
-		Status:             models.StatusUploaded,
-		Progress:           0,
-		TranscriptStatus:   models.StatusNotStarted,
-		TranscriptProgress: 0,
-		CreatedAt:          now,
-		UpdatedAt:          now,
+// jobView is the JSON representation of a job shared by the status and
+// listing endpoints.
+func jobView(job *models.ExtractionJob) map[string]any {
+	return map[string]any{
+		"id":                   job.ID,
+		"parent_id":            job.ParentID,
+		"input_file_name":      job.InputFileName,
+		"format":               job.Format,
+		"quality":              job.Quality,
+		"status":               job.Status,
+		"progress":             job.Progress,
+		"error":                job.Error,
+		"error_code":           job.ErrorCode,
+		"fingerprint":          job.Fingerprint,
+		"archived_at":          job.ArchivedAt,
+		"download_url":         downloadURLForJob(job),
+		"hls_url":              hlsURLForJob(job),
+		"transcript_status":    job.TranscriptStatus,
+		"transcript_progress":  job.TranscriptProgress,
+		"transcript_error":     job.TranscriptError,
+		"transcript_txt_url":   transcriptTXTURLForJob(job),
+		"transcript_srt_url":   transcriptSRTURLForJob(job),
+		"transcript_vtt_url":   transcriptVTTURLForJob(job),
+		"transcript_words_url": transcriptWordsURLForJob(job),
+		"transcript_truncated": job.TranscriptTruncated,
+		"conversions":          conversionViews(job),
+		"created_at":           job.CreatedAt.Format(time.RFC3339),
+		"updated_at":           job.UpdatedAt.Format(time.RFC3339),
+		"chapters":             job.Chapters,
+		"scheduled_at":         job.ScheduledAt,
+		"queue_position":       job.QueuePosition,
+		"detected_language":    job.DetectedLanguage,
+		"transcript_language":  job.TranscriptLanguage,
+		"stages":               jobStages(job),
 	}
+}
 
-	a.mu.Lock()
-	a.jobs[jobID] = job
-	a.mu.Unlock()
+// stageView is the per-stage state exposed by the JSON API, mirroring the
+// stage model of ProgressEvent.
+type stageView struct {
+	Status             models.JobStatus      `json:"status"`
+	Progress           int                   `json:"progress"`
+	Error              string                `json:"error,omitempty"`
+	DownloadURL        string                `json:"download_url,omitempty"`
+	TranscriptTXTURL   string                `json:"transcript_txt_url,omitempty"`
+	TranscriptSRTURL   string                `json:"transcript_srt_url,omitempty"`
+	TranscriptVTTURL   string                `json:"transcript_vtt_url,omitempty"`
+	TranscriptWordsURL string                `json:"transcript_words_url,omitempty"`
+	Usage              *models.ResourceUsage `json:"usage,omitempty"`
+}
 
-	a.logger.Info("upload saved", "job_id", jobID, "file", safeName, "format", format, "quality", quality)
-	http.Redirect(w, r, "/job/"+jobID, http.StatusSeeOther)
+func jobStages(job *models.ExtractionJob) map[string]stageView {
+	return map[string]stageView{
+		"extraction": {
+			Status:      job.Status,
+			Progress:    job.Progress,
+			Error:       job.Error,
+			DownloadURL: downloadURLForJob(job),
+			Usage:       job.ExtractionUsage,
+		},
+		"transcription": {
+			Status:             job.TranscriptStatus,
+			Progress:           job.TranscriptProgress,
+			Error:              job.TranscriptError,
+			TranscriptTXTURL:   transcriptTXTURLForJob(job),
+			TranscriptSRTURL:   transcriptSRTURLForJob(job),
+			TranscriptVTTURL:   transcriptVTTURLForJob(job),
+			TranscriptWordsURL: transcriptWordsURLForJob(job),
+			Usage:              job.TranscriptionUsage,
+		},
+	}
 }
 
 func (a *App) startExtraction(w http.ResponseWriter, r *http.Request) {
@@ -220,19 +631,31 @@ func (a *App) startExtraction(w http.ResponseWriter, r *http.Request) {
 	}
 
 	switch job.Status {
-	case models.StatusProcessing, models.StatusQueued:
+	case models.StatusProcessing, models.StatusQueued, models.StatusScheduled:
 		a.mu.Unlock()
 		a.respondJSON(w, http.StatusAccepted, map[string]string{"status": "already_processing"})
 		return
+	case models.StatusUploading:
+		a.mu.Unlock()
+		http.Error(w, "upload ainda não foi concluído", http.StatusConflict)
+		return
 	case models.StatusCompleted:
 		a.mu.Unlock()
 		a.respondJSON(w, http.StatusOK, map[string]string{"status": "already_completed", "download_url": "/download/" + job.ID})
 		return
 	}
+	if a.queueFullLocked() {
+		a.mu.Unlock()
+		a.rejectQueueFull(w)
+		return
+	}
 
 	job.Status = models.StatusQueued
 	job.Progress = 1
 	job.Error = ""
+	job.ErrorCode = ""
+	job.Fingerprint = ""
+	job.Outputs = nil
 	job.TranscriptStatus = models.StatusNotStarted
 	job.TranscriptProgress = 0
 	job.TranscriptError = ""
@@ -240,55 +663,145 @@ func (a *App) startExtraction(w http.ResponseWriter, r *http.Request) {
 	job.TranscriptTXTName = ""
 	job.TranscriptSRTPath = ""
 	job.TranscriptSRTName = ""
+	job.TranscriptVTTPath = ""
+	job.TranscriptVTTName = ""
+	// Word timings, chapters and conversions came from the previous output.
+	stale := []string{job.TranscriptWordsPath}
+	for _, c := range job.Conversions {
+		stale = append(stale, c.Path)
+	}
+	job.TranscriptWordsPath = ""
+	job.Chapters = nil
+	job.Conversions = nil
 	job.UpdatedAt = time.Now()
 	a.mu.Unlock()
 
+	for _, path := range stale {
+		if path != "" {
+			_ = os.Remove(path)
+		}
+	}
 	a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "extraction", Status: models.StatusQueued, Progress: 1, Message: "job em fila"})
 	go a.runExtraction(jobID)
 	a.respondJSON(w, http.StatusAccepted, map[string]string{"status": "started", "job_id": jobID})
 }
 
 func (a *App) runExtraction(jobID string) {
+	gen := a.beginRun(jobID, "extraction")
 	job, ok := a.getJob(jobID)
 	if !ok {
 		return
 	}
+	if a.heavyExtraction(job) {
+		if !a.waitForHeavyWindow(jobID, "extraction", gen) {
+			return
+		}
+		if job, ok = a.getJob(jobID); !ok {
+			return
+		}
+	}
+
+	if !a.extractPool.acquire(jobID) {
+		return
+	}
+	defer a.extractPool.release()
+	a.leaveQueue(jobID)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
 	defer cancel()
+	if !a.startRun(jobID, "extraction", gen, cancel) {
+		return
+	}
+	defer a.endRun(jobID)
+	usage := extractor.NewUsageRecorder(jobID, "extraction")
+	ctx = extractor.WithUsage(ctx, usage)
+	defer a.storeUsage(jobID, "extraction", usage)
+
+	// The file on disk stays job-scoped; only the download name follows the
+	// naming template.
+	fileName := extractor.OutputName(job.ID, job.Format)
+	outputName := a.outputFileName(ctx, job, "")
+	outputDir := a.jobOutputDir(job)
+	if job.Format == extractor.FormatHLS {
+		fileName = extractor.HLSPlaylist
+		outputName = extractor.HLSPlaylist
+		outputDir = hlsDir(a.jobOutputDir(job), job.ID)
+	}
+	outputPath := filepath.Join(outputDir, fileName)
 
-	outputName := extractor.OutputName(job.ID, job.Format)
-	outputPath := filepath.Join(a.outputsDir, outputName)
-
-	if err := os.MkdirAll(a.outputsDir, 0o755); err != nil {
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		if errors.Is(err, syscall.ENOSPC) {
+			err = extractor.ErrDiskFull
+		}
 		a.failJob(jobID, fmt.Errorf("failed to create outputs dir: %w", err))
 		return
 	}
 
-	a.updateJob(jobID, func(j *models.ExtractionJob) {
-		j.Status = models.StatusProcessing
-		j.OutputName = outputName
-		j.OutputPath = outputPath
-		j.UpdatedAt = time.Now()
-	})
+	opts := a.extractOptions(job)
 
-	err := a.extractor.ExtractAudio(ctx, job.InputPath, outputPath, job.Format, job.Quality, func(percent int, status, message string) {
-		if percent < 1 {
-			percent = 1
+	if job.SplitChannels || job.AllTracks {
+		a.updateJob(jobID, func(j *models.ExtractionJob) {
+			j.Status = models.StatusProcessing
+			j.UpdatedAt = time.Now()
+		})
+		extractMulti := a.extractChannels
+		if job.AllTracks {
+			extractMulti = a.extractTracks
+		}
+		outputs, err := extractMulti(ctx, job, outputDir, opts)
+		if err == nil {
+			err = a.verifyOutputs(ctx, job, outputs, !job.SplitChannels)
+		}
+		if err != nil {
+			for _, out := range outputs {
+				_ = os.Remove(out.Path)
+			}
+			a.failJob(jobID, err)
+			return
 		}
+		a.updateJob(jobID, func(j *models.ExtractionJob) {
+			j.Outputs = outputs
+		})
+	} else {
 		a.updateJob(jobID, func(j *models.ExtractionJob) {
 			j.Status = models.StatusProcessing
-			j.Progress = percent
+			j.OutputName = outputName
+			j.OutputPath = outputPath
 			j.UpdatedAt = time.Now()
 		})
-		a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "extraction", Status: models.StatusProcessing, Progress: percent, Message: message})
-	})
 
-	if err != nil {
+		progress := newProgressAggregator([]string{outputName}, nil, a.cfg.ProgressAggregation)
+		err := a.extractor.ExtractAudio(ctx, job.InputPath, outputPath, opts, a.outputProgressCallback(jobID, progress, 0))
+		if err != nil {
+			a.failJob(jobID, err)
+			return
+		}
+		if err := a.verifyOutputs(ctx, job, []models.JobOutput{{Name: outputName, Path: outputPath}}, true); err != nil {
+			a.failJob(jobID, err)
+			return
+		}
+
+		if a.cfg.Fingerprint && job.Format != extractor.FormatHLS {
+			a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "extraction", Status: models.StatusProcessing, Progress: 99, Message: "calculando fingerprint"})
+			fingerprint, fpErr := a.extractor.Fingerprint(ctx, outputPath)
+			if fpErr != nil {
+				a.logger.Warn("fingerprint failed", "job_id", jobID, "error", fpErr)
+			}
+			a.updateJob(jobID, func(j *models.ExtractionJob) {
+				j.Fingerprint = fingerprint
+			})
+		}
+	}
+
+	if err := a.persistOutputs(ctx, jobID); err != nil {
 		a.failJob(jobID, err)
 		return
 	}
 
+	if a.stageCanceled(jobID, "extraction") {
+		a.settleCanceled(jobID, "extraction")
+		return
+	}
 	a.updateJob(jobID, func(j *models.ExtractionJob) {
 		j.Status = models.StatusCompleted
 		j.Progress = 100
@@ -304,11 +817,187 @@ func (a *App) runExtraction(jobID string) {
 		DownloadURL: "/download/" + jobID,
 	})
 
+	a.notifyWebhook(jobID, "extraction.completed")
+	a.runPostHook(jobID, "extraction.completed")
 	a.logger.Info("extraction completed", "job_id", jobID, "output", outputPath)
 }
 
+// extractOptions maps the job's settings to the extractor's options.
+func (a *App) extractOptions(job *models.ExtractionJob) extractor.ExtractOptions {
+	opts := extractor.ExtractOptions{
+		Format:         job.Format,
+		Quality:        job.Quality,
+		Start:          job.TrimStart,
+		End:            job.TrimEnd,
+		SeekMode:       job.SeekMode,
+		CopyTimestamps: job.CopyTimestamps,
+		InputFormat:    job.InputFormat,
+		RobustInput:    a.cfg.RobustInput,
+		GainDB:         job.GainDB,
+		Normalize:      job.Normalize,
+
+		PreserveMetadata: job.PreserveMetadata,
+		SurroundDownmix:  job.SurroundDownmix,
+		Channels:         job.Channels,
+		SampleRate:       job.SampleRate,
+		NoFaststart:      job.NoFaststart,
+		Threads:          a.ffmpegThreads(job),
+		Overwrite:        a.cfg.OverwritePolicy,
+		TargetDuration:   job.TargetDuration,
+	}
+	if job.TrackIndex != nil {
+		opts.SingleTrack = true
+		opts.Track = *job.TrackIndex
+	}
+	if info, ok := a.media.get(job.ID); ok {
+		opts.SourceDuration = info.Duration
+	}
+	return opts
+}
+
+// extractChannels writes one mono file per channel of the first audio stream,
+// all from a single ffmpeg run.
+func (a *App) extractChannels(ctx context.Context, job *models.ExtractionJob, outputDir string, opts extractor.ExtractOptions) ([]models.JobOutput, error) {
+	streams, err := a.extractor.ProbeAudioStreams(ctx, job.InputPath)
+	if err != nil {
+		return nil, fmt.Errorf("não foi possível ler os canais de áudio: %w", err)
+	}
+	if len(streams) == 0 {
+		return nil, errors.New("o vídeo não possui faixa de áudio")
+	}
+	track := 0
+	if opts.SingleTrack {
+		track = opts.Track
+	}
+	if track >= len(streams) {
+		return nil, fmt.Errorf("a faixa de áudio %d não existe no arquivo", track)
+	}
+	channels := streams[track].Channels
+	if channels < 2 {
+		return nil, fmt.Errorf("o áudio possui apenas %d canal, não há o que separar", channels)
+	}
+
+	outputs := make([]models.JobOutput, channels)
+	for i := range outputs {
+		label := fmt.Sprintf("ch%d", i)
+		outputs[i] = models.JobOutput{Label: label, Name: a.outputFileName(ctx, job, label), Path: filepath.Join(outputDir, extractor.OutputName(job.ID+"_"+label, job.Format))}
+	}
+
+	return a.extractOutputs(ctx, job, outputs, func(i int) extractor.ExtractOptions {
+		channelOpts := opts
+		channelOpts.SingleChannel = true
+		channelOpts.Channel = i
+		return channelOpts
+	})
+}
+
+// extractTracks writes one file per audio stream of the input, named after
+// the stream's language tag, e.g. for the dubbed tracks of a film. All
+// tracks come out of a single ffmpeg run.
+func (a *App) extractTracks(ctx context.Context, job *models.ExtractionJob, outputDir string, opts extractor.ExtractOptions) ([]models.JobOutput, error) {
+	streams, err := a.extractor.ProbeAudioStreams(ctx, job.InputPath)
+	if err != nil {
+		return nil, fmt.Errorf("não foi possível ler as faixas de áudio: %w", err)
+	}
+	if len(streams) == 0 {
+		return nil, errors.New("o vídeo não possui faixa de áudio")
+	}
+
+	outputs := make([]models.JobOutput, len(streams))
+	for i, st := range streams {
+		label := fmt.Sprintf("track%d", st.Index)
+		nameLabel := label
+		if lang := sanitizeLanguageTag(st.Language); lang != "" {
+			nameLabel += "_" + lang
+		}
+		outputs[i] = models.JobOutput{Label: label, Name: a.outputFileName(ctx, job, nameLabel), Path: filepath.Join(outputDir, extractor.OutputName(job.ID+"_"+label, job.Format))}
+	}
+
+	return a.extractOutputs(ctx, job, outputs, func(i int) extractor.ExtractOptions {
+		trackOpts := opts
+		trackOpts.SingleTrack = true
+		trackOpts.Track = streams[i].Index
+		return trackOpts
+	})
+}
+
+// extractOutputs encodes all outputs of a multi-output job, each with its
+// own options, in one ffmpeg process that decodes the input once. The job
+// holds a single extraction slot however many outputs it has.
+func (a *App) extractOutputs(ctx context.Context, job *models.ExtractionJob, outputs []models.JobOutput, optsFor func(i int) extractor.ExtractOptions) ([]models.JobOutput, error) {
+	names := make([]string, len(outputs))
+	targets := make([]extractor.ExtractTarget, len(outputs))
+	for i, out := range outputs {
+		names[i] = out.Name
+		targets[i] = extractor.ExtractTarget{Path: out.Path, Options: optsFor(i)}
+	}
+
+	// The outputs advance together: each tick records all of them and the
+	// last one's callback broadcasts the result.
+	progress := newProgressAggregator(names, nil, a.cfg.ProgressAggregation)
+	last := len(outputs) - 1
+	report := a.outputProgressCallback(job.ID, progress, last)
+	cb := func(percent int, status, message string) {
+		for i := 0; i < last; i++ {
+			progress.Update(i, percent, models.StatusProcessing)
+		}
+		report(percent, status, message)
+	}
+	return outputs, a.extractor.ExtractMulti(ctx, job.InputPath, targets, cb)
+}
+
+// outputProgressCallback reports progress of output i through the job's
+// aggregator, so multi-output jobs still drive a single progress value.
+func (a *App) outputProgressCallback(jobID string, progress *progressAggregator, i int) extractor.ProgressCallback {
+	return func(percent int, status, message string) {
+		overall, outputs := progress.Update(i, percent, models.StatusProcessing)
+		if overall < 1 {
+			overall = 1
+		}
+		if progress.Len() < 2 {
+			outputs = nil
+		}
+		a.updateJob(jobID, func(j *models.ExtractionJob) {
+			j.Status = models.StatusProcessing
+			j.Progress = overall
+			j.UpdatedAt = time.Now()
+		})
+		a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "extraction", Status: models.StatusProcessing, Progress: overall, Message: message, Outputs: outputs})
+	}
+}
+
 func (a *App) startTranscription(w http.ResponseWriter, r *http.Request) {
 	jobID := chi.URLParam(r, "id")
+	prompt := sanitizePrompt(r.FormValue("prompt"))
+	normalizeText, ok := sanitizeNormalizeText(r.FormValue("normalize"))
+	if !ok {
+		http.Error(w, "normalização de texto inválida", http.StatusBadRequest)
+		return
+	}
+	words := parseWordsOption(r)
+	header := parseBool(r.FormValue("header"))
+	chapters := parseBool(r.FormValue("chapters"))
+	translate := parseBool(r.FormValue("translate"))
+	detectLanguage := a.parseDetectLanguage(r.FormValue("detect_language"))
+	chunkSeconds, ok := a.parseChunkSeconds(r.FormValue("chunk_seconds"))
+	if !ok {
+		http.Error(w, "tamanho de parte inválido", http.StatusBadRequest)
+		return
+	}
+	model := strings.TrimSpace(r.FormValue("model"))
+	if model != "" {
+		if _, ok := a.cfg.WhisperModels[model]; !ok {
+			http.Error(w, "modelo whisper desconhecido", http.StatusBadRequest)
+			return
+		}
+	}
+	// An empty model or language keeps the job's current one (the server
+	// default unless a previous transcription chose another).
+	language, ok := sanitizeLanguage(r.FormValue("language"))
+	if !ok {
+		http.Error(w, "idioma não suportado", http.StatusBadRequest)
+		return
+	}
 
 	a.mu.Lock()
 	job, ok := a.jobs[jobID]
@@ -322,9 +1011,14 @@ func (a *App) startTranscription(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "extração ainda não foi concluída", http.StatusConflict)
 		return
 	}
+	if len(job.Outputs) > 0 {
+		a.mu.Unlock()
+		http.Error(w, errMultiOutputTranscription, http.StatusConflict)
+		return
+	}
 
 	switch job.TranscriptStatus {
-	case models.StatusQueued, models.StatusProcessing:
+	case models.StatusQueued, models.StatusScheduled, models.StatusProcessing:
 		a.mu.Unlock()
 		a.respondJSON(w, http.StatusAccepted, map[string]string{"status": "transcription_already_processing"})
 		return
@@ -334,10 +1028,33 @@ func (a *App) startTranscription(w http.ResponseWriter, r *http.Request) {
 			"status":             "transcription_already_completed",
 			"transcript_txt_url": "/transcript/" + jobID + "?format=txt",
 			"transcript_srt_url": "/transcript/" + jobID + "?format=srt",
+			"transcript_vtt_url": "/transcript/" + jobID + "?format=vtt",
 		})
 		return
 	}
+	if a.queueFullLocked() {
+		a.mu.Unlock()
+		a.rejectQueueFull(w)
+		return
+	}
 
+	job.Prompt = prompt
+	job.NormalizeText = normalizeText
+	job.WordTimestamps = words
+	job.TranscriptHeader = header
+	job.EmbedChapters = chapters
+	job.ChunkSeconds = chunkSeconds
+	if model != "" {
+		job.Model = model
+	}
+	job.Translate = translate
+	if language != "" {
+		job.Language = language
+	}
+	job.DetectLanguage = detectLanguage
+	job.DetectedLanguage = ""
+	job.TranscriptTruncated = false
+	job.TranscriptLanguage = ""
 	job.TranscriptStatus = models.StatusQueued
 	job.TranscriptProgress = 1
 	job.TranscriptError = ""
@@ -350,7 +1067,121 @@ func (a *App) startTranscription(w http.ResponseWriter, r *http.Request) {
 	a.respondJSON(w, http.StatusAccepted, map[string]string{"status": "transcription_started", "job_id": jobID})
 }
 
+// errMultiOutputTranscription rejects transcribing split-channel and
+// all-tracks jobs, which have no single audio file to feed whisper.
+const errMultiOutputTranscription = "transcrição não é suportada para jobs com vários arquivos de saída"
+
+// restartTranscription discards the current transcript of a job and runs
+// whisper again with the model/language/translate options from the request.
+func (a *App) restartTranscription(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+
+	model := strings.TrimSpace(r.FormValue("model"))
+	if model != "" {
+		if _, ok := a.cfg.WhisperModels[model]; !ok {
+			http.Error(w, "modelo whisper desconhecido", http.StatusBadRequest)
+			return
+		}
+	}
+	language, ok := sanitizeLanguage(r.FormValue("language"))
+	if !ok {
+		http.Error(w, "idioma não suportado", http.StatusBadRequest)
+		return
+	}
+	translate := parseBool(r.FormValue("translate"))
+	prompt := sanitizePrompt(r.FormValue("prompt"))
+	normalizeText, ok := sanitizeNormalizeText(r.FormValue("normalize"))
+	if !ok {
+		http.Error(w, "normalização de texto inválida", http.StatusBadRequest)
+		return
+	}
+	words := parseWordsOption(r)
+	header := parseBool(r.FormValue("header"))
+	chapters := parseBool(r.FormValue("chapters"))
+	detectLanguage := a.parseDetectLanguage(r.FormValue("detect_language"))
+	chunkSeconds, ok := a.parseChunkSeconds(r.FormValue("chunk_seconds"))
+	if !ok {
+		http.Error(w, "tamanho de parte inválido", http.StatusBadRequest)
+		return
+	}
+	// With remote storage the audio is only in the bucket; runTranscription
+	// fetches it through localAudio. The lookup may hit the network, so it
+	// runs before taking the lock.
+	if snapshot, ok := a.getJob(jobID); ok && snapshot.OutputPath != "" && !a.outputExists(r.Context(), snapshot.OutputPath) {
+		http.Error(w, "arquivo de áudio não encontrado", http.StatusConflict)
+		return
+	}
+
+	a.mu.Lock()
+	job, ok := a.jobs[jobID]
+	if !ok {
+		a.mu.Unlock()
+		http.Error(w, "job não encontrado", http.StatusNotFound)
+		return
+	}
+	if len(job.Outputs) > 0 {
+		a.mu.Unlock()
+		http.Error(w, errMultiOutputTranscription, http.StatusConflict)
+		return
+	}
+	if job.Status != models.StatusCompleted || job.OutputPath == "" {
+		a.mu.Unlock()
+		http.Error(w, "extração ainda não foi concluída", http.StatusConflict)
+		return
+	}
+	if job.TranscriptStatus == models.StatusQueued || job.TranscriptStatus == models.StatusScheduled || job.TranscriptStatus == models.StatusProcessing {
+		a.mu.Unlock()
+		a.respondJSON(w, http.StatusAccepted, map[string]string{"status": "transcription_already_processing"})
+		return
+	}
+	if a.queueFullLocked() {
+		a.mu.Unlock()
+		a.rejectQueueFull(w)
+		return
+	}
+
+	previous := *job
+	job.Model = model
+	job.Language = language
+	job.Translate = translate
+	job.Prompt = prompt
+	job.NormalizeText = normalizeText
+	job.WordTimestamps = words
+	job.TranscriptHeader = header
+	job.EmbedChapters = chapters
+	job.ChunkSeconds = chunkSeconds
+	job.DetectLanguage = detectLanguage
+	job.DetectedLanguage = ""
+	job.TranscriptTruncated = false
+	job.TranscriptLanguage = ""
+	job.TranscriptStatus = models.StatusQueued
+	job.TranscriptProgress = 1
+	job.TranscriptError = ""
+	job.TranscriptTXTPath = ""
+	job.TranscriptTXTName = ""
+	job.TranscriptSRTPath = ""
+	job.TranscriptSRTName = ""
+	job.TranscriptVTTPath = ""
+	job.TranscriptVTTName = ""
+	job.TranscriptWordsPath = ""
+	job.TranscriptWordsName = ""
+	job.Chapters = nil
+	job.UpdatedAt = time.Now()
+	a.mu.Unlock()
+
+	removeTranscriptFiles(previous)
+
+	a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "transcription", Status: models.StatusQueued, Progress: 1, Message: "transcrição reiniciada"})
+	go a.runTranscription(jobID)
+
+	a.respondJSON(w, http.StatusAccepted, map[string]string{"status": "transcription_restarted", "job_id": jobID})
+}
+
 func (a *App) runTranscription(jobID string) {
+	gen := a.beginRun(jobID, "transcription")
+	if !a.waitForHeavyWindow(jobID, "transcription", gen) {
+		return
+	}
 	job, ok := a.getJob(jobID)
 	if !ok {
 		return
@@ -360,25 +1191,60 @@ func (a *App) runTranscription(jobID string) {
 		return
 	}
 
+	if !a.transcribePool.acquire(jobID) {
+		return
+	}
+	defer a.transcribePool.release()
+	a.leaveQueue(jobID)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Minute)
 	defer cancel()
-
-	base := filepath.Join(a.outputsDir, job.ID+"_transcript")
+	if !a.startRun(jobID, "transcription", gen, cancel) {
+		return
+	}
+	defer a.endRun(jobID)
+	usage := extractor.NewUsageRecorder(jobID, "transcription")
+	ctx = extractor.WithUsage(ctx, usage)
+	defer a.storeUsage(jobID, "transcription", usage)
+
+	// Paths stay job-scoped to avoid collisions; only the download names
+	// follow the original upload name.
+	dir := a.jobTranscriptDir(job)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		a.failTranscription(jobID, fmt.Errorf("falha ao criar diretório de transcrições: %w", err))
+		return
+	}
+	base := filepath.Join(dir, job.ID+"_transcript")
 	txtPath := base + ".txt"
 	srtPath := base + ".srt"
+	vttPath := base + ".vtt"
+	friendlyName := friendlyBaseName(job.InputFileName, "transcript")
 
 	a.updateJob(jobID, func(j *models.ExtractionJob) {
 		j.TranscriptStatus = models.StatusProcessing
 		j.TranscriptProgress = 1
 		j.TranscriptError = ""
 		j.TranscriptTXTPath = txtPath
-		j.TranscriptTXTName = filepath.Base(txtPath)
+		j.TranscriptTXTName = friendlyName + ".txt"
 		j.TranscriptSRTPath = srtPath
-		j.TranscriptSRTName = filepath.Base(srtPath)
+		j.TranscriptSRTName = friendlyName + ".srt"
+		j.TranscriptVTTPath = vttPath
+		j.TranscriptVTTName = friendlyName + ".vtt"
+		j.TranscriptWordsPath = ""
+		j.TranscriptWordsName = ""
 		j.UpdatedAt = time.Now()
 	})
 
-	err := a.extractor.TranscribeAudio(ctx, job.OutputPath, base, func(percent int, status, message string) {
+	opts := extractor.TranscribeOptions{
+		Model:     a.cfg.WhisperModels[job.Model],
+		Language:  job.Language,
+		Translate: job.Translate,
+		Prompt:    job.Prompt,
+
+		WordTimestamps: job.WordTimestamps,
+	}
+
+	progress := func(percent int, status, message string) {
 		if percent < 1 {
 			percent = 1
 		}
@@ -388,9 +1254,35 @@ func (a *App) runTranscription(jobID string) {
 			j.UpdatedAt = time.Now()
 		})
 		a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "transcription", Status: models.StatusProcessing, Progress: percent, Message: message})
-	})
+	}
 
+	localPath, cleanupAudio, err := a.localAudio(ctx, job)
 	if err != nil {
+		a.failTranscription(jobID, fmt.Errorf("falha ao obter áudio para transcrição: %w", err))
+		return
+	}
+	defer cleanupAudio()
+	// The helpers below read the audio through OutputPath, so they get a
+	// view of the job pointing at the local copy.
+	local := *job
+	local.OutputPath = localPath
+
+	opts.Language = a.resolveLanguage(ctx, &local, opts, progress)
+
+	audioPath, cleanupMono := a.whisperAudio(ctx, &local, base)
+	defer cleanupMono()
+
+	transcribe := func(opts extractor.TranscribeOptions) error {
+		if job.ChunkSeconds > 0 {
+			return a.extractor.TranscribeChunked(ctx, audioPath, base, job.ChunkSeconds, opts, progress)
+		}
+		return a.extractor.TranscribeAudio(ctx, audioPath, base, opts, progress)
+	}
+	if err := transcribe(opts); err != nil {
+		a.failTranscription(jobID, err)
+		return
+	}
+	if err := a.retryEmptyTranscript(jobID, txtPath, opts.Language, opts, transcribe, progress); err != nil {
 		a.failTranscription(jobID, err)
 		return
 	}
@@ -403,37 +1295,150 @@ func (a *App) runTranscription(jobID string) {
 		a.failTranscription(jobID, fmt.Errorf("transcrição SRT não foi gerada"))
 		return
 	}
+	if _, err := os.Stat(vttPath); err != nil {
+		a.failTranscription(jobID, fmt.Errorf("transcrição VTT não foi gerada"))
+		return
+	}
+
+	truncated, err := a.limitTranscriptSize(jobID, base)
+	if err != nil {
+		a.failTranscription(jobID, fmt.Errorf("falha ao limitar tamanho da transcrição: %w", err))
+		return
+	}
+
+	if job.NormalizeText != "" {
+		if err := transcript.NormalizeFile(txtPath, job.NormalizeText); err != nil {
+			a.failTranscription(jobID, fmt.Errorf("falha ao normalizar transcrição: %w", err))
+			return
+		}
+	}
+
+	if job.TranscriptHeader {
+		if err := transcript.PrependHeader(txtPath, a.transcriptHeader(ctx, &local)); err != nil {
+			a.failTranscription(jobID, fmt.Errorf("falha ao gravar cabeçalho da transcrição: %w", err))
+			return
+		}
+	}
+
+	if job.EmbedChapters {
+		a.addChapters(ctx, job, localPath, srtPath, base)
+	}
+
+	// Word timestamps degrade gracefully: when whisper could not produce
+	// them the job completes with TXT/SRT only.
+	wordsPath, wordsURL := "", ""
+	if job.WordTimestamps {
+		if _, err := os.Stat(extractor.WordsPath(base)); err == nil {
+			wordsPath = extractor.WordsPath(base)
+			wordsURL = "/transcript/" + jobID + "?format=words"
+		}
+	}
 
+	if a.stageCanceled(jobID, "transcription") {
+		a.settleCanceled(jobID, "transcription")
+		return
+	}
 	a.updateJob(jobID, func(j *models.ExtractionJob) {
 		j.TranscriptStatus = models.StatusCompleted
 		j.TranscriptProgress = 100
 		j.TranscriptError = ""
+		j.TranscriptTruncated = truncated
+		if wordsPath != "" {
+			j.TranscriptWordsPath = wordsPath
+			j.TranscriptWordsName = friendlyName + ".words.json"
+		}
 		j.UpdatedAt = time.Now()
 	})
 
+	message := "transcrição concluída"
+	if truncated {
+		message = "transcrição concluída, mas truncada por exceder o tamanho máximo"
+	}
 	a.broadcast(jobID, models.ProgressEvent{
-		ID:               jobID,
-		Stage:            "transcription",
-		Status:           models.StatusCompleted,
-		Progress:         100,
-		Message:          "transcrição concluída",
-		TranscriptTXTURL: "/transcript/" + jobID + "?format=txt",
-		TranscriptSRTURL: "/transcript/" + jobID + "?format=srt",
+		ID:                 jobID,
+		Stage:              "transcription",
+		Status:             models.StatusCompleted,
+		Progress:           100,
+		Message:            message,
+		TranscriptTXTURL:   "/transcript/" + jobID + "?format=txt",
+		TranscriptSRTURL:   "/transcript/" + jobID + "?format=srt",
+		TranscriptVTTURL:   "/transcript/" + jobID + "?format=vtt",
+		TranscriptWordsURL: wordsURL,
 	})
+	a.notifyWebhook(jobID, "transcription.completed")
+	a.runPostHook(jobID, "transcription.completed")
 	a.logger.Info("transcription completed", "job_id", jobID)
 }
 
+// transcriptHeader describes the job for the optional TXT header. The model
+// and language fall back to the server defaults the run actually used.
+func (a *App) transcriptHeader(ctx context.Context, job *models.ExtractionJob) transcript.Header {
+	header := transcript.Header{
+		FileName:    job.InputFileName,
+		Language:    job.Language,
+		Model:       job.Model,
+		GeneratedAt: time.Now(),
+	}
+	if header.Language == "" {
+		header.Language = a.cfg.WhisperLanguage
+	}
+	if header.Model == "" {
+		header.Model = strings.TrimSuffix(filepath.Base(a.cfg.WhisperModel), filepath.Ext(a.cfg.WhisperModel))
+	}
+	if seconds, err := a.extractor.Duration(ctx, job.OutputPath); err == nil {
+		header.Duration = time.Duration(seconds * float64(time.Second))
+	}
+	return header
+}
+
 func (a *App) failJob(jobID string, err error) {
+	if a.stageCanceled(jobID, "extraction") {
+		a.settleCanceled(jobID, "extraction")
+		return
+	}
 	a.logger.Error("extraction failed", "job_id", jobID, "error", err)
+
+	errorCode := ""
+	message := "falha na extração"
+	if errors.Is(err, extractor.ErrDiskFull) {
+		errorCode = models.ErrorCodeDiskFull
+		message = "disco cheio"
+		err = extractor.ErrDiskFull
+	} else if errors.Is(err, extractor.ErrOutputInvalid) {
+		errorCode = models.ErrorCodeOutputInvalid
+		message = "áudio gerado inválido"
+	} else if errors.Is(err, extractor.ErrOutputExists) {
+		errorCode = models.ErrorCodeOutputExists
+		message = "arquivo de saída já existe"
+	}
+
 	a.updateJob(jobID, func(j *models.ExtractionJob) {
 		j.Status = models.StatusFailed
 		j.Error = err.Error()
+		j.ErrorCode = errorCode
 		j.UpdatedAt = time.Now()
 	})
-	a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "extraction", Status: models.StatusFailed, Progress: 0, Error: err.Error(), Message: "falha na extração"})
+	a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "extraction", Status: models.StatusFailed, Progress: 0, Error: err.Error(), Message: message})
+	a.notifyWebhook(jobID, "extraction.failed")
+
+	// Whatever this run wrote is partial. Only output_exists means it
+	// wrote nothing: the file is a finished earlier result.
+	if errorCode != models.ErrorCodeOutputExists {
+		if job, ok := a.getJob(jobID); ok && job.OutputPath != "" {
+			_ = os.Remove(job.OutputPath)
+			_ = os.Remove(extractor.SettingsSidecar(job.OutputPath))
+		}
+	}
+	if errorCode == models.ErrorCodeDiskFull {
+		go a.emergencyCleanup(jobID)
+	}
 }
 
 func (a *App) failTranscription(jobID string, err error) {
+	if a.stageCanceled(jobID, "transcription") {
+		a.settleCanceled(jobID, "transcription")
+		return
+	}
 	a.logger.Error("transcription failed", "job_id", jobID, "error", err)
 	a.updateJob(jobID, func(j *models.ExtractionJob) {
 		j.TranscriptStatus = models.StatusFailed
@@ -442,6 +1447,7 @@ func (a *App) failTranscription(jobID string, err error) {
 		j.UpdatedAt = time.Now()
 	})
 	a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "transcription", Status: models.StatusFailed, Progress: 0, Error: err.Error(), Message: "falha na transcrição"})
+	a.notifyWebhook(jobID, "transcription.failed")
 }
 
 func (a *App) download(w http.ResponseWriter, r *http.Request) {
@@ -451,16 +1457,67 @@ func (a *App) download(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
-	if job.Status != models.StatusCompleted || job.OutputPath == "" {
+	if job.ArchivedAt != nil {
+		http.Error(w, "arquivado", http.StatusGone)
+		return
+	}
+	if job.Status != models.StatusCompleted {
+		http.Error(w, "arquivo ainda não está pronto", http.StatusConflict)
+		return
+	}
+	if format := r.URL.Query().Get("conversion"); format != "" {
+		a.downloadConversion(w, r, job, strings.ToLower(format))
+		return
+	}
+	if len(job.Outputs) > 0 {
+		a.downloadOutputs(w, r, job)
+		return
+	}
+	if job.OutputPath == "" {
 		http.Error(w, "arquivo ainda não está pronto", http.StatusConflict)
 		return
 	}
-	if _, err := os.Stat(job.OutputPath); err != nil {
+	if job.Format == extractor.FormatHLS {
+		a.downloadHLS(w, r, job)
+		return
+	}
+	setContentType(w, job.Format, job.OutputName)
+	a.serveOutput(w, r, job.OutputPath, job.OutputName)
+}
+
+// downloadOutputs serves a multi-output job: a single file when `channel`
+// or `track` is given, otherwise every output bundled in a ZIP.
+func (a *App) downloadOutputs(w http.ResponseWriter, r *http.Request, job *models.ExtractionJob) {
+	label := ""
+	if channel := strings.TrimSpace(r.URL.Query().Get("channel")); channel != "" {
+		label = "ch" + channel
+	} else if track := strings.TrimSpace(r.URL.Query().Get("track")); track != "" {
+		label = "track" + track
+	}
+	if label != "" {
+		for _, out := range job.Outputs {
+			if out.Label != label {
+				continue
+			}
+			setContentType(w, job.Format, out.Name)
+			a.serveOutput(w, r, out.Path, out.Name)
+			return
+		}
+		http.Error(w, "arquivo não encontrado", http.StatusNotFound)
+		return
+	}
+
+	var entries []zipEntry
+	for _, out := range job.Outputs {
+		if a.outputExists(r.Context(), out.Path) {
+			entries = append(entries, zipEntry{Name: out.Name, Path: out.Path})
+		}
+	}
+	if len(entries) == 0 {
 		http.Error(w, "arquivo não encontrado", http.StatusNotFound)
 		return
 	}
-	w.Header().Set("Content-Disposition", "attachment; filename=\""+job.OutputName+"\"")
-	http.ServeFile(w, r, job.OutputPath)
+	a.serveZip(w, r, friendlyBaseName(job.InputFileName, "audio")+".zip", entries)
 }
 
 func (a *App) downloadTranscript(w http.ResponseWriter, r *http.Request) {
@@ -471,6 +1528,10 @@ func (a *App) downloadTranscript(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if job.ArchivedAt != nil {
+		http.Error(w, "arquivado", http.StatusGone)
+		return
+	}
 	if job.TranscriptStatus != models.StatusCompleted {
 		http.Error(w, "transcrição ainda não está pronta", http.StatusConflict)
 		return
@@ -479,9 +1540,16 @@ func (a *App) downloadTranscript(w http.ResponseWriter, r *http.Request) {
 	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
 	path := job.TranscriptTXTPath
 	name := job.TranscriptTXTName
-	if format == "srt" {
+	switch format {
+	case "srt":
 		path = job.TranscriptSRTPath
 		name = job.TranscriptSRTName
+	case "vtt":
+		path = job.TranscriptVTTPath
+		name = job.TranscriptVTTName
+	case "words", "json":
+		path = job.TranscriptWordsPath
+		name = job.TranscriptWordsName
 	}
 	if path == "" {
 		http.Error(w, "arquivo de transcrição não encontrado", http.StatusNotFound)
@@ -492,10 +1560,86 @@ func (a *App) downloadTranscript(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setContentType(w, "", name)
+	a.serveFile(w, r, path, name)
+}
+
+// serveFile sends a file as an attachment, either directly or by handing it
+// off to the front proxy when a sendfile mode is configured.
+func (a *App) serveFile(w http.ResponseWriter, r *http.Request, path, name string) {
 	w.Header().Set("Content-Disposition", "attachment; filename=\""+name+"\"")
+
+	switch a.cfg.SendfileMode {
+	case sendfileXAccel:
+		rel, err := filepath.Rel(a.outputsDir, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			break
+		}
+		location := (&url.URL{Path: pathpkg.Join("/", a.cfg.SendfilePrefix, filepath.ToSlash(rel))}).EscapedPath()
+		w.Header().Set("X-Accel-Redirect", location)
+		return
+	case sendfileXSendfile:
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			break
+		}
+		w.Header().Set("X-Sendfile", abs)
+		return
+	}
+
+	// A strong validator lets http.ServeFile honor If-Range: a client
+	// resuming after the file was regenerated gets the full 200 instead of
+	// a 206 that would splice bytes from two different files.
+	if info, err := os.Stat(path); err == nil {
+		w.Header().Set("ETag", fileETag(info))
+	}
 	http.ServeFile(w, r, path)
 }
 
+// fileETag derives a strong ETag from the file's size and modification time,
+// which change whenever an output is rewritten.
+func fileETag(info os.FileInfo) string {
+	return fmt.Sprintf("\"%x-%x\"", info.ModTime().UnixNano(), info.Size())
+}
+
+// transcriptSegments returns the timed transcript segments as JSON, parsed
+// from the SRT whisper output.
+func (a *App) transcriptSegments(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+	job, ok := a.getJob(jobID)
+	if !ok {
+		http.Error(w, "job não encontrado", http.StatusNotFound)
+		return
+	}
+	if job.TranscriptStatus != models.StatusCompleted {
+		http.Error(w, "transcrição ainda não está pronta", http.StatusConflict)
+		return
+	}
+
+	f, err := os.Open(job.TranscriptSRTPath)
+	if err != nil {
+		http.Error(w, "arquivo de transcrição não encontrado", http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+
+	segments, err := transcript.ParseSRT(f)
+	if err != nil {
+		a.logger.Error("failed to parse transcript", "job_id", jobID, "error", err)
+		http.Error(w, "erro ao ler transcrição", http.StatusInternalServerError)
+		return
+	}
+	if segments == nil {
+		segments = []transcript.Segment{}
+	}
+
+	a.respondJSON(w, http.StatusOK, map[string]any{
+		"id":       job.ID,
+		"language": job.Language,
+		"segments": segments,
+	})
+}
+
 func (a *App) jobWS(w http.ResponseWriter, r *http.Request) {
 	jobID := chi.URLParam(r, "id")
 	job, ok := a.getJob(jobID)
@@ -510,12 +1654,11 @@ func (a *App) jobWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a.mu.Lock()
-	if a.subs[jobID] == nil {
-		a.subs[jobID] = make(map[*websocket.Conn]struct{})
+	if !a.subscribe(jobID, conn) {
+		a.logger.Warn("websocket subscriber limit reached", "job_id", jobID)
+		rejectSubscriber(conn)
+		return
 	}
-	a.subs[jobID][conn] = struct{}{}
-	a.mu.Unlock()
 
 	_ = conn.WriteJSON(currentProgressEvent(job))
 
@@ -525,9 +1668,7 @@ func (a *App) jobWS(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	a.mu.Lock()
-	delete(a.subs[jobID], conn)
-	a.mu.Unlock()
+	a.unsubscribe(jobID, conn)
 	_ = conn.Close()
 }
 
@@ -540,14 +1681,19 @@ func currentProgressEvent(job *models.ExtractionJob) models.ProgressEvent {
 		Error:       job.Error,
 		DownloadURL: downloadURLForJob(job),
 	}
+	if job.Status == models.StatusUploading {
+		event.Stage = "upload"
+	}
 
-	if job.TranscriptStatus == models.StatusQueued || job.TranscriptStatus == models.StatusProcessing || job.TranscriptStatus == models.StatusCompleted || job.TranscriptStatus == models.StatusFailed {
+	if job.TranscriptStatus == models.StatusQueued || job.TranscriptStatus == models.StatusScheduled || job.TranscriptStatus == models.StatusProcessing || job.TranscriptStatus == models.StatusCompleted || job.TranscriptStatus == models.StatusFailed || job.TranscriptStatus == models.StatusCanceled {
 		event.Stage = "transcription"
 		event.Status = job.TranscriptStatus
 		event.Progress = job.TranscriptProgress
 		event.Error = job.TranscriptError
 		event.TranscriptTXTURL = transcriptTXTURLForJob(job)
 		event.TranscriptSRTURL = transcriptSRTURLForJob(job)
+		event.TranscriptVTTURL = transcriptVTTURLForJob(job)
+		event.TranscriptWordsURL = transcriptWordsURLForJob(job)
 	}
 
 	return event
@@ -574,7 +1720,44 @@ func transcriptSRTURLForJob(job *models.ExtractionJob) string {
 	return ""
 }
 
+// parseWordsOption reads the per-word timing option: words=true, or
+// json=true for clients that ask for "the JSON transcript".
+func parseWordsOption(r *http.Request) bool {
+	return parseBool(r.FormValue("words")) || parseBool(r.FormValue("json"))
+}
+
+// transcriptVTTURLForJob is empty for transcripts made before VTT output
+// existed.
+func transcriptVTTURLForJob(job *models.ExtractionJob) string {
+	if job.TranscriptStatus == models.StatusCompleted && job.TranscriptVTTPath != "" {
+		return "/transcript/" + job.ID + "?format=vtt"
+	}
+	return ""
+}
+
+func hlsURLForJob(job *models.ExtractionJob) string {
+	if job.Status == models.StatusCompleted && job.Format == extractor.FormatHLS {
+		return "/hls/" + job.ID + "/" + extractor.HLSPlaylist
+	}
+	return ""
+}
+
+func transcriptWordsURLForJob(job *models.ExtractionJob) string {
+	if job.TranscriptStatus == models.StatusCompleted && job.TranscriptWordsPath != "" {
+		return "/transcript/" + job.ID + "?format=words"
+	}
+	return ""
+}
+
 func (a *App) broadcast(jobID string, evt models.ProgressEvent) {
+	a.mu.RLock()
+	_, live := a.jobs[jobID]
+	a.mu.RUnlock()
+	// The series is forgotten with the job, so nothing else may add to it.
+	if live {
+		a.progressSeries.record(jobID, evt.Stage, evt.Progress)
+	}
+
 	a.mu.RLock()
 	conns := make([]*websocket.Conn, 0, len(a.subs[jobID]))
 	for c := range a.subs[jobID] {
@@ -584,9 +1767,7 @@ func (a *App) broadcast(jobID string, evt models.ProgressEvent) {
 
 	for _, c := range conns {
 		if err := c.WriteJSON(evt); err != nil {
-			a.mu.Lock()
-			delete(a.subs[jobID], c)
-			a.mu.Unlock()
+			a.unsubscribe(jobID, c)
 			_ = c.Close()
 		}
 	}
@@ -646,7 +1827,10 @@ func (a *App) recentJobs(limit int) []*models.ExtractionJob {
 	return jobs
 }
 
-func (a *App) StartCleanupLoop(ctx context.Context, interval, ttl time.Duration) {
+// StartCleanupLoop periodically expires jobs idle for longer than ttl. With a
+// positive purgeGrace, expired jobs are first archived (kept on disk, but
+// downloads answer 410) and only deleted once archived for purgeGrace.
+func (a *App) StartCleanupLoop(ctx context.Context, interval, ttl, purgeGrace time.Duration) {
 	if interval <= 0 || ttl <= 0 {
 		return
 	}
@@ -659,52 +1843,198 @@ func (a *App) StartCleanupLoop(ctx context.Context, interval, ttl time.Duration)
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				a.cleanup(ttl)
+				a.warnExpiring(ttl)
+				a.cleanup(ttl, purgeGrace)
 			}
 		}
 	}()
 }
 
-func (a *App) cleanup(ttl time.Duration) {
-	cutoff := time.Now().Add(-ttl)
+func (a *App) cleanup(ttl, purgeGrace time.Duration) {
+	now := time.Now()
+	cutoff := now.Add(-ttl)
+	purgeCutoff := now.Add(-purgeGrace)
+	var oldJobs []models.ExtractionJob
+	archived := 0
+
+	a.mu.Lock()
+	for id, job := range a.jobs {
+		switch {
+		case job.ArchivedAt != nil:
+			if job.ArchivedAt.Before(purgeCutoff) {
+				oldJobs = append(oldJobs, *job)
+				delete(a.jobs, id)
+				a.forgetRunsLocked(id)
+			}
+		case job.ScheduledAt != nil:
+			// Waiting for the heavy job window, possibly longer than the TTL.
+		case !isJobIdle(job) || job.Status == models.StatusUploading:
+			// Still running; an abandoned upload expires through the
+			// pending-upload queue instead.
+		case job.UpdatedAt.Before(cutoff):
+			if purgeGrace <= 0 {
+				oldJobs = append(oldJobs, *job)
+				delete(a.jobs, id)
+				a.forgetRunsLocked(id)
+				continue
+			}
+			archivedAt := now
+			job.ArchivedAt = &archivedAt
+			archived++
+		}
+	}
+	a.mu.Unlock()
+
+	for _, job := range oldJobs {
+		a.progressSeries.forget(job.ID)
+		a.media.forget(job.ID)
+		a.removeJobFiles(job)
+	}
+
+	if len(oldJobs) > 0 || archived > 0 {
+		a.logger.Info("cleanup completed", "removed_jobs", len(oldJobs), "archived_jobs", archived)
+	}
+}
+
+// restoreJob clears the archived flag of a job still inside its purge grace
+// window, making its files downloadable again.
+func (a *App) restoreJob(w http.ResponseWriter, r *http.Request) {
+	if !a.isAdminRequest(r) {
+		http.Error(w, "não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	jobID := chi.URLParam(r, "id")
+	a.mu.Lock()
+	job, ok := a.jobs[jobID]
+	if !ok {
+		a.mu.Unlock()
+		http.Error(w, "job não encontrado", http.StatusNotFound)
+		return
+	}
+	job.ArchivedAt = nil
+	job.UpdatedAt = time.Now()
+	a.mu.Unlock()
+
+	a.logger.Info("job restored from archive", "job_id", jobID)
+	a.respondJSON(w, http.StatusOK, map[string]string{"status": "restored", "job_id": jobID})
+}
+
+// emergencyCleanup frees disk space after an ENOSPC failure by removing every
+// finished job older than emergencyCleanupAge. keepID is never removed so the
+// failed job stays visible to its client.
+func (a *App) emergencyCleanup(keepID string) {
+	cutoff := time.Now().Add(-emergencyCleanupAge)
 	var oldJobs []models.ExtractionJob
 
 	a.mu.Lock()
 	for id, job := range a.jobs {
+		if id == keepID || !isJobIdle(job) {
+			continue
+		}
 		if job.UpdatedAt.Before(cutoff) {
 			oldJobs = append(oldJobs, *job)
 			delete(a.jobs, id)
+			a.forgetRunsLocked(id)
 		}
 	}
 	a.mu.Unlock()
 
 	for _, job := range oldJobs {
-		if job.InputPath != "" {
-			_ = os.Remove(job.InputPath)
-		}
-		if job.OutputPath != "" {
-			_ = os.Remove(job.OutputPath)
-		}
-		if job.TranscriptTXTPath != "" {
-			_ = os.Remove(job.TranscriptTXTPath)
-		}
-		if job.TranscriptSRTPath != "" {
-			_ = os.Remove(job.TranscriptSRTPath)
+		a.progressSeries.forget(job.ID)
+		a.media.forget(job.ID)
+		a.removeJobFiles(job)
+	}
+	a.logger.Warn("emergency cleanup completed", "removed_jobs", len(oldJobs))
+}
+
+// isJobIdle reports whether no extraction or transcription is running for the job.
+func isJobIdle(job *models.ExtractionJob) bool {
+	switch job.Status {
+	case models.StatusQueued, models.StatusScheduled, models.StatusProcessing:
+		return false
+	}
+	switch job.TranscriptStatus {
+	case models.StatusQueued, models.StatusScheduled, models.StatusProcessing:
+		return false
+	}
+	return true
+}
+
+func removeJobFiles(job models.ExtractionJob) {
+	if job.InputPath != "" {
+		_ = os.Remove(job.InputPath)
+	}
+	if job.OutputPath != "" {
+		_ = os.Remove(job.OutputPath)
+		_ = os.Remove(extractor.SettingsSidecar(job.OutputPath))
+	}
+	for _, out := range job.Outputs {
+		_ = os.Remove(out.Path)
+	}
+	for _, c := range job.Conversions {
+		_ = os.Remove(c.Path)
+	}
+	if job.Format == extractor.FormatHLS && job.OutputPath != "" {
+		_ = os.RemoveAll(filepath.Dir(job.OutputPath))
+	}
+	removeTranscriptFiles(job)
+}
+
+// removeEmptyDirs removes dir and then each parent below root for as long
+// as they are empty, so an output_dir subtree goes away with its last job.
+func removeEmptyDirs(root, dir string) {
+	root = filepath.Clean(root)
+	for dir = filepath.Clean(dir); strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
 		}
 	}
+}
 
-	if len(oldJobs) > 0 {
-		a.logger.Info("cleanup completed", "removed_jobs", len(oldJobs))
+func removeTranscriptFiles(job models.ExtractionJob) {
+	if job.TranscriptTXTPath != "" {
+		_ = os.Remove(job.TranscriptTXTPath)
+		extractor.RemoveChunkArtifacts(strings.TrimSuffix(job.TranscriptTXTPath, ".txt"))
+	}
+	if job.TranscriptSRTPath != "" {
+		_ = os.Remove(job.TranscriptSRTPath)
+	}
+	if job.TranscriptVTTPath != "" {
+		_ = os.Remove(job.TranscriptVTTPath)
+	}
+	if job.TranscriptWordsPath != "" {
+		_ = os.Remove(job.TranscriptWordsPath)
 	}
 }
 
-func sanitizeFormat(v string) string {
+// sanitizeChannels validates the output channel layout, falling back to
+// keeping the source layout.
+func sanitizeChannels(v string) string {
 	switch strings.ToLower(strings.TrimSpace(v)) {
-	case "mp3", "wav", "aac", "flac", "ogg":
-		return strings.ToLower(v)
+	case extractor.ChannelsMono, extractor.ChannelsStereo:
+		return strings.ToLower(strings.TrimSpace(v))
 	default:
-		return "mp3"
+		return extractor.ChannelsSource
+	}
+}
+
+// sampleRates lists the output rates accepted by sanitizeSampleRate.
+var sampleRates = map[int]struct{}{
+	8000: {}, 16000: {}, 22050: {}, 44100: {}, 48000: {},
+}
+
+// sanitizeSampleRate validates the output sample rate in Hz. "source" and
+// unrecognized values return 0, which keeps the source rate.
+func sanitizeSampleRate(v string) int {
+	rate, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return 0
 	}
+	if _, ok := sampleRates[rate]; !ok {
+		return 0
+	}
+	return rate
 }
 
 func sanitizeQuality(v string) string {
@@ -716,6 +2046,90 @@ func sanitizeQuality(v string) string {
 	}
 }
 
+// whisperLanguages lists the language codes accepted for transcription.
+var whisperLanguages = map[string]struct{}{
+	"auto": {}, "pt": {}, "en": {}, "es": {}, "fr": {}, "de": {}, "it": {}, "nl": {},
+	"ru": {}, "uk": {}, "pl": {}, "tr": {}, "ar": {}, "he": {}, "hi": {}, "ja": {},
+	"ko": {}, "zh": {}, "sv": {}, "no": {}, "da": {}, "fi": {}, "el": {}, "cs": {},
+	"ro": {}, "hu": {}, "ca": {}, "id": {}, "vi": {}, "th": {},
+}
+
+// sanitizeLanguage normalizes a language code. An empty value is valid and
+// means "use the server default".
+func sanitizeLanguage(v string) (string, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "" {
+		return "", true
+	}
+	if _, ok := whisperLanguages[v]; !ok {
+		return "", false
+	}
+	return v, true
+}
+
+// parseChunkSeconds resolves the chunk length for a transcription. Empty uses
+// the configured default, "0" disables chunking and other values are clamped
+// to [minChunkSeconds, TranscribeMaxChunkSeconds].
+func (a *App) parseChunkSeconds(v string) (float64, bool) {
+	v = strings.TrimSpace(v)
+	seconds := a.cfg.TranscribeChunkSeconds
+	if v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed < 0 || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+			return 0, false
+		}
+		seconds = parsed
+	}
+	if seconds <= 0 {
+		return 0, true
+	}
+
+	maxSeconds := a.cfg.TranscribeMaxChunkSeconds
+	if maxSeconds <= 0 {
+		maxSeconds = defaultMaxChunkSeconds
+	}
+	return math.Min(math.Max(seconds, minChunkSeconds), maxSeconds), true
+}
+
+// sanitizePrompt strips control characters from a whisper prompt, collapses
+// whitespace and truncates it to maxPromptLength runes.
+func sanitizePrompt(v string) string {
+	v = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || r == '\r' {
+			return ' '
+		}
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, v)
+	v = strings.Join(strings.Fields(v), " ")
+	if runes := []rune(v); len(runes) > maxPromptLength {
+		v = string(runes[:maxPromptLength])
+	}
+	return v
+}
+
+// sanitizeNormalizeText validates the transcript normalization mode. An empty
+// value disables normalization.
+func sanitizeNormalizeText(v string) (string, bool) {
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case "", transcript.NormalizeBasic, transcript.NormalizeLower, transcript.NormalizeSentence:
+		return v, true
+	default:
+		return "", false
+	}
+}
+
+func parseBool(v string) bool {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "1", "true", "on", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
 func sanitizeFileName(name string) string {
 	name = filepath.Base(strings.TrimSpace(name))
 	name = strings.ReplaceAll(name, " ", "_")
@@ -731,25 +2145,45 @@ func sanitizeFileName(name string) string {
 	return name
 }
 
-func newID() string {
-	b := make([]byte, 8)
-	if _, err := rand.Read(b); err != nil {
-		return fmt.Sprintf("job-%d", time.Now().UnixNano())
+// friendlyBaseName strips the extension from an already sanitized upload name
+// so it can be reused for derived downloads. fallback is used when nothing
+// meaningful is left.
+func friendlyBaseName(fileName, fallback string) string {
+	name := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	name = strings.Trim(name, "._-")
+	if name == "" {
+		return fallback
 	}
-	return hex.EncodeToString(b)
+	return name
 }
 
-func (a *App) corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+// jobOutputDir returns where a job's files are written: the outputs root or
+// the job's sanitized subdirectory under it.
+func (a *App) jobOutputDir(job *models.ExtractionJob) string {
+	if job.OutputDir == "" {
+		return a.outputsDir
+	}
+	return filepath.Join(a.outputsDir, filepath.FromSlash(job.OutputDir))
+}
 
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
+// jobTranscriptDir is jobOutputDir for transcripts: the same subdirectory,
+// under transcriptsDir.
+func (a *App) jobTranscriptDir(job *models.ExtractionJob) string {
+	if job.OutputDir == "" {
+		return a.transcriptsDir
+	}
+	return filepath.Join(a.transcriptsDir, filepath.FromSlash(job.OutputDir))
+}
 
-		next.ServeHTTP(w, r)
-	})
+// isAdminRequest reports whether the request carries the configured admin
+// bearer token.
+func (a *App) isAdminRequest(r *http.Request) bool {
+	if a.cfg.AdminToken == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(a.cfg.AdminToken)) == 1
 }
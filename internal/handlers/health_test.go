@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"extratorDeAudio/internal/extractor"
+)
+
+func healthBody(t *testing.T, app *App, token string) (string, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	app.Router().ServeHTTP(rec, req)
+	raw := rec.Body.String()
+	var body map[string]any
+	if err := json.Unmarshal([]byte(raw), &body); err != nil {
+		t.Fatalf("decode health: %v", err)
+	}
+	return raw, body
+}
+
+func TestHealthHidesDiskPathsFromPublic(t *testing.T) {
+	app := newTestApp(t, Config{AdminToken: "admin"})
+
+	raw, body := healthBody(t, app, "")
+	if strings.Contains(raw, app.uploadsDir) || strings.Contains(raw, app.outputsDir) {
+		t.Errorf("public health exposes storage paths: %s", raw)
+	}
+	for _, d := range body["disk"].([]any) {
+		entry := d.(map[string]any)
+		if _, ok := entry["name"]; !ok {
+			t.Errorf("disk entry %v has no name", entry)
+		}
+		if _, ok := entry["free_bytes"]; ok {
+			t.Errorf("public disk entry %v has byte counts", entry)
+		}
+	}
+
+	raw, _ = healthBody(t, app, "admin")
+	if !strings.Contains(raw, app.uploadsDir) {
+		t.Errorf("admin health misses the storage paths: %s", raw)
+	}
+}
+
+func TestHealthToolsOnlyReportAvailabilityToPublic(t *testing.T) {
+	app := newTestApp(t, Config{AdminToken: "admin"})
+	app.tools = []extractor.ToolStatus{{Name: "ffmpeg", Path: "/usr/bin/ffmpeg", Version: "ffmpeg version 6.1", Available: true, Required: true}}
+
+	raw, _ := healthBody(t, app, "")
+	if strings.Contains(raw, "/usr/bin/ffmpeg") || strings.Contains(raw, "6.1") {
+		t.Errorf("public health exposes tool paths or versions: %s", raw)
+	}
+	if !strings.Contains(raw, `"available":true`) {
+		t.Errorf("public health misses tool availability: %s", raw)
+	}
+
+	raw, _ = healthBody(t, app, "admin")
+	if !strings.Contains(raw, "/usr/bin/ffmpeg") {
+		t.Errorf("admin health misses the tool path: %s", raw)
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// hlsFileName matches the only files an HLS job directory may serve.
+var hlsFileName = regexp.MustCompile(`^(index\.m3u8|seg_[0-9]+\.ts)$`)
+
+// hlsDir is the per-job directory holding the playlist and its segments.
+func hlsDir(outputDir, jobID string) string {
+	return filepath.Join(outputDir, jobID+"_hls")
+}
+
+// serveHLS serves the playlist and segments of an HLS job for in-browser
+// playback. Only names produced by the HLS muxer are accepted, so the route
+// cannot be used to read other files.
+func (a *App) serveHLS(w http.ResponseWriter, r *http.Request) {
+	job, ok := a.getJob(chi.URLParam(r, "id"))
+	if !ok || job.Format != extractor.FormatHLS || job.OutputPath == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if job.ArchivedAt != nil {
+		http.Error(w, "arquivado", http.StatusGone)
+		return
+	}
+	if job.Status != models.StatusCompleted {
+		http.Error(w, "arquivo ainda não está pronto", http.StatusConflict)
+		return
+	}
+
+	name := chi.URLParam(r, "file")
+	if !hlsFileName.MatchString(name) {
+		http.NotFound(w, r)
+		return
+	}
+	path := filepath.Join(filepath.Dir(job.OutputPath), name)
+	if _, err := os.Stat(path); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if filepath.Ext(name) == ".m3u8" {
+		w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	} else {
+		w.Header().Set("Content-Type", "video/mp2t")
+	}
+	http.ServeFile(w, r, path)
+}
+
+// downloadHLS bundles the playlist and segments in a ZIP.
+func (a *App) downloadHLS(w http.ResponseWriter, r *http.Request, job *models.ExtractionJob) {
+	entries := hlsEntries(job, "")
+	if len(entries) == 0 {
+		http.Error(w, "arquivo não encontrado", http.StatusNotFound)
+		return
+	}
+	a.serveZip(w, r, friendlyBaseName(job.InputFileName, "audio")+"_hls.zip", entries)
+}
+
+// hlsEntries lists the job's playlist and segments, named under prefix.
+func hlsEntries(job *models.ExtractionJob, prefix string) []zipEntry {
+	dir := filepath.Dir(job.OutputPath)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, f := range files {
+		if hlsFileName.MatchString(f.Name()) {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+
+	entries := make([]zipEntry, len(names))
+	for i, name := range names {
+		entries[i] = zipEntry{Name: prefix + name, Path: filepath.Join(dir, name)}
+	}
+	return entries
+}
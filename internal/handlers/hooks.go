@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"extratorDeAudio/internal/models"
+)
+
+const (
+	defaultPostHookTimeout = 2 * time.Minute
+	// maxConcurrentHooks bounds how many hook processes run at once.
+	maxConcurrentHooks = 4
+	// maxHookLogBytes bounds the hook output kept in the logs.
+	maxHookLogBytes = 2048
+)
+
+// hookRunner executes the operator's POST_HOOK_CMD after completed jobs. The
+// command is an argument slice run without a shell; placeholders such as
+// {output} are expanded per argument, so values with spaces or shell
+// metacharacters can't inject extra arguments or commands.
+type hookRunner struct {
+	args    []string
+	timeout time.Duration
+	slots   chan struct{}
+}
+
+func newHookRunner(args []string, timeout time.Duration) *hookRunner {
+	if len(args) == 0 {
+		return nil
+	}
+	if timeout <= 0 {
+		timeout = defaultPostHookTimeout
+	}
+	return &hookRunner{args: args, timeout: timeout, slots: make(chan struct{}, maxConcurrentHooks)}
+}
+
+// runPostHook runs the configured hook for event in the background.
+func (a *App) runPostHook(jobID, event string) {
+	if a.hooks == nil {
+		return
+	}
+	job, ok := a.getJob(jobID)
+	if !ok {
+		return
+	}
+	go a.hooks.run(a, job, event)
+}
+
+func (h *hookRunner) run(a *App, job *models.ExtractionJob, event string) {
+	h.slots <- struct{}{}
+	defer func() { <-h.slots }()
+
+	vars := hookVars(job, event)
+	replacements := make([]string, 0, len(vars)*2)
+	env := os.Environ()
+	for _, v := range vars {
+		replacements = append(replacements, "{"+v.name+"}", v.value)
+		env = append(env, "EXTRATOR_"+strings.ToUpper(v.name)+"="+v.value)
+	}
+	replacer := strings.NewReplacer(replacements...)
+	args := make([]string, len(h.args))
+	for i, arg := range h.args {
+		args[i] = replacer.Replace(arg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = env
+	output := &tailWriter{max: maxHookLogBytes}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	start := time.Now()
+	err := cmd.Run()
+	logged := output.String()
+
+	attrs := []any{"job_id", job.ID, "event", event, "command", args[0], "duration_ms", time.Since(start).Milliseconds(), "output", logged}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		a.logger.Error("post hook failed", append(attrs, "error", err)...)
+		return
+	}
+	a.logger.Info("post hook finished", attrs...)
+}
+
+// tailWriter keeps only the last max bytes written to it, so a noisy hook
+// can't grow the buffer without bound. exec calls Write from at most one
+// goroutine at a time when Stdout and Stderr are the same writer.
+type tailWriter struct {
+	max int
+	buf []byte
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(p) >= t.max {
+		t.buf = append(t.buf[:0], p[len(p)-t.max:]...)
+		return n, nil
+	}
+	if drop := len(t.buf) + len(p) - t.max; drop > 0 {
+		t.buf = append(t.buf[:0], t.buf[drop:]...)
+	}
+	t.buf = append(t.buf, p...)
+	return n, nil
+}
+
+func (t *tailWriter) String() string {
+	return string(t.buf)
+}
+
+type hookVar struct {
+	name  string
+	value string
+}
+
+func hookVars(job *models.ExtractionJob, event string) []hookVar {
+	return []hookVar{
+		{"id", job.ID},
+		{"event", event},
+		{"input_name", job.InputFileName},
+		{"output", job.OutputPath},
+		{"output_name", job.OutputName},
+		{"format", job.Format},
+		{"transcript_txt", job.TranscriptTXTPath},
+		{"transcript_srt", job.TranscriptSRTPath},
+		{"transcript_vtt", job.TranscriptVTTPath},
+	}
+}
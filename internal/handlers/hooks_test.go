@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTailWriterKeepsLastBytes(t *testing.T) {
+	w := &tailWriter{max: 8}
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(w, "%d,", i)
+	}
+	if got := w.String(); got != "7,98,99," {
+		t.Errorf("tail = %q", got)
+	}
+	if cap(w.buf) > 64 {
+		t.Errorf("buffer grew to %d bytes", cap(w.buf))
+	}
+
+	_, _ = w.Write([]byte(strings.Repeat("x", 20) + "fim"))
+	if got := w.String(); got != "xxxxxfim" {
+		t.Errorf("tail after a large write = %q", got)
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Job ID formats for Config.IDFormat.
+const (
+	idFormatHex      = "hex"
+	idFormatFriendly = "friendly"
+	idFormatBase32   = "base32"
+)
+
+// friendlyNouns and friendlyAdjectives build IDs like
+// "gato-azul-k3q7m2xw9pa4e" whose words are easy to read over the phone.
+// Adjectives are invariable in gender so every pair reads correctly. The
+// words add only ~9 bits, so a 64-bit random suffix keeps the IDs, which
+// are all that guards a download, from being enumerated.
+var (
+	friendlyNouns = []string{
+		"gato", "peixe", "lobo", "urso", "tigre", "coruja", "pato", "sapo",
+		"leao", "cavalo", "girafa", "abelha", "zebra", "panda", "foca", "raposa",
+		"rio", "mar", "sol", "lua", "pedra", "nuvem", "vento", "ponte",
+	}
+	friendlyAdjectives = []string{
+		"azul", "verde", "feliz", "veloz", "forte", "leve", "doce", "livre",
+		"gentil", "jovem", "firme", "alegre", "nobre", "suave", "tenaz", "sutil",
+	}
+	lowerBase32 = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+)
+
+// newIDGenerator returns the job ID generator for format. prefix, reduced to
+// URL-safe characters, is prepended to the friendly and base32 formats.
+// Unknown formats fall back to the default hex IDs.
+func newIDGenerator(format, prefix string) func() string {
+	prefix = sanitizeIDPrefix(prefix)
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case idFormatFriendly:
+		return func() string {
+			b := make([]byte, 8)
+			if _, err := rand.Read(b); err != nil {
+				return prefix + newID()
+			}
+			return prefix + randomItem(friendlyNouns) + "-" + randomItem(friendlyAdjectives) + "-" + lowerBase32.EncodeToString(b)
+		}
+	case idFormatBase32:
+		return func() string {
+			b := make([]byte, 10)
+			if _, err := rand.Read(b); err != nil {
+				return prefix + newID()
+			}
+			return prefix + lowerBase32.EncodeToString(b)
+		}
+	default:
+		return newID
+	}
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("job-%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// uniqueJobID draws IDs until one is unused. Callers must hold a.mu so the
+// ID can be inserted before anyone else checks it.
+func (a *App) uniqueJobID() string {
+	for {
+		id := a.newJobID()
+		if _, taken := a.jobs[id]; !taken {
+			return id
+		}
+	}
+}
+
+// sanitizeIDPrefix keeps lowercase letters, digits and dashes, and ends the
+// prefix with a dash.
+func sanitizeIDPrefix(prefix string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(prefix) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			b.WriteRune(r)
+		}
+	}
+	clean := strings.Trim(b.String(), "-")
+	if clean == "" {
+		return ""
+	}
+	return clean + "-"
+}
+
+func randomItem(items []string) string {
+	return items[randomInt(len(items))]
+}
+
+func randomInt(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return int(time.Now().UnixNano() % int64(n))
+	}
+	return int(v.Int64())
+}
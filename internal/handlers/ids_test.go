@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFriendlyIDsCarry64RandomBits(t *testing.T) {
+	gen := newIDGenerator(idFormatFriendly, "sup")
+	// 8 random bytes encode to 13 base32 characters.
+	re := regexp.MustCompile(`^sup-[a-z]+-[a-z]+-[a-z2-7]{13}$`)
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := gen()
+		if !re.MatchString(id) {
+			t.Fatalf("id %q doesn't match %s", id, re)
+		}
+		if seen[id] {
+			t.Fatalf("id %q repeated", id)
+		}
+		seen[id] = true
+	}
+}
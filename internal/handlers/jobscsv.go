@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"extratorDeAudio/internal/models"
+)
+
+// jobFilter narrows job listings: Status and TranscriptStatus match the
+// stage statuses, Since keeps jobs updated after it and Limit keeps the most
+// recently updated jobs (0 = all).
+type jobFilter struct {
+	Limit            int
+	Status           models.JobStatus
+	TranscriptStatus models.JobStatus
+	Since            time.Time
+}
+
+// jobStatuses are the values accepted by the status filter.
+var jobStatuses = map[models.JobStatus]struct{}{
+	models.StatusNotStarted: {}, models.StatusUploading: {}, models.StatusUploaded: {},
+	models.StatusQueued: {}, models.StatusScheduled: {}, models.StatusProcessing: {},
+	models.StatusCompleted: {}, models.StatusFailed: {}, models.StatusCanceled: {},
+}
+
+// parseJobFilter reads ?limit=, ?status=, ?transcript_status= and ?since=
+// (RFC3339) from a listing request.
+func parseJobFilter(r *http.Request) (jobFilter, error) {
+	var f jobFilter
+	if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return f, errors.New("limit inválido")
+		}
+		f.Limit = limit
+	}
+	if v := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("status"))); v != "" {
+		if _, ok := jobStatuses[models.JobStatus(v)]; !ok {
+			return f, errors.New("status inválido")
+		}
+		f.Status = models.JobStatus(v)
+	}
+	if v := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("transcript_status"))); v != "" {
+		if _, ok := jobStatuses[models.JobStatus(v)]; !ok {
+			return f, errors.New("transcript_status inválido")
+		}
+		f.TranscriptStatus = models.JobStatus(v)
+	}
+	if v := strings.TrimSpace(r.URL.Query().Get("since")); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return f, errors.New("since inválido, use RFC3339")
+		}
+		f.Since = since
+	}
+	return f, nil
+}
+
+// filteredJobs returns clones of the jobs matching f, most recent first.
+func (a *App) filteredJobs(f jobFilter) []*models.ExtractionJob {
+	jobs := a.recentJobs(0)
+	matched := jobs[:0]
+	for _, job := range jobs {
+		switch {
+		case f.Status != "" && job.Status != f.Status:
+		case f.TranscriptStatus != "" && job.TranscriptStatus != f.TranscriptStatus:
+		case !f.Since.IsZero() && !job.UpdatedAt.After(f.Since):
+		default:
+			matched = append(matched, job)
+		}
+	}
+	jobs = matched
+	if f.Limit > 0 && len(jobs) > f.Limit {
+		jobs = jobs[:f.Limit]
+	}
+	return jobs
+}
+
+// jobsCSVHeader lists the columns of GET /api/jobs.csv.
+var jobsCSVHeader = []string{
+	"id", "input_file_name", "format", "quality", "status", "error_code",
+	"transcript_status", "input_bytes", "output_bytes", "clip_seconds",
+	"processing_seconds", "created_at", "updated_at",
+}
+
+// jobsCSV streams the job list as CSV for spreadsheets and billing, with the
+// same filters as the JSON listing. Sizes are read from disk and are empty
+// once the files are gone; clip_seconds is only set for trimmed jobs.
+func (a *App) jobsCSV(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseJobFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	jobs := a.filteredJobs(filter)
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="jobs-`+time.Now().Format("20060102-150405")+`.csv"`)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write(jobsCSVHeader)
+	for _, job := range jobs {
+		_ = cw.Write(jobCSVRecord(job))
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		a.logger.Warn("jobs csv write failed", "error", err)
+	}
+}
+
+func jobCSVRecord(job *models.ExtractionJob) []string {
+	outputBytes := int64(-1)
+	if len(job.Outputs) > 0 {
+		for _, out := range job.Outputs {
+			outputBytes = addSize(outputBytes, fileSize(out.Path))
+		}
+	} else {
+		outputBytes = fileSize(job.OutputPath)
+	}
+
+	clipSeconds := ""
+	if job.TrimEnd > job.TrimStart {
+		clipSeconds = formatCSVFloat(job.TrimEnd - job.TrimStart)
+	}
+	processingSeconds := ""
+	if job.Status == models.StatusCompleted || job.Status == models.StatusFailed {
+		processingSeconds = formatCSVFloat(job.UpdatedAt.Sub(job.CreatedAt).Seconds())
+	}
+
+	record := []string{
+		job.ID,
+		job.InputFileName,
+		job.Format,
+		job.Quality,
+		string(job.Status),
+		job.ErrorCode,
+		string(job.TranscriptStatus),
+		formatCSVSize(fileSize(job.InputPath)),
+		formatCSVSize(outputBytes),
+		clipSeconds,
+		processingSeconds,
+		job.CreatedAt.UTC().Format(time.RFC3339),
+		job.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	for i, cell := range record {
+		record[i] = csvSafe(cell)
+	}
+	return record
+}
+
+// csvSafe defuses cells a spreadsheet would run as a formula, such as a
+// file name of "=HYPERLINK(...)", by prefixing them with a quote.
+func csvSafe(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// fileSize returns the size of path, or -1 when it is unset or missing.
+func fileSize(path string) int64 {
+	if path == "" {
+		return -1
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return -1
+	}
+	return info.Size()
+}
+
+// addSize sums sizes where -1 means unknown.
+func addSize(total, size int64) int64 {
+	if size < 0 {
+		return total
+	}
+	if total < 0 {
+		return size
+	}
+	return total + size
+}
+
+func formatCSVSize(size int64) string {
+	if size < 0 {
+		return ""
+	}
+	return strconv.FormatInt(size, 10)
+}
+
+func formatCSVFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"extratorDeAudio/internal/models"
+)
+
+func TestJobCSVRecordDefusesFormulas(t *testing.T) {
+	tests := map[string]string{
+		"=HYPERLINK(\"http://x\")": "'=HYPERLINK(\"http://x\")",
+		"+1.mp4":                   "'+1.mp4",
+		"-cmd.mp4":                 "'-cmd.mp4",
+		"@SUM(A1).mkv":             "'@SUM(A1).mkv",
+		"\tvideo.mp4":              "'\tvideo.mp4",
+		"\rvideo.mp4":              "'\rvideo.mp4",
+		"video=final.mp4":          "video=final.mp4",
+	}
+	for name, want := range tests {
+		job := &models.ExtractionJob{ID: "abc", InputFileName: name, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		if got := jobCSVRecord(job)[1]; got != want {
+			t.Errorf("input_file_name %q written as %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+)
+
+// defaultLanguageDetectSeconds is the leading sample used for language
+// pre-detection; whisper only looks at the first 30s anyway.
+const defaultLanguageDetectSeconds = 30
+
+// parseDetectLanguage reads detect_language, falling back to the server
+// default when the request leaves it out.
+func (a *App) parseDetectLanguage(v string) bool {
+	if strings.TrimSpace(v) == "" {
+		return a.cfg.LanguageDetect
+	}
+	return parseBool(v)
+}
+
+// resolveLanguage returns the language the transcription should use. For
+// auto-language jobs with pre-detection enabled it detects the language on a
+// short leading sample so the full run can use it fixed; when detection fails
+// the run falls back to whisper's own auto-detect. Both the sampled result and
+// the final language are recorded on the job.
+func (a *App) resolveLanguage(ctx context.Context, job *models.ExtractionJob, opts extractor.TranscribeOptions, cb extractor.ProgressCallback) string {
+	language := opts.Language
+	if language == "" {
+		language = a.cfg.WhisperLanguage
+	}
+	if language == "" {
+		language = "auto"
+	}
+
+	detected := ""
+	if language == "auto" && job.DetectLanguage {
+		seconds := a.cfg.LanguageDetectSeconds
+		if seconds <= 0 {
+			seconds = defaultLanguageDetectSeconds
+		}
+		cb(2, "processing", "detectando idioma")
+		var err error
+		detected, err = a.extractor.DetectLanguage(ctx, job.OutputPath, seconds, opts)
+		if err != nil {
+			a.logger.Warn("language pre-detection failed", "job_id", job.ID, "error", err)
+		} else {
+			language = detected
+			a.logger.Info("language pre-detected", "job_id", job.ID, "language", detected)
+		}
+	}
+
+	a.updateJob(job.ID, func(j *models.ExtractionJob) {
+		j.DetectedLanguage = detected
+		j.TranscriptLanguage = language
+	})
+	return language
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+)
+
+// maintenanceMessage is the 503 body for requests rejected in maintenance.
+const maintenanceMessage = "servidor em manutenção: envios, extrações e transcrições estão temporariamente desativados"
+
+// writable rejects mutating requests with 503 while the server is in
+// maintenance (read-only) mode. Status, downloads and WebSocket progress keep
+// working and jobs already running finish normally.
+func (a *App) writable(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.maintenance.Load() {
+			w.Header().Set("Retry-After", "300")
+			http.Error(w, maintenanceMessage, http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setMaintenance toggles maintenance mode at runtime: POST
+// /admin/maintenance with enabled=1 or enabled=0. Without enabled it only
+// reports the current state.
+func (a *App) setMaintenance(w http.ResponseWriter, r *http.Request) {
+	if !a.isAdminRequest(r) {
+		http.Error(w, "não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	if v := strings.TrimSpace(r.FormValue("enabled")); v != "" {
+		enabled := parseBool(v)
+		if a.maintenance.Swap(enabled) != enabled {
+			a.logger.Info("maintenance mode changed", "enabled", enabled)
+		}
+	}
+	a.respondJSON(w, http.StatusOK, map[string]any{"maintenance": a.maintenance.Load()})
+}
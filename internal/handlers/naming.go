@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+)
+
+// namePlaceholders are the fields an output naming template may use.
+var namePlaceholders = map[string]struct{}{
+	"basename": {}, "jobid": {}, "format": {}, "quality": {}, "date": {}, "duration": {}, "ext": {},
+}
+
+var namePlaceholderRe = regexp.MustCompile(`\{([a-z_]*)\}`)
+
+// maxNameTemplateLen bounds templates and the names they expand to.
+const maxNameTemplateLen = 200
+
+// ValidateNameTemplate checks an output naming template such as
+// "{basename}_{quality}_{date}.{ext}". An empty template is valid and keeps
+// the default naming.
+func ValidateNameTemplate(tmpl string) error {
+	if len(tmpl) > maxNameTemplateLen {
+		return fmt.Errorf("template longer than %d characters", maxNameTemplateLen)
+	}
+	for _, m := range namePlaceholderRe.FindAllStringSubmatch(tmpl, -1) {
+		if _, ok := namePlaceholders[m[1]]; !ok {
+			return fmt.Errorf("unknown placeholder %q", m[0])
+		}
+	}
+	if strings.ContainsAny(namePlaceholderRe.ReplaceAllString(tmpl, ""), "{}") {
+		return fmt.Errorf("unbalanced braces in template %q", tmpl)
+	}
+	return nil
+}
+
+// nameSanitizer drops characters that are unsafe in file names or in the
+// quoted Content-Disposition filename.
+var nameSanitizer = strings.NewReplacer("/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_")
+
+// outputFileName returns the download name of a job output. label tells
+// apart the files of multi-output jobs (e.g. "ch0"). Without a template, the
+// job-scoped default name is used.
+func (a *App) outputFileName(ctx context.Context, job *models.ExtractionJob, label string) string {
+	id := job.ID
+	if label != "" {
+		id += "_" + label
+	}
+	fallback := extractor.OutputName(id, job.Format)
+
+	tmpl := job.NameTemplate
+	if tmpl == "" {
+		tmpl = a.cfg.OutputNameTemplate
+	}
+	if tmpl == "" {
+		return fallback
+	}
+
+	ext := strings.TrimPrefix(filepath.Ext(fallback), ".")
+	values := map[string]string{
+		"basename": friendlyBaseName(job.InputFileName, "audio"),
+		"jobid":    job.ID,
+		"format":   job.Format,
+		"quality":  job.Quality,
+		"date":     job.CreatedAt.Format("2006-01-02"),
+		"ext":      ext,
+	}
+	if strings.Contains(tmpl, "{duration}") {
+		if seconds, err := a.sourceDuration(ctx, job); err == nil {
+			seconds = clipDuration(seconds, job.TrimStart, job.TrimEnd)
+			values["duration"] = (time.Duration(seconds) * time.Second).String()
+		}
+	}
+
+	name := namePlaceholderRe.ReplaceAllStringFunc(tmpl, func(m string) string {
+		return values[m[1:len(m)-1]]
+	})
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, nameSanitizer.Replace(name))
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	// The extension always follows the real container, wherever {ext}
+	// appears in the template.
+	name = strings.TrimSuffix(name, "."+ext)
+	if label != "" {
+		name += "_" + label
+	}
+	name += "." + ext
+	if strings.HasPrefix(name, ".") || len(name) > maxNameTemplateLen+50 {
+		return fallback
+	}
+	return name
+}
+
+// clipDuration mirrors the extractor's trimmed length: the whole input, or
+// the [start, end) window when trimming.
+func clipDuration(total, start, end float64) float64 {
+	if end > start {
+		if total > 0 && end > total {
+			end = total
+		}
+		return end - start
+	}
+	if total > start {
+		return total - start
+	}
+	return 0
+}
@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+	"extratorDeAudio/internal/transcript"
+)
+
+// maxGainDB bounds the volume change accepted by gain_db, in either direction.
+const maxGainDB = 30.0
+
+// uploadOptions are the per-job settings accepted by POST /upload. The same
+// parser backs POST /api/validate-options so both agree on what is valid.
+type uploadOptions struct {
+	Format     string `json:"format"`
+	Quality    string `json:"quality"`
+	Channels   string `json:"channels"`
+	SampleRate int    `json:"sample_rate,omitempty"`
+	// Faststart puts the index of m4a outputs up front; on by default.
+	Faststart      bool    `json:"faststart"`
+	Start          float64 `json:"start"`
+	End            float64 `json:"end"`
+	SeekMode       string  `json:"seek"`
+	CopyTimestamps bool    `json:"copy_timestamps"`
+	InputFormat    string  `json:"input_format"`
+	OutputDir      string  `json:"output_dir"`
+	SplitChannels  bool    `json:"split_channels"`
+	CallbackURL    string  `json:"callback_url"`
+	GainDB         float64 `json:"gain_db"`
+	Normalize      bool    `json:"normalize"`
+	// PreserveMetadata keeps the source tags where the format allows it.
+	PreserveMetadata bool `json:"preserve_metadata"`
+	// SurroundDownmix folds 5.1/7.1 sources to stereo ("default" or
+	// "dialogue").
+	SurroundDownmix string `json:"surround_downmix,omitempty"`
+	// Track picks one audio stream by its index among audio streams; nil
+	// lets ffmpeg choose.
+	Track *int `json:"track,omitempty"`
+	// AllTracks extracts every audio stream to its own file.
+	AllTracks bool `json:"all_tracks"`
+	// Threads overrides FFMPEG_THREADS for this job; 0 means all cores.
+	Threads *int `json:"threads,omitempty"`
+	// NameTemplate overrides OUTPUT_NAME_TEMPLATE for this job.
+	NameTemplate string `json:"name_template,omitempty"`
+	// TargetDuration forces the output length in seconds, trimming longer
+	// audio and padding shorter audio with silence.
+	TargetDuration float64 `json:"target_duration,omitempty"`
+}
+
+// fieldError describes why one option was rejected.
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// parseUploadOptions reads and validates upload options through get (e.g.
+// r.FormValue). Unknown format/quality/channels/sample_rate values fall back
+// to their defaults as they always have; every other invalid value yields a
+// fieldError.
+func (a *App) parseUploadOptions(get func(string) string) (uploadOptions, []fieldError) {
+	var errs []fieldError
+	opts := uploadOptions{
+		Quality:        sanitizeQuality(get("quality")),
+		Channels:       sanitizeChannels(get("channels")),
+		SampleRate:     sanitizeSampleRate(get("sample_rate")),
+		Faststart:      get("faststart") == "" || parseBool(get("faststart")),
+		SeekMode:       sanitizeSeekMode(get("seek")),
+		CopyTimestamps: parseBool(get("copy_timestamps")),
+		SplitChannels:  parseBool(get("split_channels")),
+
+		PreserveMetadata: parseBool(get("preserve_metadata")),
+		Normalize:        parseBool(get("normalize")),
+		AllTracks:        parseBool(get("all_tracks")),
+	}
+
+	start, err := parseClipTime(get("start"))
+	if err != nil {
+		errs = append(errs, fieldError{Field: "start", Message: "início do corte inválido"})
+	}
+	end, err := parseClipTime(get("end"))
+	if err != nil {
+		errs = append(errs, fieldError{Field: "end", Message: "fim do corte inválido"})
+	}
+	duration, err := parseClipTime(get("duration"))
+	if err != nil {
+		errs = append(errs, fieldError{Field: "duration", Message: "duração do corte inválida"})
+	}
+	switch {
+	case end > 0 && duration > 0:
+		errs = append(errs, fieldError{Field: "duration", Message: "informe apenas o fim ou a duração do corte"})
+	case duration > 0:
+		end = start + duration
+	}
+	if end > 0 && end <= start {
+		errs = append(errs, fieldError{Field: "end", Message: "fim do corte deve ser maior que o início"})
+	}
+	opts.Start, opts.End = start, end
+	target, err := parseClipTime(get("target_duration"))
+	if err != nil || (target <= 0 && strings.TrimSpace(get("target_duration")) != "") {
+		errs = append(errs, fieldError{Field: "target_duration", Message: "duração final deve ser positiva"})
+	}
+	opts.TargetDuration = target
+
+	var ok bool
+	if opts.Format, ok = a.resolveFormat(get("format")); !ok {
+		errs = append(errs, fieldError{Field: "format", Message: fmt.Sprintf("formato %s não está habilitado neste servidor", strings.ToLower(strings.TrimSpace(get("format"))))})
+	}
+	if opts.InputFormat, ok = sanitizeInputFormat(get("input_format")); !ok {
+		errs = append(errs, fieldError{Field: "input_format", Message: "formato de entrada não suportado"})
+	}
+	if opts.OutputDir, ok = sanitizeOutputDir(get("output_dir")); !ok {
+		errs = append(errs, fieldError{Field: "output_dir", Message: "diretório de saída inválido"})
+	}
+	if opts.GainDB, ok = parseGainDB(get("gain_db")); !ok {
+		errs = append(errs, fieldError{Field: "gain_db", Message: fmt.Sprintf("ganho deve estar entre %g e %g dB", -maxGainDB, maxGainDB)})
+	}
+	if opts.SplitChannels && opts.Format == extractor.FormatHLS {
+		errs = append(errs, fieldError{Field: "split_channels", Message: "canais separados não são suportados no formato HLS"})
+	}
+	if opts.SurroundDownmix, ok = sanitizeSurroundDownmix(get("surround_downmix")); !ok {
+		errs = append(errs, fieldError{Field: "surround_downmix", Message: "modo de downmix inválido (use default ou dialogue)"})
+	}
+	if opts.Track, ok = parseTrackIndex(get("track")); !ok {
+		errs = append(errs, fieldError{Field: "track", Message: "faixa de áudio inválida"})
+	}
+	if opts.Track != nil && opts.AllTracks {
+		errs = append(errs, fieldError{Field: "track", Message: "escolha entre uma faixa e todas as faixas"})
+	}
+	if opts.AllTracks && opts.Format == extractor.FormatHLS {
+		errs = append(errs, fieldError{Field: "all_tracks", Message: "todas as faixas não são suportadas no formato HLS"})
+	}
+	if opts.AllTracks && opts.SplitChannels {
+		errs = append(errs, fieldError{Field: "all_tracks", Message: "escolha entre todas as faixas e canais separados"})
+	}
+	if opts.Threads, ok = parseThreads(get("threads")); !ok {
+		errs = append(errs, fieldError{Field: "threads", Message: fmt.Sprintf("threads deve ser um inteiro entre 0 e %d", MaxFFmpegThreads)})
+	}
+	opts.NameTemplate = strings.TrimSpace(get("name_template"))
+	if err := ValidateNameTemplate(opts.NameTemplate); err != nil {
+		errs = append(errs, fieldError{Field: "name_template", Message: "modelo de nome inválido: " + err.Error()})
+	}
+	if opts.CallbackURL, ok = a.checkCallbackURL(get("callback_url")); !ok {
+		errs = append(errs, fieldError{Field: "callback_url", Message: "URL de callback inválida"})
+	}
+
+	return opts, errs
+}
+
+// apply copies the options onto a new job.
+func (o uploadOptions) apply(job *models.ExtractionJob) {
+	job.Format = o.Format
+	job.Quality = o.Quality
+	job.Channels = o.Channels
+	job.SampleRate = o.SampleRate
+	job.NoFaststart = !o.Faststart
+	job.TrimStart = o.Start
+	job.TrimEnd = o.End
+	job.SeekMode = o.SeekMode
+	job.CopyTimestamps = o.CopyTimestamps
+	job.InputFormat = o.InputFormat
+	job.OutputDir = o.OutputDir
+	job.SplitChannels = o.SplitChannels
+	job.CallbackURL = o.CallbackURL
+	job.GainDB = o.GainDB
+	job.Normalize = o.Normalize
+	job.PreserveMetadata = o.PreserveMetadata
+	job.NameTemplate = o.NameTemplate
+	job.AllTracks = o.AllTracks
+	job.SurroundDownmix = o.SurroundDownmix
+	job.TrackIndex = o.Track
+	job.FFmpegThreads = o.Threads
+	job.TargetDuration = o.TargetDuration
+}
+
+// maxTrackIndex bounds the track option; real files carry a handful.
+const maxTrackIndex = 63
+
+// parseTrackIndex parses the 0-based audio track index. An empty value is
+// valid and means ffmpeg's default stream.
+func parseTrackIndex(v string) (*int, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, true
+	}
+	track, err := strconv.Atoi(v)
+	if err != nil || track < 0 || track > maxTrackIndex {
+		return nil, false
+	}
+	return &track, true
+}
+
+// MaxFFmpegThreads bounds FFMPEG_THREADS and the threads option.
+const MaxFFmpegThreads = 256
+
+// ValidateFFmpegThreads checks an ffmpeg thread count: 0 (auto) up to
+// MaxFFmpegThreads.
+func ValidateFFmpegThreads(n int) error {
+	if n < 0 || n > MaxFFmpegThreads {
+		return fmt.Errorf("threads must be between 0 and %d, got %d", MaxFFmpegThreads, n)
+	}
+	return nil
+}
+
+// parseThreads parses the per-job ffmpeg thread count. An empty value is
+// valid and keeps the server default.
+func parseThreads(v string) (*int, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, true
+	}
+	threads, err := strconv.Atoi(v)
+	if err != nil || ValidateFFmpegThreads(threads) != nil {
+		return nil, false
+	}
+	return &threads, true
+}
+
+// ffmpegThreads is the thread count for the job's extractions: its own
+// override, else the server default.
+func (a *App) ffmpegThreads(job *models.ExtractionJob) int {
+	if job.FFmpegThreads != nil {
+		return *job.FFmpegThreads
+	}
+	return a.cfg.FFmpegThreads
+}
+
+// sanitizeSurroundDownmix validates the downmix mode. An empty value is valid
+// and keeps the source channels.
+func sanitizeSurroundDownmix(v string) (string, bool) {
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case "", extractor.DownmixDefault, extractor.DownmixDialogue:
+		return v, true
+	}
+	return "", false
+}
+
+// sanitizeLanguageTag keeps a stream language tag usable in a file name:
+// lowercase letters only, "und" (undetermined) dropped.
+func sanitizeLanguageTag(v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "" || v == "und" || len(v) > 8 {
+		return ""
+	}
+	for _, r := range v {
+		if r < 'a' || r > 'z' {
+			return ""
+		}
+	}
+	return v
+}
+
+// validateOptions is a dry run of the upload option parsing: it accepts the
+// options as a JSON object and returns the effective values or the errors.
+func (a *App) validateOptions(w http.ResponseWriter, r *http.Request) {
+	var raw map[string]any
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&raw); err != nil {
+		a.respondJSON(w, http.StatusBadRequest, map[string]any{
+			"valid":  false,
+			"errors": []fieldError{{Field: "", Message: "JSON inválido"}},
+		})
+		return
+	}
+
+	opts, errs := a.parseUploadOptions(func(key string) string {
+		v, ok := raw[key]
+		if !ok || v == nil {
+			return ""
+		}
+		return fmt.Sprint(v)
+	})
+	if len(errs) > 0 {
+		a.respondJSON(w, http.StatusUnprocessableEntity, map[string]any{"valid": false, "errors": errs})
+		return
+	}
+	// Same rule as the upload itself.
+	if opts.OutputDir != "" && !a.isAdminRequest(r) {
+		a.respondJSON(w, http.StatusForbidden, map[string]any{
+			"valid":  false,
+			"errors": []fieldError{{Field: "output_dir", Message: "diretório de saída exige autenticação"}},
+		})
+		return
+	}
+	a.respondJSON(w, http.StatusOK, map[string]any{"valid": true, "options": opts})
+}
+
+// inputFormats lists the ffmpeg demuxers accepted as an upload format hint.
+var inputFormats = map[string]struct{}{
+	"aac": {}, "ac3": {}, "aiff": {}, "amr": {}, "asf": {}, "avi": {}, "flac": {},
+	"flv": {}, "matroska": {}, "mov": {}, "mp3": {}, "mp4": {}, "mpeg": {},
+	"mpegts": {}, "ogg": {}, "wav": {}, "webm": {},
+}
+
+// sanitizeInputFormat validates the optional demuxer hint. An empty value is
+// valid and lets ffmpeg probe the input.
+func sanitizeInputFormat(v string) (string, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "" {
+		return "", true
+	}
+	if _, ok := inputFormats[v]; !ok {
+		return "", false
+	}
+	return v, true
+}
+
+// sanitizeOutputDir validates a relative output subdirectory. Absolute paths,
+// ".." segments and characters outside [A-Za-z0-9._-] are rejected. An empty
+// value is valid and means the outputs root.
+func sanitizeOutputDir(v string) (string, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", true
+	}
+	if strings.HasPrefix(v, "/") || strings.HasPrefix(v, "\\") || filepath.IsAbs(v) || filepath.VolumeName(v) != "" {
+		return "", false
+	}
+
+	segments := strings.Split(strings.Trim(v, "/"), "/")
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", false
+		}
+		for _, r := range segment {
+			if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '.' || r == '_' || r == '-') {
+				return "", false
+			}
+		}
+	}
+	return strings.Join(segments, "/"), true
+}
+
+// parseGainDB parses a gain such as "+6", "-3" or "-3dB". An empty value
+// means no change.
+func parseGainDB(v string) (float64, bool) {
+	v = strings.TrimSpace(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(v)), "db"))
+	if v == "" {
+		return 0, true
+	}
+	gain, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsNaN(gain) || gain < -maxGainDB || gain > maxGainDB {
+		return 0, false
+	}
+	return gain, true
+}
+
+func sanitizeSeekMode(v string) string {
+	if strings.ToLower(strings.TrimSpace(v)) == extractor.SeekAccurate {
+		return extractor.SeekAccurate
+	}
+	return extractor.SeekFast
+}
+
+// parseClipTime accepts plain seconds ("90.5") or a timecode ("01:30",
+// "00:01:30.5"). An empty value yields zero.
+func parseClipTime(v string) (float64, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, nil
+	}
+	if !strings.Contains(v, ":") {
+		seconds, err := strconv.ParseFloat(v, 64)
+		if err != nil || seconds < 0 || math.IsInf(seconds, 0) || math.IsNaN(seconds) {
+			return 0, fmt.Errorf("invalid time %q", v)
+		}
+		return seconds, nil
+	}
+	d, err := transcript.ParseTimecode(v)
+	if err != nil {
+		return 0, err
+	}
+	return d.Seconds(), nil
+}
+
+// ValidateOverwritePolicy checks the OVERWRITE_POLICY setting.
+func ValidateOverwritePolicy(v string) error {
+	if !extractor.ValidOverwritePolicy(v) {
+		return fmt.Errorf("unknown overwrite policy %q (want overwrite, skip or fail)", v)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"time"
+)
+
+// uploadIdleTimeout is how long a reserved upload may wait for its file, or
+// a chunked upload for its next chunk, before it is dropped.
+const uploadIdleTimeout = 5 * time.Minute
+
+// A pending upload is a job that isn't receiving data right now: a
+// reservation from /api/uploads not yet claimed, or a chunked upload between
+// chunks. Pending uploads fail after uploadIdleTimeout, so abandoned ones
+// don't linger until the cleanup TTL. Callers of the Locked helpers must
+// hold a.mu.
+
+// trackPendingLocked (re)starts the idle timer of jobID.
+func (a *App) trackPendingLocked(jobID string) {
+	if t, ok := a.pending[jobID]; ok {
+		t.Stop()
+	}
+	a.pending[jobID] = time.AfterFunc(uploadIdleTimeout, func() { a.expirePending(jobID) })
+}
+
+// untrackPendingLocked stops the idle timer of jobID, reporting whether it
+// was pending.
+func (a *App) untrackPendingLocked(jobID string) bool {
+	t, ok := a.pending[jobID]
+	if ok {
+		t.Stop()
+		delete(a.pending, jobID)
+	}
+	return ok
+}
+
+// expirePending fails an upload that stayed idle for uploadIdleTimeout. The
+// job is kept as failed so subscribed clients learn why.
+func (a *App) expirePending(jobID string) {
+	a.mu.Lock()
+	if _, ok := a.pending[jobID]; !ok {
+		// Claimed in the meantime.
+		a.mu.Unlock()
+		return
+	}
+	delete(a.pending, jobID)
+	job, ok := a.jobs[jobID]
+	var inputPath string
+	if ok {
+		inputPath = job.InputPath
+	}
+	a.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	a.logger.Info("idle upload expired", "job_id", jobID)
+	a.abortUpload(jobID, inputPath, true, "upload expirado por inatividade")
+}
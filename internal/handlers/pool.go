@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"extratorDeAudio/internal/models"
+)
+
+// workerPool caps how many jobs of one stage run at once. Jobs beyond the
+// limit wait in FIFO order and are told their position in line whenever it
+// changes. A size <= 0 means no limit.
+type workerPool struct {
+	stage string
+	size  int
+	// onQueue reports the 1-based position of each waiting job.
+	onQueue func(stage, jobID string, position int)
+
+	mu      sync.Mutex
+	active  int
+	waiting []*poolWaiter
+}
+
+type poolWaiter struct {
+	jobID string
+	// task marks work that isn't a job stage; its position isn't reported.
+	task bool
+	// ready receives true when the job gets a slot and false when it was
+	// removed from the line.
+	ready chan bool
+}
+
+func newWorkerPool(stage string, size int, onQueue func(stage, jobID string, position int)) *workerPool {
+	return &workerPool{stage: stage, size: size, onQueue: onQueue}
+}
+
+// acquire blocks until the job may run. It returns false when the job left
+// the line through remove; otherwise the caller must call release.
+func (p *workerPool) acquire(jobID string) bool {
+	return p.wait(&poolWaiter{jobID: jobID})
+}
+
+// acquireTask is acquire for work that isn't a job stage, such as conversions
+// and samples. key only identifies the waiter: it must be unique among
+// waiters and is never reported through onQueue. When ctx ends first the
+// task leaves the line and false is returned.
+func (p *workerPool) acquireTask(ctx context.Context, key string) bool {
+	stop := context.AfterFunc(ctx, func() { p.remove(key) })
+	ok := p.wait(&poolWaiter{jobID: key, task: true})
+	stop()
+	return ok
+}
+
+// wait takes a free slot or queues w until one is handed over.
+func (p *workerPool) wait(w *poolWaiter) bool {
+	p.mu.Lock()
+	if p.size <= 0 || (p.active < p.size && len(p.waiting) == 0) {
+		p.active++
+		p.mu.Unlock()
+		return true
+	}
+	w.ready = make(chan bool, 1)
+	p.waiting = append(p.waiting, w)
+	line := p.lineLocked()
+	p.mu.Unlock()
+
+	p.notify(line)
+	return <-w.ready
+}
+
+// release frees a slot, handing it straight to the first waiting job.
+func (p *workerPool) release() {
+	p.mu.Lock()
+	if len(p.waiting) == 0 {
+		p.active--
+		p.mu.Unlock()
+		return
+	}
+	next := p.waiting[0]
+	p.waiting = p.waiting[1:]
+	next.ready <- true
+	line := p.lineLocked()
+	p.mu.Unlock()
+
+	p.notify(line)
+}
+
+// remove takes a waiting job out of the line, e.g. when it is canceled. It
+// reports whether the job was waiting.
+func (p *workerPool) remove(jobID string) bool {
+	p.mu.Lock()
+	for i, w := range p.waiting {
+		if w.jobID != jobID {
+			continue
+		}
+		p.waiting = append(p.waiting[:i:i], p.waiting[i+1:]...)
+		w.ready <- false
+		line := p.lineLocked()
+		p.mu.Unlock()
+		p.notify(line)
+		return true
+	}
+	p.mu.Unlock()
+	return false
+}
+
+// waitingTasks counts the tasks waiting in line; job stages are counted by
+// their status instead.
+func (p *workerPool) waitingTasks() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, w := range p.waiting {
+		if w.task {
+			n++
+		}
+	}
+	return n
+}
+
+// stats reports the running and waiting jobs and the limit.
+func (p *workerPool) stats() map[string]int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return map[string]int{"active": p.active, "waiting": len(p.waiting), "size": p.size}
+}
+
+// lineLocked lists the waiting job IDs in order, with "" for tasks so jobs
+// behind them still get their true position.
+func (p *workerPool) lineLocked() []string {
+	line := make([]string, len(p.waiting))
+	for i, w := range p.waiting {
+		if !w.task {
+			line[i] = w.jobID
+		}
+	}
+	return line
+}
+
+// notify runs outside p.mu since onQueue takes the App lock.
+func (p *workerPool) notify(line []string) {
+	if p.onQueue == nil {
+		return
+	}
+	for i, jobID := range line {
+		if jobID != "" {
+			p.onQueue(p.stage, jobID, i+1)
+		}
+	}
+}
+
+// reportQueuePosition stores and broadcasts a job's place in line.
+func (a *App) reportQueuePosition(stage, jobID string, position int) {
+	a.updateJob(jobID, func(j *models.ExtractionJob) {
+		j.QueuePosition = position
+		j.UpdatedAt = time.Now()
+	})
+	a.broadcast(jobID, models.ProgressEvent{
+		ID:       jobID,
+		Stage:    stage,
+		Status:   models.StatusQueued,
+		Progress: 1,
+		Message:  fmt.Sprintf("na fila: posição %d", position),
+	})
+}
+
+// leaveQueue clears the position once the job got a slot.
+func (a *App) leaveQueue(jobID string) {
+	a.updateJob(jobID, func(j *models.ExtractionJob) {
+		j.QueuePosition = 0
+	})
+}
+
+// stagePool returns the pool of a stage.
+func (a *App) stagePool(stage string) *workerPool {
+	if stage == "transcription" {
+		return a.transcribePool
+	}
+	return a.extractPool
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAcquireTaskLeavesLineWhenCanceled(t *testing.T) {
+	p := newWorkerPool("extraction", 1, nil)
+	if !p.acquire("running") {
+		t.Fatal("first acquire should get the free slot")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if p.acquireTask(ctx, "waiting") {
+		t.Fatal("acquireTask got a slot while the pool was full")
+	}
+	if got := p.stats()["waiting"]; got != 0 {
+		t.Fatalf("waiting = %d after the context ended, want 0", got)
+	}
+
+	p.release()
+	if !p.acquireTask(context.Background(), "next") {
+		t.Fatal("acquireTask should get the released slot")
+	}
+	p.release()
+}
+
+func TestTasksAreNotReportedInLine(t *testing.T) {
+	var mu sync.Mutex
+	var reported []string
+	p := newWorkerPool("extraction", 1, func(stage, jobID string, position int) {
+		mu.Lock()
+		reported = append(reported, jobID)
+		mu.Unlock()
+	})
+	p.acquire("running")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.acquireTask(ctx, "job/samples/1")
+	waitWaiting(t, p, 1)
+	go p.acquire("job2")
+	waitWaiting(t, p, 2)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(reported, []string{"job2"}) {
+		t.Errorf("reported = %v, want only the job", reported)
+	}
+}
+
+func waitWaiting(t *testing.T, p *workerPool, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for p.stats()["waiting"] != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("waiting = %d, want %d", p.stats()["waiting"], n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBroadcastSkipsSeriesOfUnknownIDs(t *testing.T) {
+	a := newTestApp(t, Config{})
+	a.reportQueuePosition("extraction", "job/transcode/mp3", 1)
+	if got := a.progressSeries.snapshot("job/transcode/mp3"); len(got) != 0 {
+		t.Errorf("series recorded for a non-job key: %v", got)
+	}
+}
+
+func TestQueueDepthCountsWaitingTasks(t *testing.T) {
+	a := newTestApp(t, Config{MaxConcurrentJobs: 1, MaxQueueDepth: 1})
+	a.extractPool.acquire("running")
+	defer a.extractPool.release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.extractPool.acquireTask(ctx, "job/samples/1")
+	waitWaiting(t, a.extractPool, 1)
+
+	if !a.queueFull() {
+		t.Error("a waiting task did not count toward MAX_QUEUE_DEPTH")
+	}
+}
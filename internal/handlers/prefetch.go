@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+)
+
+// mediaCache keeps the ffprobe summary of each upload, gathered right after
+// the file lands so it is ready before the user asks for an extraction.
+type mediaCache struct {
+	mu    sync.Mutex
+	infos map[string]extractor.MediaInfo
+}
+
+func newMediaCache() *mediaCache {
+	return &mediaCache{infos: make(map[string]extractor.MediaInfo)}
+}
+
+func (c *mediaCache) get(jobID string) (extractor.MediaInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info, ok := c.infos[jobID]
+	return info, ok
+}
+
+func (c *mediaCache) set(jobID string, info extractor.MediaInfo) {
+	c.mu.Lock()
+	c.infos[jobID] = info
+	c.mu.Unlock()
+}
+
+func (c *mediaCache) forget(jobID string) {
+	c.mu.Lock()
+	delete(c.infos, jobID)
+	c.mu.Unlock()
+}
+
+// sourceDuration returns the length of the job's input, from the prefetch
+// cache when the upload was already probed.
+func (a *App) sourceDuration(ctx context.Context, job *models.ExtractionJob) (float64, error) {
+	if info, ok := a.media.get(job.ID); ok && info.Duration > 0 {
+		return info.Duration, nil
+	}
+	return a.extractor.Duration(ctx, job.InputPath)
+}
+
+// prefetchMedia probes a finished upload in the background when
+// PrefetchProbe is on. Failures are only logged: the extraction probes again
+// and reports real problems itself.
+func (a *App) prefetchMedia(jobID, inputPath string) {
+	if !a.cfg.PrefetchProbe {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		start := time.Now()
+		info, err := a.extractor.ProbeInfo(ctx, inputPath)
+		if err != nil {
+			a.logger.Warn("upload prefetch probe failed", "job_id", jobID, "error", err)
+			return
+		}
+		a.media.set(jobID, info)
+		a.logger.Info("upload probed", "job_id", jobID, "duration", info.Duration, "elapsed_ms", time.Since(start).Milliseconds())
+	}()
+}
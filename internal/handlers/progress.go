@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"sync"
+
+	"extratorDeAudio/internal/models"
+)
+
+// Progress aggregation modes for jobs producing several output files.
+const (
+	aggregateAverage  = "average"
+	aggregateWeighted = "weighted"
+)
+
+// progressAggregator folds the progress of several outputs of one job into a
+// single percentage. Outputs may be encoded concurrently, so updates are
+// serialized by a mutex.
+type progressAggregator struct {
+	mu      sync.Mutex
+	outputs []models.OutputProgress
+	weights []float64
+}
+
+// newProgressAggregator tracks the given outputs. In weighted mode each
+// output counts proportionally to its weight (e.g. estimated size); otherwise
+// outputs are averaged. Missing or non-positive weights count as 1.
+func newProgressAggregator(names []string, weights []float64, mode string) *progressAggregator {
+	p := &progressAggregator{
+		outputs: make([]models.OutputProgress, len(names)),
+		weights: make([]float64, len(names)),
+	}
+	for i, name := range names {
+		p.outputs[i] = models.OutputProgress{Name: name, Status: models.StatusQueued}
+		p.weights[i] = 1
+		if mode == aggregateWeighted && i < len(weights) && weights[i] > 0 {
+			p.weights[i] = weights[i]
+		}
+	}
+	return p
+}
+
+// Update records the progress of output i and returns the overall percentage
+// plus a snapshot of every output.
+func (p *progressAggregator) Update(i, percent int, status models.JobStatus) (int, []models.OutputProgress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if i >= 0 && i < len(p.outputs) {
+		p.outputs[i].Progress = clampPercent(percent)
+		p.outputs[i].Status = status
+	}
+
+	var done, total float64
+	for i, out := range p.outputs {
+		done += float64(out.Progress) * p.weights[i]
+		total += p.weights[i]
+	}
+	overall := 0
+	if total > 0 {
+		overall = int(done / total)
+	}
+
+	snapshot := make([]models.OutputProgress, len(p.outputs))
+	copy(snapshot, p.outputs)
+	return overall, snapshot
+}
+
+// Len returns the number of tracked outputs.
+func (p *progressAggregator) Len() int {
+	return len(p.outputs)
+}
+
+func clampPercent(v int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 100 {
+		return 100
+	}
+	return v
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxProgressSamples bounds the samples kept per job stage. When a stage
+// reaches it, every other sample is dropped, so the series keeps covering
+// the whole run at a coarser resolution.
+const maxProgressSamples = 200
+
+// progressSample is one point of a progress series.
+type progressSample struct {
+	At       time.Time `json:"t"`
+	Progress int       `json:"progress"`
+}
+
+// progressSeries records progress over time per job and stage, for
+// sparklines showing processing speed.
+type progressSeries struct {
+	mu   sync.Mutex
+	jobs map[string]map[string][]progressSample
+}
+
+func newProgressSeries() *progressSeries {
+	return &progressSeries{jobs: make(map[string]map[string][]progressSample)}
+}
+
+// record appends a sample unless the progress did not change.
+func (p *progressSeries) record(jobID, stage string, progress int) {
+	if jobID == "" || stage == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stages := p.jobs[jobID]
+	if stages == nil {
+		stages = make(map[string][]progressSample)
+		p.jobs[jobID] = stages
+	}
+	samples := stages[stage]
+	if n := len(samples); n > 0 && samples[n-1].Progress == progress {
+		return
+	}
+	if len(samples) >= maxProgressSamples {
+		kept := samples[:0]
+		for i := 0; i < len(samples); i += 2 {
+			kept = append(kept, samples[i])
+		}
+		samples = kept
+	}
+	stages[stage] = append(samples, progressSample{At: time.Now(), Progress: progress})
+}
+
+// snapshot copies the series of a job.
+func (p *progressSeries) snapshot(jobID string) map[string][]progressSample {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make(map[string][]progressSample, len(p.jobs[jobID]))
+	for stage, samples := range p.jobs[jobID] {
+		out[stage] = append([]progressSample(nil), samples...)
+	}
+	return out
+}
+
+func (p *progressSeries) forget(jobID string) {
+	p.mu.Lock()
+	delete(p.jobs, jobID)
+	p.mu.Unlock()
+}
+
+// jobProgressSeries serves the recorded (timestamp, percent) points of each
+// stage of a job.
+func (a *App) jobProgressSeries(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+	if _, ok := a.getJob(jobID); !ok {
+		http.Error(w, "job não encontrado", http.StatusNotFound)
+		return
+	}
+	a.respondJSON(w, http.StatusOK, map[string]any{
+		"job_id": jobID,
+		"stages": a.progressSeries.snapshot(jobID),
+	})
+}
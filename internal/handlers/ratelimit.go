@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultUploadsPerMinute = 30
+
+// rateLimiter is a per-client token bucket: each key may spend burst
+// tokens at once, refilled at rate per second.
+type rateLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(perMinute),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token for key, or reports how long until one is available.
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep drops buckets that have refilled completely, at most once a minute,
+// so idle clients don't accumulate.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// limitUploads caps uploads per client IP at UploadsPerMinute, answering 429
+// with Retry-After once the bucket is empty. It relies on realIP having set
+// RemoteAddr.
+func (a *App) limitUploads(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.uploadLimiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ok, wait := a.uploadLimiter.allow(clientIP(r), time.Now())
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "muitos envios, tente novamente mais tarde", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newUploadLimiter builds the upload limiter from UploadsPerMinute: zero
+// uses the default and a negative value disables limiting.
+func newUploadLimiter(perMinute int) *rateLimiter {
+	if perMinute < 0 {
+		return nil
+	}
+	if perMinute == 0 {
+		perMinute = defaultUploadsPerMinute
+	}
+	return newRateLimiter(perMinute)
+}
+
+// clientIP is RemoteAddr without the port, which realIP leaves off when it
+// took the address from a proxy header.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
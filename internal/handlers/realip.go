@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParseTrustedProxies parses TRUSTED_PROXIES, a comma-separated list of
+// proxy addresses or CIDR ranges (e.g. "10.0.0.0/8,127.0.0.1").
+func ParseTrustedProxies(v string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			addr, err := netip.ParseAddr(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid proxy address %q", part)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy range %q", part)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// trustedProxy reports whether addr is one of the TrustedProxies.
+func (a *App) trustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range a.cfg.TrustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// realIP sets RemoteAddr to the client address. X-Forwarded-For and
+// X-Real-IP are only honored when the connection comes from a trusted
+// proxy; anyone else could rotate them to dodge the per-IP upload limit.
+// X-Forwarded-For is read from the right, skipping trusted hops, so a value
+// the client prepended is never picked.
+func (a *App) realIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := a.forwardedIP(r); ip != "" {
+			r.RemoteAddr = ip
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *App) forwardedIP(r *http.Request) string {
+	peer, err := netip.ParseAddr(clientIP(r))
+	if err != nil || !a.trustedProxy(peer) {
+		return ""
+	}
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				return ""
+			}
+			if !a.trustedProxy(hop) {
+				return hop.Unmap().String()
+			}
+		}
+		return ""
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIPTrustsOnlyConfiguredProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := newTestApp(t, Config{TrustedProxies: proxies})
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    string
+		realIP string
+		want   string
+	}{
+		{"direct client spoofing", "203.0.113.7:5000", "1.2.3.4", "", "203.0.113.7:5000"},
+		{"direct client spoofing x-real-ip", "203.0.113.7:5000", "", "1.2.3.4", "203.0.113.7:5000"},
+		{"trusted proxy", "10.1.2.3:5000", "198.51.100.9", "", "198.51.100.9"},
+		{"prepended value ignored", "10.1.2.3:5000", "1.2.3.4, 198.51.100.9, 192.168.1.5", "", "198.51.100.9"},
+		{"trusted proxy x-real-ip", "192.168.1.5:80", "", "198.51.100.9", "198.51.100.9"},
+		{"only proxies in chain", "10.1.2.3:5000", "10.9.9.9", "", "10.1.2.3:5000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			var got string
+			app.realIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = r.RemoteAddr })).ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxiesRejectsGarbage(t *testing.T) {
+	if _, err := ParseTrustedProxies("10.0.0.0/8,proxy.local"); err == nil {
+		t.Error("want an error for a host name")
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// reextract runs a fresh extraction of a job's preserved upload in another
+// format/quality. The result is a new child job (ParentID links it back)
+// sharing the parent's input file and settings, so both outputs stay
+// downloadable.
+func (a *App) reextract(w http.ResponseWriter, r *http.Request) {
+	parentID := chi.URLParam(r, "id")
+	if strings.TrimSpace(r.FormValue("format")) == "" {
+		http.Error(w, "informe o formato", http.StatusBadRequest)
+		return
+	}
+	format, ok := a.resolveFormat(r.FormValue("format"))
+	if !ok {
+		http.Error(w, "formato desativado neste servidor", http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	parent, ok := a.jobs[parentID]
+	if !ok {
+		a.mu.Unlock()
+		http.Error(w, "job não encontrado", http.StatusNotFound)
+		return
+	}
+	switch parent.Status {
+	case models.StatusNotStarted, models.StatusUploading:
+		a.mu.Unlock()
+		http.Error(w, "upload ainda não foi concluído", http.StatusConflict)
+		return
+	}
+	if parent.ArchivedAt != nil || parent.InputPath == "" {
+		a.mu.Unlock()
+		http.Error(w, "arquivo de entrada indisponível", http.StatusGone)
+		return
+	}
+	if _, err := os.Stat(parent.InputPath); err != nil {
+		a.mu.Unlock()
+		http.Error(w, "arquivo de entrada indisponível", http.StatusGone)
+		return
+	}
+	if format == extractor.FormatHLS && (parent.SplitChannels || parent.AllTracks) {
+		a.mu.Unlock()
+		http.Error(w, "o formato HLS não suporta as saídas múltiplas deste job", http.StatusBadRequest)
+		return
+	}
+	if a.queueFullLocked() {
+		a.mu.Unlock()
+		a.rejectQueueFull(w)
+		return
+	}
+
+	now := time.Now()
+	child := &models.ExtractionJob{
+		ID:               a.uniqueJobID(),
+		ParentID:         parent.ID,
+		InputFileName:    parent.InputFileName,
+		InputPath:        parent.InputPath,
+		Status:           models.StatusQueued,
+		Progress:         1,
+		TranscriptStatus: models.StatusNotStarted,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	copyExtractionSettings(child, parent)
+	child.Format = format
+	if q := r.FormValue("quality"); q != "" {
+		child.Quality = sanitizeQuality(q)
+	}
+	a.jobs[child.ID] = child
+	view := *child
+	a.mu.Unlock()
+	// The child reads the same file, so the parent's probe still holds.
+	if info, ok := a.media.get(parent.ID); ok {
+		a.media.set(view.ID, info)
+	}
+
+	a.logger.Info("re-extraction queued", "job_id", view.ID, "parent_id", parentID, "format", view.Format, "quality", view.Quality)
+	a.broadcast(view.ID, models.ProgressEvent{ID: view.ID, Stage: "extraction", Status: models.StatusQueued, Progress: 1, Message: "job em fila"})
+	go a.runExtraction(view.ID)
+	a.respondJobCreated(w, &view)
+}
+
+// copyExtractionSettings copies the options that shape an extraction, the
+// same set uploadOptions.apply fills in.
+func copyExtractionSettings(dst, src *models.ExtractionJob) {
+	dst.Format = src.Format
+	dst.Quality = src.Quality
+	dst.Channels = src.Channels
+	dst.SampleRate = src.SampleRate
+	dst.NoFaststart = src.NoFaststart
+	dst.TrimStart = src.TrimStart
+	dst.TrimEnd = src.TrimEnd
+	dst.SeekMode = src.SeekMode
+	dst.CopyTimestamps = src.CopyTimestamps
+	dst.InputFormat = src.InputFormat
+	dst.OutputDir = src.OutputDir
+	dst.SplitChannels = src.SplitChannels
+	dst.CallbackURL = src.CallbackURL
+	dst.GainDB = src.GainDB
+	dst.Normalize = src.Normalize
+	dst.PreserveMetadata = src.PreserveMetadata
+	dst.NameTemplate = src.NameTemplate
+	dst.AllTracks = src.AllTracks
+	dst.SurroundDownmix = src.SurroundDownmix
+	dst.TrackIndex = src.TrackIndex
+	dst.FFmpegThreads = src.FFmpegThreads
+	dst.TargetDuration = src.TargetDuration
+}
+
+// inputShared reports whether another live job uses path as its input, as
+// re-extraction children do, so cleanup keeps the file for it.
+func (a *App) inputShared(jobID, path string) bool {
+	if path == "" {
+		return false
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for id, job := range a.jobs {
+		if id != jobID && job.InputPath == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"extratorDeAudio/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Chunked uploads let a client on a flaky connection resume instead of
+// starting over: POST /upload/init announces the file, PATCH /upload/{id}
+// appends bytes at Upload-Offset (HEAD tells where to resume) and POST
+// /upload/{id}/commit finishes it like a regular upload. The job exists in
+// the uploading state from init on, so its WebSocket shows progress. Between
+// chunks the upload is pending (see trackPendingLocked): it doesn't count
+// toward the queue limit and fails once idle for uploadIdleTimeout. The
+// bytes still missing stay reserved when later uploads check free space.
+
+const uploadOffsetHeader = "Upload-Offset"
+
+// initChunkedUpload creates the job and an empty file for an upload of
+// `size` bytes named `filename`.
+func (a *App) initChunkedUpload(w http.ResponseWriter, r *http.Request) {
+	size, err := strconv.ParseInt(strings.TrimSpace(r.FormValue("size")), 10, 64)
+	if err != nil || size <= 0 {
+		http.Error(w, "informe o tamanho do arquivo em size", http.StatusBadRequest)
+		return
+	}
+	if size > a.maxUploadBytes {
+		http.Error(w, "arquivo excede o limite de 500MB", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(r.FormValue("filename")) == "" {
+		http.Error(w, "informe o nome do arquivo em filename", http.StatusBadRequest)
+		return
+	}
+	if a.queueFull() {
+		a.rejectQueueFull(w)
+		return
+	}
+	if err := os.MkdirAll(a.uploadsDir, 0o755); err != nil {
+		a.logger.Error("failed to ensure uploads dir", "error", err)
+		http.Error(w, "erro interno ao preparar upload", http.StatusInternalServerError)
+		return
+	}
+
+	safeName := sanitizeFileName(r.FormValue("filename"))
+	jobID, err := a.registerChunkedUpload(safeName, size)
+	if err != nil {
+		a.logger.Warn("chunked upload rejected, low disk space", "error", err)
+		http.Error(w, "espaço em disco insuficiente, tente novamente mais tarde", http.StatusInsufficientStorage)
+		return
+	}
+	job, _ := a.getJob(jobID)
+	f, err := os.Create(job.InputPath)
+	if err != nil {
+		a.logger.Error("failed to create upload file", "error", err)
+		a.abortUpload(jobID, "", false, "erro ao salvar upload")
+		http.Error(w, "erro ao salvar upload", http.StatusInternalServerError)
+		return
+	}
+	_ = f.Close()
+
+	a.logger.Info("chunked upload started", "job_id", jobID, "file", safeName, "size", size)
+	a.respondJSON(w, http.StatusCreated, map[string]any{
+		"id":         jobID,
+		"size":       size,
+		"offset":     0,
+		"upload_url": "/upload/" + jobID,
+		"commit_url": "/upload/" + jobID + "/commit",
+		"ws_url":     "/ws/" + jobID,
+	})
+}
+
+// chunkedUploadOffset answers HEAD /upload/{id} with the bytes received so
+// far, where the client should resume.
+func (a *App) chunkedUploadOffset(w http.ResponseWriter, r *http.Request) {
+	job, ok := a.getJob(chi.URLParam(r, "id"))
+	if !ok || job.UploadSize == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(uploadOffsetHeader, strconv.FormatInt(job.UploadOffset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(job.UploadSize, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// appendUploadChunk writes the request body at Upload-Offset, which must
+// match the bytes already received. Whatever arrives is kept even if the
+// connection drops, so the client resumes from the new offset.
+func (a *App) appendUploadChunk(w http.ResponseWriter, r *http.Request) {
+	a.extendUploadDeadlines(w)
+	jobID := chi.URLParam(r, "id")
+	offset, err := strconv.ParseInt(strings.TrimSpace(r.Header.Get(uploadOffsetHeader)), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "cabeçalho Upload-Offset inválido", http.StatusBadRequest)
+		return
+	}
+
+	job, status, message := a.claimUploadSession(jobID)
+	if status != 0 {
+		http.Error(w, message, status)
+		return
+	}
+	defer a.releaseUploadSession(jobID)
+	if offset != job.UploadOffset {
+		w.Header().Set(uploadOffsetHeader, strconv.FormatInt(job.UploadOffset, 10))
+		http.Error(w, "Upload-Offset não confere com o recebido até agora", http.StatusConflict)
+		return
+	}
+
+	f, err := os.OpenFile(job.InputPath, os.O_WRONLY, 0)
+	if err != nil {
+		a.logger.Error("failed to open upload file", "job_id", jobID, "error", err)
+		http.Error(w, "erro ao gravar arquivo", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	// Drop bytes past the offset left by an earlier failed write.
+	if err := f.Truncate(offset); err == nil {
+		_, err = f.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		a.logger.Error("failed to seek upload file", "job_id", jobID, "error", err)
+		http.Error(w, "erro ao gravar arquivo", http.StatusInternalServerError)
+		return
+	}
+
+	// The remaining announced size also caps the assembled file at
+	// MaxUploadBytes, which init checked.
+	body := http.MaxBytesReader(w, r.Body, job.UploadSize-offset)
+	progress := &uploadProgressWriter{app: a, jobID: jobID, total: job.UploadSize, written: offset}
+	progress.last = clampPercent(int(offset * 100 / job.UploadSize))
+	status, message = a.copyUpload(r.Context(), f, body, progress)
+
+	received := offset
+	if info, err := f.Stat(); err == nil {
+		received = info.Size()
+	}
+	a.updateJob(jobID, func(j *models.ExtractionJob) {
+		j.UploadOffset = received
+		j.UpdatedAt = time.Now()
+	})
+	w.Header().Set(uploadOffsetHeader, strconv.FormatInt(received, 10))
+	switch status {
+	case 0:
+		w.WriteHeader(http.StatusNoContent)
+	case statusClientClosedRequest:
+		a.logger.Info("upload chunk interrupted", "job_id", jobID, "offset", received)
+	default:
+		if status == http.StatusBadRequest {
+			message = "trecho ultrapassa o tamanho anunciado do arquivo"
+		}
+		http.Error(w, message, status)
+	}
+}
+
+// commitChunkedUpload finishes a complete chunked upload: the content is
+// checked like a regular upload and the form fields are the upload options.
+func (a *App) commitChunkedUpload(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+	// Sessions don't count toward the queue limit, so it applies here.
+	if a.queueFull() {
+		a.rejectQueueFull(w)
+		return
+	}
+	job, status, message := a.claimUploadSession(jobID)
+	if status != 0 {
+		http.Error(w, message, status)
+		return
+	}
+	defer a.releaseUploadSession(jobID)
+	if job.UploadOffset < job.UploadSize {
+		w.Header().Set(uploadOffsetHeader, strconv.FormatInt(job.UploadOffset, 10))
+		http.Error(w, "upload incompleto", http.StatusConflict)
+		return
+	}
+
+	head := make([]byte, sniffLen)
+	f, err := os.Open(job.InputPath)
+	if err != nil {
+		a.logger.Error("failed to open upload file", "job_id", jobID, "error", err)
+		http.Error(w, "erro ao ler upload", http.StatusInternalServerError)
+		return
+	}
+	n, _ := io.ReadFull(f, head)
+	_ = f.Close()
+	if !isMediaContainer(head[:n]) {
+		const message = "o arquivo não é um vídeo ou áudio reconhecido"
+		a.logger.Warn("upload rejected, unrecognized container", "job_id", jobID, "file", job.InputFileName, "content_type", http.DetectContentType(head[:n]))
+		a.abortUpload(jobID, job.InputPath, true, message)
+		http.Error(w, message, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Option errors leave the upload in place so the commit can be retried.
+	opts, fieldErrs := a.parseUploadOptions(r.FormValue)
+	if len(fieldErrs) > 0 {
+		http.Error(w, fieldErrs[0].Message, http.StatusBadRequest)
+		return
+	}
+	if opts.OutputDir != "" && !a.isAdminRequest(r) {
+		http.Error(w, "diretório de saída exige autenticação", http.StatusForbidden)
+		return
+	}
+	a.commitUpload(w, r, jobID, job.InputFileName, job.InputPath, opts, true)
+}
+
+// registerChunkedUpload adds the uploading job of a chunked upload of size
+// bytes. The free space check counts the bytes other chunked uploads still
+// have to send, and runs under the same lock that registers this one, so
+// concurrent inits can't promise the same space twice.
+func (a *App) registerChunkedUpload(safeName string, size int64) (string, error) {
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var reserved int64
+	for _, job := range a.jobs {
+		if job.Status == models.StatusUploading && job.UploadSize > 0 {
+			reserved += job.UploadSize - job.UploadOffset
+		}
+	}
+	if err := a.checkFreeSpace(size + reserved); err != nil {
+		return "", err
+	}
+
+	jobID := a.uniqueJobID()
+	a.jobs[jobID] = &models.ExtractionJob{
+		ID:               jobID,
+		InputFileName:    safeName,
+		InputPath:        filepath.Join(a.uploadsDir, jobID+"_"+safeName),
+		UploadSize:       size,
+		Status:           models.StatusUploading,
+		TranscriptStatus: models.StatusNotStarted,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	a.trackPendingLocked(jobID)
+	return jobID, nil
+}
+
+// claimUploadSession returns the chunked upload's job and marks it busy so
+// chunks and the commit don't run concurrently; it stops being pending until
+// released. A non-zero status explains why it can't be used.
+func (a *App) claimUploadSession(jobID string) (models.ExtractionJob, int, string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	job, ok := a.jobs[jobID]
+	if !ok || job.UploadSize == 0 {
+		return models.ExtractionJob{}, http.StatusNotFound, "upload não encontrado"
+	}
+	if job.Status != models.StatusUploading {
+		return models.ExtractionJob{}, http.StatusConflict, "upload já finalizado"
+	}
+	if a.uploadBusy[jobID] {
+		return models.ExtractionJob{}, http.StatusConflict, "outro trecho deste upload está em andamento"
+	}
+	a.uploadBusy[jobID] = true
+	a.untrackPendingLocked(jobID)
+	return *job, 0, ""
+}
+
+// releaseUploadSession ends a chunk or commit. An upload that is still
+// receiving chunks goes back to pending, restarting its idle timer.
+func (a *App) releaseUploadSession(jobID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.uploadBusy, jobID)
+	if job, ok := a.jobs[jobID]; ok && job.Status == models.StatusUploading {
+		a.trackPendingLocked(jobID)
+	}
+}
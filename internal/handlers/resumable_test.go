@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"extratorDeAudio/internal/models"
+)
+
+func newTestApp(t *testing.T, cfg Config, opts ...Option) *App {
+	t.Helper()
+	if cfg.UploadsDir == "" {
+		cfg.UploadsDir = t.TempDir()
+	}
+	if cfg.OutputsDir == "" {
+		cfg.OutputsDir = t.TempDir()
+	}
+	if cfg.MinFreeDiskBytes == 0 {
+		cfg.MinFreeDiskBytes = -1
+	}
+	return NewApp(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg, opts...)
+}
+
+func decodeJSON(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+}
+
+// mp4Bytes returns size bytes that pass the container sniffing as MP4.
+func mp4Bytes(size int) []byte {
+	data := bytes.Repeat([]byte{0x42}, size)
+	copy(data, "\x00\x00\x00\x18ftypisom")
+	return data
+}
+
+func initUpload(t *testing.T, h http.Handler, size int) (int, string) {
+	t.Helper()
+	form := url.Values{"size": {strconv.Itoa(size)}, "filename": {"video.mp4"}}
+	req := httptest.NewRequest(http.MethodPost, "/upload/init", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		return rec.Code, ""
+	}
+	var body struct {
+		ID string `json:"id"`
+	}
+	decodeJSON(t, rec, &body)
+	return rec.Code, body.ID
+}
+
+func patchChunk(h http.Handler, id string, offset int, chunk []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, "/upload/"+id, bytes.NewReader(chunk))
+	req.Header.Set(uploadOffsetHeader, strconv.Itoa(offset))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func commitChunks(h http.Handler, id string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/upload/"+id+"/commit", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestChunkedUploadRoundTrip(t *testing.T) {
+	app := newTestApp(t, Config{})
+	h := app.Router()
+	data := mp4Bytes(4096)
+
+	code, id := initUpload(t, h, len(data))
+	if code != http.StatusCreated {
+		t.Fatalf("init: status %d", code)
+	}
+
+	if rec := patchChunk(h, id, 0, data[:1000]); rec.Code != http.StatusNoContent {
+		t.Fatalf("first chunk: status %d: %s", rec.Code, rec.Body)
+	}
+	rec := patchChunk(h, id, 0, data[:1000])
+	if rec.Code != http.StatusConflict || rec.Header().Get(uploadOffsetHeader) != "1000" {
+		t.Fatalf("stale offset: status %d, offset %q", rec.Code, rec.Header().Get(uploadOffsetHeader))
+	}
+	if rec := commitChunks(h, id); rec.Code != http.StatusConflict {
+		t.Fatalf("incomplete commit: status %d", rec.Code)
+	}
+
+	head := httptest.NewRecorder()
+	h.ServeHTTP(head, httptest.NewRequest(http.MethodHead, "/upload/"+id, nil))
+	if got := head.Header().Get(uploadOffsetHeader); got != "1000" {
+		t.Fatalf("HEAD offset = %q, want 1000", got)
+	}
+
+	if rec := patchChunk(h, id, 1000, data[1000:]); rec.Code != http.StatusNoContent {
+		t.Fatalf("last chunk: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := patchChunk(h, id, len(data), []byte("x")); rec.Code != http.StatusBadRequest {
+		t.Fatalf("chunk past the size: status %d", rec.Code)
+	}
+	if rec := commitChunks(h, id); rec.Code != http.StatusCreated {
+		t.Fatalf("commit: status %d: %s", rec.Code, rec.Body)
+	}
+
+	job, _ := app.getJob(id)
+	if job.Status != models.StatusUploaded {
+		t.Fatalf("status = %s, want uploaded", job.Status)
+	}
+	got, err := os.ReadFile(job.InputPath)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("assembled file differs from the upload (err %v)", err)
+	}
+	if rec := patchChunk(h, id, len(data), nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("chunk after commit: status %d", rec.Code)
+	}
+}
+
+func TestChunkedUploadRejectsUnknownContainer(t *testing.T) {
+	app := newTestApp(t, Config{})
+	h := app.Router()
+	data := bytes.Repeat([]byte("not a video "), 100)
+
+	_, id := initUpload(t, h, len(data))
+	patchChunk(h, id, 0, data)
+	if rec := commitChunks(h, id); rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("commit: status %d", rec.Code)
+	}
+	job, _ := app.getJob(id)
+	if job.Status != models.StatusFailed {
+		t.Fatalf("status = %s, want failed", job.Status)
+	}
+	if _, err := os.Stat(job.InputPath); !os.IsNotExist(err) {
+		t.Fatalf("rejected upload left on disk")
+	}
+}
+
+func TestChunkedUploadSessionsDontFillQueue(t *testing.T) {
+	app := newTestApp(t, Config{MaxQueueDepth: 1})
+	h := app.Router()
+	for i := 0; i < 3; i++ {
+		if code, _ := initUpload(t, h, 100); code != http.StatusCreated {
+			t.Fatalf("init %d: status %d", i, code)
+		}
+	}
+}
+
+func TestChunkedUploadExpiresWhenIdle(t *testing.T) {
+	app := newTestApp(t, Config{})
+	h := app.Router()
+	data := mp4Bytes(100)
+	_, id := initUpload(t, h, len(data))
+	patchChunk(h, id, 0, data[:10])
+
+	app.expirePending(id)
+
+	job, _ := app.getJob(id)
+	if job.Status != models.StatusFailed {
+		t.Fatalf("status = %s, want failed", job.Status)
+	}
+	if _, err := os.Stat(job.InputPath); !os.IsNotExist(err) {
+		t.Fatalf("expired upload left on disk")
+	}
+	if rec := patchChunk(h, id, 10, data[10:]); rec.Code != http.StatusConflict {
+		t.Fatalf("chunk after expiry: status %d", rec.Code)
+	}
+}
+
+func TestChunkedUploadReservesDiskSpace(t *testing.T) {
+	uploads := t.TempDir()
+	free, err := freeDiskBytes(uploads)
+	if err != nil {
+		t.Skipf("free space unavailable: %v", err)
+	}
+	// Each init fits on its own; two don't.
+	size := int(free / 5)
+	app := newTestApp(t, Config{UploadsDir: uploads, OutputsDir: uploads, MinFreeDiskBytes: 1, UploadSpaceFactor: 3, MaxUploadBytes: int64(free)})
+	h := app.Router()
+
+	if code, _ := initUpload(t, h, size); code != http.StatusCreated {
+		t.Fatalf("first init: status %d", code)
+	}
+	if code, _ := initUpload(t, h, size); code != http.StatusInsufficientStorage {
+		t.Fatalf("second init: status %d, want 507", code)
+	}
+}
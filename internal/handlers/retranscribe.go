@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+	"extratorDeAudio/internal/transcript"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxRetranscribeSeconds bounds the window of a partial re-transcription.
+const maxRetranscribeSeconds = 30 * 60
+
+// retranscribeRange re-runs whisper on [start, end) of a finished transcript
+// with the settings from the request and splices the new cues into the
+// existing SRT/TXT, e.g. to fix a noisy section without redoing the rest.
+func (a *App) retranscribeRange(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+
+	start, err := parseClipTime(r.FormValue("start"))
+	if err != nil {
+		http.Error(w, "início do trecho inválido", http.StatusBadRequest)
+		return
+	}
+	end, err := parseClipTime(r.FormValue("end"))
+	if err != nil {
+		http.Error(w, "fim do trecho inválido", http.StatusBadRequest)
+		return
+	}
+	if end <= start {
+		http.Error(w, "fim do trecho deve ser maior que o início", http.StatusBadRequest)
+		return
+	}
+	if end-start > maxRetranscribeSeconds {
+		http.Error(w, "trecho excede o limite de 30 minutos", http.StatusBadRequest)
+		return
+	}
+
+	model := strings.TrimSpace(r.FormValue("model"))
+	if model != "" {
+		if _, ok := a.cfg.WhisperModels[model]; !ok {
+			http.Error(w, "modelo whisper desconhecido", http.StatusBadRequest)
+			return
+		}
+	}
+	language, ok := sanitizeLanguage(r.FormValue("language"))
+	if !ok {
+		http.Error(w, "idioma não suportado", http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	job, ok := a.jobs[jobID]
+	if !ok {
+		a.mu.Unlock()
+		http.Error(w, "job não encontrado", http.StatusNotFound)
+		return
+	}
+	if job.ArchivedAt != nil {
+		a.mu.Unlock()
+		http.Error(w, "arquivado", http.StatusGone)
+		return
+	}
+	if job.TranscriptStatus != models.StatusCompleted || job.TranscriptSRTPath == "" {
+		a.mu.Unlock()
+		http.Error(w, "transcrição ainda não está pronta", http.StatusConflict)
+		return
+	}
+	if a.queueFullLocked() {
+		a.mu.Unlock()
+		a.rejectQueueFull(w)
+		return
+	}
+	if language == "" {
+		// Short windows auto-detect poorly; reuse the language the full
+		// transcript was made with.
+		language = job.TranscriptLanguage
+	}
+	if model == "" {
+		// Keep the window consistent with the rest of the transcript.
+		model = job.Model
+	}
+	job.TranscriptStatus = models.StatusQueued
+	job.TranscriptProgress = 1
+	job.UpdatedAt = time.Now()
+	a.mu.Unlock()
+
+	opts := extractor.TranscribeOptions{
+		Model:          a.cfg.WhisperModels[model],
+		Language:       language,
+		Translate:      parseBool(r.FormValue("translate")),
+		Prompt:         sanitizePrompt(r.FormValue("prompt")),
+		WordTimestamps: job.TranscriptWordsPath != "",
+	}
+
+	a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "transcription", Status: models.StatusQueued, Progress: 1, Message: "retranscrição do trecho em fila"})
+	go a.runRangeTranscription(jobID, start, end, opts)
+
+	a.respondJSON(w, http.StatusAccepted, map[string]string{"status": "retranscription_started", "job_id": jobID})
+}
+
+// runRangeTranscription goes through the heavy job window, the
+// transcription pool and run registration like runTranscription, so it
+// counts against the same limits and POST /cancel stops it.
+func (a *App) runRangeTranscription(jobID string, start, end float64, opts extractor.TranscribeOptions) {
+	gen := a.beginRun(jobID, "transcription")
+	if !a.waitForHeavyWindow(jobID, "transcription", gen) {
+		a.keepTranscript(jobID, gen, "retranscrição do trecho cancelada", "")
+		return
+	}
+	if !a.transcribePool.acquire(jobID) {
+		a.keepTranscript(jobID, gen, "retranscrição do trecho cancelada", "")
+		return
+	}
+	defer a.transcribePool.release()
+	a.leaveQueue(jobID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+	if !a.startRun(jobID, "transcription", gen, cancel) {
+		a.keepTranscript(jobID, gen, "retranscrição do trecho cancelada", "")
+		return
+	}
+	defer a.endRun(jobID)
+	a.updateJob(jobID, func(j *models.ExtractionJob) {
+		j.TranscriptStatus = models.StatusProcessing
+		j.UpdatedAt = time.Now()
+	})
+	a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "transcription", Status: models.StatusProcessing, Progress: 1, Message: "retranscrevendo trecho"})
+
+	if err := a.spliceRange(ctx, jobID, start, end, opts); err != nil {
+		if a.stageCanceled(jobID, "transcription") {
+			a.keepTranscript(jobID, gen, "retranscrição do trecho cancelada", "")
+			return
+		}
+		a.logger.Error("range retranscription failed", "job_id", jobID, "error", err)
+		a.keepTranscript(jobID, gen, "falha ao retranscrever trecho", err.Error())
+		return
+	}
+
+	a.updateJob(jobID, func(j *models.ExtractionJob) {
+		j.TranscriptStatus = models.StatusCompleted
+		j.TranscriptProgress = 100
+		j.UpdatedAt = time.Now()
+	})
+	job, _ := a.getJob(jobID)
+	a.broadcast(jobID, models.ProgressEvent{
+		ID:                 jobID,
+		Stage:              "transcription",
+		Status:             models.StatusCompleted,
+		Progress:           100,
+		Message:            "trecho retranscrito",
+		TranscriptTXTURL:   transcriptTXTURLForJob(job),
+		TranscriptSRTURL:   transcriptSRTURLForJob(job),
+		TranscriptVTTURL:   transcriptVTTURLForJob(job),
+		TranscriptWordsURL: transcriptWordsURLForJob(job),
+	})
+	a.notifyWebhook(jobID, "transcription.completed")
+	a.logger.Info("range retranscription completed", "job_id", jobID, "start", start, "end", end)
+}
+
+// keepTranscript ends a range re-transcription that didn't splice anything:
+// the previous transcript is untouched, so the job goes back to completed.
+// A run superseded by a newer one leaves the status to it.
+func (a *App) keepTranscript(jobID string, gen uint64, message, errMessage string) {
+	a.mu.Lock()
+	job, ok := a.jobs[jobID]
+	if !ok || (!a.currentRunLocked(jobID, "transcription", gen) && job.TranscriptStatus != models.StatusCanceled) {
+		a.mu.Unlock()
+		return
+	}
+	job.TranscriptStatus = models.StatusCompleted
+	job.TranscriptProgress = 100
+	job.ScheduledAt = nil
+	job.QueuePosition = 0
+	job.UpdatedAt = time.Now()
+	a.mu.Unlock()
+	a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "transcription", Status: models.StatusCompleted, Progress: 100, Error: errMessage, Message: message})
+}
+
+// spliceRange transcribes the window and merges it into the job's files.
+func (a *App) spliceRange(ctx context.Context, jobID string, start, end float64, opts extractor.TranscribeOptions) error {
+	job, ok := a.getJob(jobID)
+	if !ok {
+		return fmt.Errorf("job não encontrado")
+	}
+
+	audioPath, cleanupAudio, err := a.localAudio(ctx, job)
+	if err != nil {
+		return fmt.Errorf("falha ao obter áudio: %w", err)
+	}
+	defer cleanupAudio()
+
+	base := filepath.Join(a.jobOutputDir(job), job.ID+"_range")
+	defer func() {
+		for _, path := range []string{base + ".wav", base + ".txt", base + ".srt", base + ".vtt", extractor.WordsPath(base)} {
+			_ = os.Remove(path)
+		}
+	}()
+
+	if err := a.extractor.ExtractClip(ctx, audioPath, base+".wav", start, end-start); err != nil {
+		return err
+	}
+	err = a.extractor.TranscribeAudio(ctx, base+".wav", base, opts, func(percent int, status, message string) {
+		if status != "processing" {
+			return
+		}
+		a.updateJob(jobID, func(j *models.ExtractionJob) { j.TranscriptProgress = clampPercent(percent) })
+		a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "transcription", Status: models.StatusProcessing, Progress: percent, Message: "retranscrevendo trecho"})
+	})
+	if err != nil {
+		return err
+	}
+
+	offset := time.Duration(start * float64(time.Second))
+	windowEnd := time.Duration(end * float64(time.Second))
+
+	replacement, err := readSRTFile(base + ".srt")
+	if err != nil {
+		return err
+	}
+	segments, err := readSRTFile(job.TranscriptSRTPath)
+	if err != nil {
+		return err
+	}
+	segments = transcript.Splice(segments, transcript.Shift(replacement, offset), offset, windowEnd)
+
+	if err := writeSRTFile(job.TranscriptSRTPath, segments); err != nil {
+		return err
+	}
+	if job.TranscriptVTTPath != "" {
+		if err := writeFileAtomic(job.TranscriptVTTPath, func(f *os.File) error { return transcript.WriteVTT(f, segments) }); err != nil {
+			return err
+		}
+	}
+	if err := a.rewriteTranscriptTXT(ctx, job, segments); err != nil {
+		return err
+	}
+
+	if job.TranscriptWordsPath != "" {
+		if err := spliceWords(job.TranscriptWordsPath, extractor.WordsPath(base), offset, windowEnd); err != nil {
+			a.logger.Warn("range retranscription produced no word timestamps", "job_id", jobID, "error", err)
+		}
+	}
+	return a.limitSplicedTranscript(job)
+}
+
+// spliceWords replaces the word timestamps inside the window with those of
+// the range transcription at rangePath.
+func spliceWords(path, rangePath string, offset, windowEnd time.Duration) error {
+	newWords, err := readWordsJSON(rangePath)
+	if err != nil {
+		return err
+	}
+	words, err := readWordsJSON(path)
+	if err != nil {
+		return err
+	}
+	words = transcript.SpliceWords(words, transcript.ShiftWords(newWords, offset), offset, windowEnd)
+	return writeFileAtomic(path, func(f *os.File) error { return transcript.WriteWords(f, words) })
+}
+
+// limitSplicedTranscript applies MaxTranscriptBytes to the merged files, as
+// the full transcription does, since the window may have grown them.
+func (a *App) limitSplicedTranscript(job *models.ExtractionJob) error {
+	base := strings.TrimSuffix(job.TranscriptTXTPath, ".txt")
+	truncated, err := a.limitTranscriptSize(job.ID, base)
+	if err != nil || !truncated {
+		return err
+	}
+	a.updateJob(job.ID, func(j *models.ExtractionJob) {
+		j.TranscriptTruncated = true
+		if j.TranscriptWordsPath != "" && fileSize(j.TranscriptWordsPath) < 0 {
+			j.TranscriptWordsPath = ""
+		}
+	})
+	return nil
+}
+
+// rewriteTranscriptTXT regenerates the TXT from the spliced cues, one line
+// per cue, reapplying the job's normalization and header.
+func (a *App) rewriteTranscriptTXT(ctx context.Context, job *models.ExtractionJob, segments []transcript.Segment) error {
+	var b strings.Builder
+	for _, seg := range segments {
+		if text := strings.TrimSpace(seg.Text); text != "" {
+			b.WriteString(text)
+			b.WriteString("\n")
+		}
+	}
+	if err := writeFileAtomic(job.TranscriptTXTPath, func(f *os.File) error {
+		_, err := f.WriteString(b.String())
+		return err
+	}); err != nil {
+		return err
+	}
+	if job.NormalizeText != "" {
+		if err := transcript.NormalizeFile(job.TranscriptTXTPath, job.NormalizeText); err != nil {
+			return err
+		}
+	}
+	if job.TranscriptHeader {
+		return transcript.PrependHeader(job.TranscriptTXTPath, a.transcriptHeader(ctx, job))
+	}
+	return nil
+}
+
+func readSRTFile(path string) ([]transcript.Segment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return transcript.ParseSRT(f)
+}
+
+func writeSRTFile(path string, segments []transcript.Segment) error {
+	return writeFileAtomic(path, func(f *os.File) error { return transcript.WriteSRT(f, segments) })
+}
+
+func readWordsJSON(path string) ([]transcript.Word, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return transcript.ReadWords(f)
+}
+
+// writeFileAtomic writes through a temporary file so readers never see a
+// half-written transcript.
+func writeFileAtomic(path string, write func(*os.File) error) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
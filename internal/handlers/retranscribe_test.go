@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"extratorDeAudio/internal/models"
+)
+
+func TestSplicedTranscriptHonoursSizeLimit(t *testing.T) {
+	a := newTestApp(t, Config{MaxTranscriptBytes: 64})
+	base := filepath.Join(a.transcriptsDir, "job_transcript")
+	job := &models.ExtractionJob{
+		ID:                  "job",
+		TranscriptTXTPath:   base + ".txt",
+		TranscriptSRTPath:   base + ".srt",
+		TranscriptWordsPath: base + ".words.json",
+	}
+	files := map[string]string{
+		job.TranscriptTXTPath:   strings.Repeat("fala repetida\n", 20),
+		job.TranscriptSRTPath:   "1\n00:00:00,000 --> 00:00:01,000\ncurta\n\n",
+		job.TranscriptWordsPath: "[" + strings.Repeat(`{"start":0,"end":1,"word":"x"},`, 10) + `{"start":0,"end":1,"word":"x"}]`,
+	}
+	for path, data := range files {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.mu.Lock()
+	a.jobs[job.ID] = job
+	a.mu.Unlock()
+
+	if err := a.limitSplicedTranscript(job); err != nil {
+		t.Fatal(err)
+	}
+	if txt, _ := os.ReadFile(job.TranscriptTXTPath); !strings.Contains(string(txt), "transcrição truncada") {
+		t.Errorf("TXT not truncated: %q", txt)
+	}
+	got, _ := a.getJob(job.ID)
+	if !got.TranscriptTruncated {
+		t.Error("job not flagged as truncated")
+	}
+	if got.TranscriptWordsPath != "" {
+		t.Errorf("dropped words JSON still referenced: %q", got.TranscriptWordsPath)
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// sampleSeconds is the length of each quality comparison sample.
+	sampleSeconds = 15.0
+	// sampleTTL is how long samples are kept; they only serve a quick A/B
+	// listen, so they are dropped aggressively.
+	sampleTTL = 10 * time.Minute
+)
+
+// sampleQualities are the presets compared by createSamples.
+var sampleQualities = []string{"low", "medium", "high"}
+
+func (a *App) samplesDir(jobID string) string {
+	return filepath.Join(a.outputsDir, "samples", jobID)
+}
+
+// sampleSetDir holds one createSamples request's files. Each request gets
+// its own set, so an earlier request's expiry never deletes newer samples.
+func (a *App) sampleSetDir(jobID, set string) string {
+	return filepath.Join(a.samplesDir(jobID), set)
+}
+
+// sampleSetRe matches the set IDs createSamples hands out (newID).
+var sampleSetRe = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// sampleFormat is the format samples are encoded in: the job's format,
+// or AAC for HLS jobs so each sample is a single playable file.
+func sampleFormat(job *models.ExtractionJob) string {
+	if job.Format == extractor.FormatHLS {
+		return "aac"
+	}
+	return job.Format
+}
+
+// createSamples encodes a short window of the input at each quality preset so
+// users can compare them before extracting. The window starts at `start`
+// (default: the job's trim start).
+func (a *App) createSamples(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+	job, ok := a.getJob(jobID)
+	if !ok {
+		http.Error(w, "job não encontrado", http.StatusNotFound)
+		return
+	}
+	if job.ArchivedAt != nil || job.InputPath == "" {
+		http.Error(w, "arquivo de entrada indisponível", http.StatusGone)
+		return
+	}
+
+	start := job.TrimStart
+	if v := r.FormValue("start"); v != "" {
+		var err error
+		if start, err = parseClipTime(v); err != nil {
+			http.Error(w, "início da amostra inválido", http.StatusBadRequest)
+			return
+		}
+	}
+	if a.queueFull() {
+		a.rejectQueueFull(w)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	// All three encodes hold one extraction slot; the request gives up if
+	// the pool doesn't free one before the timeout.
+	set := newID()
+	if !a.extractPool.acquireTask(ctx, jobID+"/samples/"+set) {
+		http.Error(w, "servidor ocupado, tente novamente", http.StatusServiceUnavailable)
+		return
+	}
+	defer a.extractPool.release()
+
+	dir := a.sampleSetDir(jobID, set)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		a.logger.Error("failed to create samples dir", "error", err)
+		http.Error(w, "erro ao gerar amostras", http.StatusInternalServerError)
+		return
+	}
+	time.AfterFunc(sampleTTL, func() { _ = os.RemoveAll(dir) })
+
+	format := sampleFormat(job)
+	samples := make(map[string]string, len(sampleQualities))
+	for _, quality := range sampleQualities {
+		opts := extractor.ExtractOptions{
+			Format:          format,
+			Quality:         quality,
+			Start:           start,
+			End:             start + sampleSeconds,
+			SeekMode:        job.SeekMode,
+			InputFormat:     job.InputFormat,
+			GainDB:          job.GainDB,
+			Normalize:       job.Normalize,
+			SurroundDownmix: job.SurroundDownmix,
+			RobustInput:     a.cfg.RobustInput,
+			Channels:        job.Channels,
+			SampleRate:      job.SampleRate,
+			Threads:         a.ffmpegThreads(job),
+		}
+		if job.TrackIndex != nil {
+			opts.SingleTrack = true
+			opts.Track = *job.TrackIndex
+		}
+		path := filepath.Join(dir, "sample_"+quality+"."+format)
+		if err := a.extractor.ExtractAudio(ctx, job.InputPath, path, opts, nil); err != nil {
+			a.logger.Warn("sample extraction failed", "job_id", jobID, "quality", quality, "error", err)
+			_ = os.RemoveAll(dir)
+			http.Error(w, "erro ao gerar amostras", http.StatusUnprocessableEntity)
+			return
+		}
+		samples[quality] = "/samples/" + jobID + "/" + quality + "?set=" + set
+	}
+
+	a.respondJSON(w, http.StatusOK, map[string]any{
+		"id":         jobID,
+		"format":     format,
+		"start":      start,
+		"duration":   sampleSeconds,
+		"samples":    samples,
+		"expires_at": time.Now().Add(sampleTTL).Format(time.RFC3339),
+	})
+}
+
+// serveSample streams one quality sample inline for an audio element.
+func (a *App) serveSample(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+	quality := chi.URLParam(r, "quality")
+	set := r.URL.Query().Get("set")
+	job, ok := a.getJob(jobID)
+	if !ok || sanitizeQuality(quality) != quality || quality == "original" || !sampleSetRe.MatchString(set) {
+		http.NotFound(w, r)
+		return
+	}
+
+	path := filepath.Join(a.sampleSetDir(jobID, set), "sample_"+quality+"."+sampleFormat(job))
+	if _, err := os.Stat(path); err != nil {
+		http.Error(w, "amostra expirada ou inexistente", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeFile(w, r, path)
+}
+
+// removeSamples deletes a job's comparison samples, if any.
+func (a *App) removeSamples(jobID string) {
+	if strings.TrimSpace(jobID) == "" {
+		return
+	}
+	_ = os.RemoveAll(a.samplesDir(jobID))
+}
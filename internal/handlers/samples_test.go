@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"extratorDeAudio/internal/models"
+)
+
+func TestServeSampleReadsItsOwnSet(t *testing.T) {
+	app := newTestApp(t, Config{})
+	h := app.Router()
+	app.mu.Lock()
+	app.jobs["job1"] = &models.ExtractionJob{ID: "job1", Format: "mp3"}
+	app.mu.Unlock()
+
+	older, newer := newID(), newID()
+	for set, data := range map[string]string{older: "old", newer: "new"} {
+		dir := app.sampleSetDir("job1", set)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "sample_low.mp3"), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The older request's expiry must leave the newer samples alone.
+	if err := os.RemoveAll(app.sampleSetDir("job1", older)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url      string
+		wantCode int
+		wantBody string
+	}{
+		{"/samples/job1/low?set=" + newer, http.StatusOK, "new"},
+		{"/samples/job1/low?set=" + older, http.StatusNotFound, ""},
+		{"/samples/job1/low", http.StatusNotFound, ""},
+		{"/samples/job1/low?set=../../x", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+		if rec.Code != tt.wantCode {
+			t.Errorf("GET %s = %d, want %d", tt.url, rec.Code, tt.wantCode)
+		}
+		if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+			t.Errorf("GET %s body = %q, want %q", tt.url, rec.Body.String(), tt.wantBody)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"extratorDeAudio/internal/models"
+)
+
+// TimeWindow is a daily window in server local time, such as 22:00-06:00.
+// It may wrap past midnight. The zero value is disabled: always open.
+type TimeWindow struct {
+	start, end time.Duration
+	enabled    bool
+}
+
+// ParseTimeWindow parses "HH:MM-HH:MM". An empty value disables the window.
+func ParseTimeWindow(v string) (TimeWindow, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return TimeWindow{}, nil
+	}
+	from, to, ok := strings.Cut(v, "-")
+	if !ok {
+		return TimeWindow{}, fmt.Errorf("invalid window %q, want HH:MM-HH:MM", v)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return TimeWindow{}, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return TimeWindow{}, err
+	}
+	if start == end {
+		return TimeWindow{}, fmt.Errorf("invalid window %q: start equals end", v)
+	}
+	return TimeWindow{start: start, end: end, enabled: true}, nil
+}
+
+func parseClock(v string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(v))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", v)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Enabled reports whether the window restricts anything.
+func (w TimeWindow) Enabled() bool {
+	return w.enabled
+}
+
+// Contains reports whether t falls inside the window.
+func (w TimeWindow) Contains(t time.Time) bool {
+	if !w.enabled {
+		return true
+	}
+	offset := sinceMidnight(t)
+	if w.start < w.end {
+		return offset >= w.start && offset < w.end
+	}
+	return offset >= w.start || offset < w.end
+}
+
+// NextOpen returns when the window next opens at or after t.
+func (w TimeWindow) NextOpen(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	open := midnight.Add(w.start)
+	if !open.After(t) {
+		open = midnight.AddDate(0, 0, 1).Add(w.start)
+	}
+	return open
+}
+
+// String renders the window as HH:MM-HH:MM.
+func (w TimeWindow) String() string {
+	if !w.enabled {
+		return ""
+	}
+	return formatClock(w.start) + "-" + formatClock(w.end)
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// waitForHeavyWindow defers a CPU-heavy stage until HeavyJobWindow opens.
+// While waiting the stage reports StatusScheduled with the planned start.
+// It returns false when the job disappeared, or the run gen was canceled or
+// superseded, in the meantime; canceling wakes the wait right away.
+func (a *App) waitForHeavyWindow(jobID, stage string, gen uint64) bool {
+	window := a.cfg.HeavyJobWindow
+	now := time.Now()
+	if window.Contains(now) {
+		return true
+	}
+
+	openAt := window.NextOpen(now)
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	if !a.setStageScheduled(jobID, stage, gen, &openAt, stop) {
+		return false
+	}
+	a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: stage, Status: models.StatusScheduled, Message: "agendado para " + openAt.Format("02/01 15:04")})
+	a.logger.Info("heavy job deferred to window", "job_id", jobID, "stage", stage, "window", window.String(), "scheduled_at", openAt)
+
+	timer := time.NewTimer(time.Until(openAt))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return false
+	}
+	return a.setStageScheduled(jobID, stage, gen, nil, nil)
+}
+
+// setStageScheduled marks the stage as scheduled at the given time, with
+// stop to wake the wait, or back to queued when at is nil. It reports
+// whether the job still exists and gen is still its latest run.
+func (a *App) setStageScheduled(jobID, stage string, gen uint64, at *time.Time, stop context.CancelFunc) bool {
+	status := models.StatusScheduled
+	if at == nil {
+		status = models.StatusQueued
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	j, ok := a.jobs[jobID]
+	if !ok || stageStatus(j, stage) == models.StatusCanceled || !a.currentRunLocked(jobID, stage, gen) {
+		return false
+	}
+	key := runKey(jobID, stage)
+	if at == nil {
+		delete(a.waiting, key)
+	} else {
+		a.waiting[key] = heavyWait{gen: gen, stop: stop}
+	}
+	if stage == "transcription" {
+		j.TranscriptStatus = status
+	} else {
+		j.Status = status
+	}
+	j.ScheduledAt = at
+	j.UpdatedAt = time.Now()
+	return true
+}
+
+// heavyExtraction reports whether the job's extraction is long enough to be
+// held for the heavy job window (HeavyExtractMinSeconds, 0 disables).
+func (a *App) heavyExtraction(job *models.ExtractionJob) bool {
+	if !a.cfg.HeavyJobWindow.Enabled() || a.cfg.HeavyExtractMinSeconds <= 0 {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	total, err := a.sourceDuration(ctx, job)
+	if err != nil {
+		a.logger.Warn("could not probe duration for heavy job window", "job_id", job.ID, "error", err)
+		return false
+	}
+	length := total
+	if job.TrimEnd > job.TrimStart {
+		length = job.TrimEnd - job.TrimStart
+	} else if job.TrimStart > 0 {
+		length = total - job.TrimStart
+	}
+	return length >= a.cfg.HeavyExtractMinSeconds
+}
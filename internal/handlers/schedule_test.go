@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"extratorDeAudio/internal/models"
+)
+
+// closedWindow returns a heavy job window that opens in about an hour.
+func closedWindow(t *testing.T) TimeWindow {
+	t.Helper()
+	now := time.Now()
+	w, err := ParseTimeWindow(now.Add(time.Hour).Format("15:04") + "-" + now.Add(2*time.Hour).Format("15:04"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func waitAsync(a *App, jobID string, gen uint64) <-chan bool {
+	done := make(chan bool, 1)
+	go func() { done <- a.waitForHeavyWindow(jobID, "transcription", gen) }()
+	return done
+}
+
+func addTranscriptionJob(a *App, jobID string) {
+	a.mu.Lock()
+	a.jobs[jobID] = &models.ExtractionJob{ID: jobID, Status: models.StatusCompleted, TranscriptStatus: models.StatusQueued}
+	a.mu.Unlock()
+}
+
+func waitScheduled(t *testing.T, a *App, jobID string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		job, _ := a.getJob(jobID)
+		if job.TranscriptStatus == models.StatusScheduled {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stage was never scheduled")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWaitForHeavyWindowWakesOnCancel(t *testing.T) {
+	a := newTestApp(t, Config{HeavyJobWindow: closedWindow(t)})
+	addTranscriptionJob(a, "job1")
+	gen := a.beginRun("job1", "transcription")
+	done := waitAsync(a, "job1", gen)
+	waitScheduled(t, a, "job1")
+
+	a.mu.Lock()
+	a.invalidateRunLocked("job1", "transcription")
+	a.mu.Unlock()
+
+	select {
+	case ok := <-done:
+		if ok {
+			t.Fatal("canceled wait reported the window open")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("canceled wait kept sleeping")
+	}
+}
+
+func TestWaitForHeavyWindowStaleRun(t *testing.T) {
+	a := newTestApp(t, Config{HeavyJobWindow: closedWindow(t)})
+	addTranscriptionJob(a, "job1")
+	first := a.beginRun("job1", "transcription")
+	done := waitAsync(a, "job1", first)
+	waitScheduled(t, a, "job1")
+
+	// A restart supersedes the waiting run, which must not go on to run.
+	second := a.beginRun("job1", "transcription")
+	select {
+	case ok := <-done:
+		if ok {
+			t.Fatal("superseded wait reported the window open")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("superseded wait kept sleeping")
+	}
+	if a.startRun("job1", "transcription", first, func() {}) {
+		t.Error("stale run was allowed to start")
+	}
+	if !a.startRun("job1", "transcription", second, func() {}) {
+		t.Error("current run was refused")
+	}
+}
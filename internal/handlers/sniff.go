@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// sniffLen is how much of an upload is inspected, the same window
+// http.DetectContentType looks at.
+const sniffLen = 512
+
+// containerSignature is a magic number at a fixed offset.
+type containerSignature struct {
+	offset int
+	magic  []byte
+}
+
+// containerSignatures covers media that http.DetectContentType doesn't
+// recognize: Matroska (sniffed as WebM), QuickTime/3GP brands, MPEG
+// program/transport streams, FLV, ASF/WMV and raw audio streams.
+var containerSignatures = []containerSignature{
+	{0, []byte{0x1A, 0x45, 0xDF, 0xA3}}, // EBML: Matroska, WebM
+	{4, []byte("ftyp")},                 // ISO BMFF: MP4, MOV, M4A, 3GP
+	{4, []byte("moov")},                 // old QuickTime
+	{4, []byte("mdat")},
+	{4, []byte("wide")},
+	{4, []byte("free")},
+	{0, []byte{0x00, 0x00, 0x01, 0xBA}}, // MPEG program stream
+	{0, []byte("FLV")},
+	{0, []byte{0x30, 0x26, 0xB2, 0x75, 0x8E, 0x66, 0xCF, 0x11}}, // ASF: WMV, WMA
+	{0, []byte("fLaC")},
+	{0, []byte("#!AMR")},
+	{0, []byte("caff")},
+	{0, []byte("RF64")},
+}
+
+// isMediaContainer reports whether head, the first bytes of an upload,
+// looks like an audio or video file ffmpeg can read.
+func isMediaContainer(head []byte) bool {
+	ctype := http.DetectContentType(head)
+	if strings.HasPrefix(ctype, "audio/") || strings.HasPrefix(ctype, "video/") || ctype == "application/ogg" {
+		return true
+	}
+	for _, sig := range containerSignatures {
+		if len(head) >= sig.offset+len(sig.magic) && bytes.Equal(head[sig.offset:sig.offset+len(sig.magic)], sig.magic) {
+			return true
+		}
+	}
+	return isMPEGTransportStream(head) || isAudioFrameSync(head)
+}
+
+// isMPEGTransportStream checks the 0x47 sync byte of the first packets.
+func isMPEGTransportStream(head []byte) bool {
+	const packet = 188
+	if len(head) < 2*packet+1 {
+		return false
+	}
+	return head[0] == 0x47 && head[packet] == 0x47 && head[2*packet] == 0x47
+}
+
+// isAudioFrameSync detects bare MP3 or ADTS AAC streams without an ID3 tag.
+func isAudioFrameSync(head []byte) bool {
+	return len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0
+}
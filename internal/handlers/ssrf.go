@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const callbackResolveTimeout = 5 * time.Second
+
+var errBlockedAddress = errors.New("endereço de callback não permitido")
+
+// sharedAddressSpace is 100.64.0.0/10 (carrier-grade NAT), which net.IP
+// doesn't classify as private.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicIP reports whether ip is routable on the internet: loopback,
+// private, link-local (cloud metadata lives there), multicast and
+// unspecified addresses are not.
+func publicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip) || ip.To4() != nil && ip.To4()[0] == 0)
+}
+
+// webhookHostAllowed reports whether host is in WebhookAllowedHosts, which
+// may point at internal services.
+func (a *App) webhookHostAllowed(host string) bool {
+	for _, allowed := range a.cfg.WebhookAllowedHosts {
+		if strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCallbackURL accepts absolute http(s) URLs whose host resolves only to
+// public addresses, or is listed in WebhookAllowedHosts. When the allowlist
+// is set, other hosts are refused. An empty value is valid and disables the
+// webhook.
+func (a *App) checkCallbackURL(v string) (string, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", true
+	}
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return "", false
+	}
+	host := u.Hostname()
+	if a.webhookHostAllowed(host) {
+		return u.String(), true
+	}
+	if len(a.cfg.WebhookAllowedHosts) > 0 {
+		return "", false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), callbackResolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return "", false
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return "", false
+		}
+	}
+	return u.String(), true
+}
+
+// webhookTransport re-checks every address it connects to, so a DNS answer
+// that changed after checkCallbackURL (rebinding) or a redirect can't reach
+// internal addresses. Proxies are not used since they would dial for us.
+func (a *App) webhookTransport() *http.Transport {
+	dialer := &net.Dialer{Timeout: webhookTimeout}
+	guarded := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicIP(net.ParseIP(host)) {
+				return fmt.Errorf("%w: %s", errBlockedAddress, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if a.webhookHostAllowed(host) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		if len(a.cfg.WebhookAllowedHosts) > 0 {
+			return nil, fmt.Errorf("%w: %s", errBlockedAddress, host)
+		}
+		return guarded.DialContext(ctx, network, addr)
+	}
+	return transport
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+	"extratorDeAudio/internal/storage"
+)
+
+// WithStorage replaces the local outputs directory with another backend,
+// such as an S3 bucket.
+func WithStorage(s storage.Storage) Option {
+	return func(a *App) {
+		a.storage = s
+	}
+}
+
+// remoteStorage reports whether outputs leave the local disk after
+// extraction.
+func (a *App) remoteStorage() bool {
+	_, local := a.storage.(storage.LocalPather)
+	return !local
+}
+
+// storageKey maps a path under the outputs directory to its storage key.
+func (a *App) storageKey(path string) (string, error) {
+	rel, err := filepath.Rel(a.outputsDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the outputs dir", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// jobOutputPaths lists the extracted audio files of a job.
+func jobOutputPaths(job *models.ExtractionJob) []string {
+	if len(job.Outputs) > 0 {
+		paths := make([]string, len(job.Outputs))
+		for i, out := range job.Outputs {
+			paths[i] = out.Path
+		}
+		return paths
+	}
+	if job.OutputPath != "" {
+		return []string{job.OutputPath}
+	}
+	return nil
+}
+
+// persistOutputs uploads a finished job's audio to remote storage and drops
+// the local copies. Local storage already has the files in place.
+func (a *App) persistOutputs(ctx context.Context, jobID string) error {
+	if !a.remoteStorage() {
+		return nil
+	}
+	job, ok := a.getJob(jobID)
+	// HLS playlists reference their segments by relative path, so they are
+	// always served from local disk.
+	if !ok || job.Format == extractor.FormatHLS {
+		return nil
+	}
+	for _, path := range jobOutputPaths(job) {
+		if err := a.putFile(ctx, path); err != nil {
+			return fmt.Errorf("failed to upload output: %w", err)
+		}
+	}
+	for _, path := range jobOutputPaths(job) {
+		_ = os.Remove(path)
+	}
+	return nil
+}
+
+func (a *App) putFile(ctx context.Context, path string) error {
+	return a.putFileAs(ctx, path, path)
+}
+
+// putFileAs uploads the file at localPath under the storage key of path,
+// e.g. a private copy made by localAudio.
+func (a *App) putFileAs(ctx context.Context, localPath, path string) error {
+	key, err := a.storageKey(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return a.storage.Put(ctx, key, f, info.Size())
+}
+
+// serveOutput sends an extracted file. Files on local disk go through
+// serveFile; remote objects redirect to a presigned URL when the backend
+// provides one, otherwise they are proxied.
+func (a *App) serveOutput(w http.ResponseWriter, r *http.Request, path, name string) {
+	if _, err := os.Stat(path); err == nil {
+		a.serveFile(w, r, path, name)
+		return
+	}
+	if !a.remoteStorage() {
+		http.Error(w, "arquivo não encontrado", http.StatusNotFound)
+		return
+	}
+
+	key, err := a.storageKey(path)
+	if err != nil {
+		http.Error(w, "arquivo não encontrado", http.StatusNotFound)
+		return
+	}
+	info, err := a.storage.Stat(r.Context(), key)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			a.logger.Error("storage stat failed", "key", key, "error", err)
+		}
+		http.Error(w, "arquivo não encontrado", http.StatusNotFound)
+		return
+	}
+
+	location, err := a.storage.URL(r.Context(), key, name)
+	if err != nil {
+		a.logger.Error("storage url failed", "key", key, "error", err)
+		http.Error(w, "erro ao gerar link de download", http.StatusInternalServerError)
+		return
+	}
+	if location != "" {
+		http.Redirect(w, r, location, http.StatusFound)
+		return
+	}
+
+	body, err := a.storage.Get(r.Context(), key)
+	if err != nil {
+		a.logger.Error("storage get failed", "key", key, "error", err)
+		http.Error(w, "arquivo não encontrado", http.StatusNotFound)
+		return
+	}
+	defer body.Close()
+	// Callers usually set the type from the job format; otherwise it comes
+	// from the name, since the proxied bytes aren't sniffed reliably.
+	if w.Header().Get("Content-Type") == "" {
+		ct := contentTypeFor("", name)
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", ct)
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+name+"\"")
+	_, _ = io.Copy(w, body)
+}
+
+// outputExists reports whether path is available locally or in storage.
+func (a *App) outputExists(ctx context.Context, path string) bool {
+	if _, err := os.Stat(path); err == nil {
+		return true
+	}
+	if !a.remoteStorage() {
+		return false
+	}
+	key, err := a.storageKey(path)
+	if err != nil {
+		return false
+	}
+	_, err = a.storage.Stat(ctx, key)
+	return err == nil
+}
+
+// openOutput opens path from local disk, falling back to storage.
+func (a *App) openOutput(ctx context.Context, path string) (io.ReadCloser, time.Time, error) {
+	if f, err := os.Open(path); err == nil {
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, time.Time{}, err
+		}
+		return f, info.ModTime(), nil
+	}
+	key, err := a.storageKey(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	info, err := a.storage.Stat(ctx, key)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	body, err := a.storage.Get(ctx, key)
+	return body, info.ModTime, err
+}
+
+// localAudio returns a local path to the job's audio for tools such as
+// whisper: the output itself when it is on disk, otherwise a copy downloaded
+// from storage. Each call gets its own copy, so concurrent callers never see
+// a partial download or lose the file to another's cleanup, which removes
+// the copy.
+func (a *App) localAudio(ctx context.Context, job *models.ExtractionJob) (path string, cleanup func(), err error) {
+	if _, err := os.Stat(job.OutputPath); err == nil || !a.remoteStorage() {
+		return job.OutputPath, func() {}, nil
+	}
+	body, _, err := a.openOutput(ctx, job.OutputPath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer body.Close()
+
+	// The extension stays last so tools can still tell the format.
+	ext := filepath.Ext(job.OutputPath)
+	pattern := strings.TrimSuffix(filepath.Base(job.OutputPath), ext) + ".local-*" + ext
+	f, err := os.CreateTemp(filepath.Dir(job.OutputPath), pattern)
+	if err != nil {
+		return "", nil, err
+	}
+	path = f.Name()
+	a.mu.Lock()
+	a.localCopies[path] = struct{}{}
+	a.mu.Unlock()
+	cleanup = func() {
+		_ = os.Remove(path)
+		a.mu.Lock()
+		delete(a.localCopies, path)
+		a.mu.Unlock()
+	}
+
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		cleanup()
+		return "", nil, err
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return path, cleanup, nil
+}
+
+// removeJobFiles deletes every file of a job, including remote outputs.
+func (a *App) removeJobFiles(job models.ExtractionJob) {
+	if a.inputShared(job.ID, job.InputPath) {
+		job.InputPath = ""
+	}
+	removeJobFiles(job)
+	a.removeSamples(job.ID)
+	if job.OutputDir != "" {
+		removeEmptyDirs(a.outputsDir, a.jobOutputDir(&job))
+		removeEmptyDirs(a.transcriptsDir, a.jobTranscriptDir(&job))
+	}
+	if !a.remoteStorage() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, path := range jobOutputPaths(&job) {
+		key, err := a.storageKey(path)
+		if err != nil {
+			continue
+		}
+		if err := a.storage.Delete(ctx, key); err != nil {
+			a.logger.Warn("failed to delete stored output", "key", key, "error", err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"extratorDeAudio/internal/models"
+	"extratorDeAudio/internal/storage"
+)
+
+// memStorage is a remote-like storage without presigned URLs, so outputs
+// are proxied through the app.
+type memStorage map[string][]byte
+
+func (m memStorage) Put(_ context.Context, key string, r io.Reader, _ int64) error {
+	data, err := io.ReadAll(r)
+	m[key] = data
+	return err
+}
+
+func (m memStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	data, ok := m[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m memStorage) Delete(_ context.Context, key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m memStorage) Stat(_ context.Context, key string) (storage.Info, error) {
+	data, ok := m[key]
+	if !ok {
+		return storage.Info{}, storage.ErrNotFound
+	}
+	return storage.Info{Size: int64(len(data)), ModTime: time.Now()}, nil
+}
+
+func (m memStorage) URL(context.Context, string, string) (string, error) {
+	return "", nil
+}
+
+func TestServeOutputProxiesWithContentType(t *testing.T) {
+	outputs := t.TempDir()
+	a := NewApp(slog.New(slog.NewTextHandler(io.Discard, nil)), Config{UploadsDir: t.TempDir(), OutputsDir: outputs, MinFreeDiskBytes: -1},
+		WithStorage(memStorage{"job/audio.mka": []byte("matroska audio")}))
+
+	rec := httptest.NewRecorder()
+	a.serveOutput(rec, httptest.NewRequest("GET", "/download/job", nil), filepath.Join(outputs, "job", "audio.mka"), "audio.mka")
+
+	if rec.Code != 200 {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/octet-stream" {
+		t.Errorf("Content-Type = %q, want the fallback for an unknown extension", got)
+	}
+	if got := rec.Header().Get("Content-Length"); got != "14" {
+		t.Errorf("Content-Length = %q, want 14", got)
+	}
+
+	rec = httptest.NewRecorder()
+	_ = a.storage.Put(context.Background(), "job/audio.flac", bytes.NewReader([]byte("fLaC")), 4)
+	a.serveOutput(rec, httptest.NewRequest("GET", "/download/job", nil), filepath.Join(outputs, "job", "audio.flac"), "audio.flac")
+	if got := rec.Header().Get("Content-Type"); got != "audio/flac" {
+		t.Errorf("Content-Type = %q, want audio/flac from the name", got)
+	}
+}
+
+func TestLocalAudioGivesEachCallerItsOwnCopy(t *testing.T) {
+	outputs := t.TempDir()
+	a := NewApp(slog.New(slog.NewTextHandler(io.Discard, nil)), Config{UploadsDir: t.TempDir(), OutputsDir: outputs, MinFreeDiskBytes: -1},
+		WithStorage(memStorage{"job.mp3": []byte("remote audio")}))
+	job := &models.ExtractionJob{ID: "job", OutputPath: filepath.Join(outputs, "job.mp3")}
+
+	first, cleanupFirst, err := a.localAudio(context.Background(), job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, cleanupSecond, err := a.localAudio(context.Background(), job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupSecond()
+	if first == second || first == job.OutputPath || filepath.Ext(first) != ".mp3" {
+		t.Fatalf("copies = %q and %q, want distinct .mp3 files besides the output", first, second)
+	}
+	if files, _ := a.referencedPaths(); len(files) != 2 {
+		t.Errorf("vacuum protects %d copies, want 2", len(files))
+	}
+
+	cleanupFirst()
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("first copy survived its cleanup: %v", err)
+	}
+	data, err := os.ReadFile(second)
+	if err != nil || string(data) != "remote audio" {
+		t.Errorf("second copy = %q, %v after the first cleanup", data, err)
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxConcurrentStreams bounds the ffmpeg processes started by previews,
+// which don't go through the extraction pool.
+const maxConcurrentStreams = 4
+
+// streamAudio encodes the job's upload on the fly and sends it as the
+// response body, for previews that shouldn't leave a file behind. format
+// and quality default to the job's. Closing the connection cancels the
+// request context, which kills ffmpeg.
+func (a *App) streamAudio(w http.ResponseWriter, r *http.Request) {
+	job, ok := a.getJob(chi.URLParam(r, "id"))
+	if !ok {
+		http.Error(w, "job não encontrado", http.StatusNotFound)
+		return
+	}
+	if job.Status == models.StatusUploading {
+		http.Error(w, "upload ainda não foi concluído", http.StatusConflict)
+		return
+	}
+	if job.ArchivedAt != nil || job.InputPath == "" {
+		http.Error(w, "arquivo de entrada indisponível", http.StatusGone)
+		return
+	}
+	if _, err := os.Stat(job.InputPath); err != nil {
+		http.Error(w, "arquivo de entrada indisponível", http.StatusGone)
+		return
+	}
+
+	opts := a.extractOptions(job)
+	if v := r.URL.Query().Get("format"); v != "" {
+		if opts.Format, ok = a.resolveFormat(v); !ok {
+			http.Error(w, "formato não está habilitado neste servidor", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := r.URL.Query().Get("quality"); v != "" {
+		opts.Quality = sanitizeQuality(v)
+	}
+	if !extractor.Streamable(opts.Format) {
+		http.Error(w, "este formato não pode ser transmitido", http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case a.streamSlots <- struct{}{}:
+		defer func() { <-a.streamSlots }()
+	default:
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "muitas transmissões em andamento, tente novamente mais tarde", http.StatusServiceUnavailable)
+		return
+	}
+
+	if ct := contentTypeFor(opts.Format, ""); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	out := &countingWriter{w: w}
+	err := a.extractor.StreamAudio(r.Context(), job.InputPath, out, opts)
+	switch {
+	case err == nil:
+	case r.Context().Err() != nil:
+		a.logger.Info("audio stream closed by client", "job_id", job.ID)
+	case out.n == 0:
+		// Nothing was sent yet, so the status can still report the failure.
+		a.logger.Error("audio stream failed", "job_id", job.ID, "error", err)
+		w.Header().Del("Content-Type")
+		http.Error(w, "falha ao gerar o áudio", http.StatusInternalServerError)
+	default:
+		a.logger.Error("audio stream failed mid-response", "job_id", job.ID, "error", err)
+	}
+}
+
+// countingWriter records whether any bytes reached the response.
+type countingWriter struct {
+	w http.ResponseWriter
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultWSMaxPerJob      = 10
+	defaultWSMaxConnections = 1000
+	// closeTryAgainLater is the RFC 6455 registry code 1013, which gorilla
+	// doesn't name.
+	closeTryAgainLater = 1013
+)
+
+// subscribe registers conn for the job's progress events unless the per-job
+// or global subscriber cap is reached.
+func (a *App) subscribe(jobID string, conn *websocket.Conn) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if overCap(a.wsConns, a.cfg.WSMaxConnections, defaultWSMaxConnections) ||
+		overCap(len(a.subs[jobID]), a.cfg.WSMaxPerJob, defaultWSMaxPerJob) {
+		return false
+	}
+	if a.subs[jobID] == nil {
+		a.subs[jobID] = make(map[*websocket.Conn]struct{})
+	}
+	a.subs[jobID][conn] = struct{}{}
+	a.wsConns++
+	return true
+}
+
+// unsubscribe removes conn, dropping the job's set once empty.
+func (a *App) unsubscribe(jobID string, conn *websocket.Conn) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.subs[jobID][conn]; !ok {
+		return
+	}
+	delete(a.subs[jobID], conn)
+	a.wsConns--
+	if len(a.subs[jobID]) == 0 {
+		delete(a.subs, jobID)
+	}
+}
+
+// overCap reports whether count already reached limit (0 uses fallback,
+// negative means unlimited).
+func overCap(count, limit, fallback int) bool {
+	if limit < 0 {
+		return false
+	}
+	if limit == 0 {
+		limit = fallback
+	}
+	return count >= limit
+}
+
+// rejectSubscriber closes a freshly upgraded connection with a close frame
+// telling the client to retry later.
+func rejectSubscriber(conn *websocket.Conn) {
+	msg := websocket.FormatCloseMessage(closeTryAgainLater, "limite de conexões atingido")
+	_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	_ = conn.Close()
+}
+
+// closeSubscribers sends a normal close frame to every subscriber of the
+// job and drops them, e.g. once the job is deleted.
+func (a *App) closeSubscribers(jobID string) {
+	a.mu.Lock()
+	conns := a.subs[jobID]
+	delete(a.subs, jobID)
+	a.wsConns -= len(conns)
+	a.mu.Unlock()
+
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "job removido")
+	for conn := range conns {
+		_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		_ = conn.Close()
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"extratorDeAudio/internal/extractor"
+)
+
+const toolCheckTimeout = 10 * time.Second
+
+// checkTools probes the external binaries once at startup, so a container
+// shipped without ffmpeg is reported by /healthz instead of failing the
+// first extraction.
+func (a *App) checkTools() []extractor.ToolStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), toolCheckTimeout)
+	defer cancel()
+	tools := a.extractor.CheckTools(ctx)
+	for _, tool := range tools {
+		switch {
+		case tool.Available:
+			a.logger.Info("external tool found", "tool", tool.Name, "path", tool.Path, "version", tool.Version)
+		case tool.Required:
+			a.logger.Error("required external tool missing", "tool", tool.Name, "error", tool.Error)
+		default:
+			a.logger.Warn("optional external tool missing", "tool", tool.Name, "error", tool.Error)
+		}
+	}
+	return tools
+}
+
+// toolsView is the tools section of /healthz. The public view only says
+// which tools are available; paths, versions and errors help fingerprint
+// the host, so they are left to admin requests (detail).
+func toolsView(tools []extractor.ToolStatus, detail bool) any {
+	if detail {
+		return tools
+	}
+	view := make([]map[string]any, len(tools))
+	for i, tool := range tools {
+		view[i] = map[string]any{"name": tool.Name, "available": tool.Available, "required": tool.Required}
+	}
+	return view
+}
+
+// toolsReady reports whether every required tool was found.
+func toolsReady(tools []extractor.ToolStatus) bool {
+	for _, tool := range tools {
+		if tool.Required && !tool.Available {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// probe describes the uploaded file (container, duration, audio codec,
+// bitrate, sample rate, channels) before committing to an extraction.
+func (a *App) probe(w http.ResponseWriter, r *http.Request) {
+	job, ok := a.uploadedJob(w, r)
+	if !ok {
+		return
+	}
+
+	// Usually already gathered by the upload prefetch.
+	info, ok := a.media.get(job.ID)
+	if !ok {
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+		var err error
+		if info, err = a.extractor.ProbeInfo(ctx, job.InputPath); err != nil {
+			a.logger.Warn("probe failed", "job_id", job.ID, "error", err)
+			http.Error(w, "não foi possível analisar o arquivo", http.StatusUnprocessableEntity)
+			return
+		}
+		a.media.set(job.ID, info)
+	}
+
+	a.respondJSON(w, http.StatusOK, map[string]any{
+		"id":    job.ID,
+		"file":  job.InputFileName,
+		"probe": info,
+	})
+}
+
+// uploadedJob loads the job from the URL and checks that its upload is on
+// disk, answering 404/409 otherwise.
+func (a *App) uploadedJob(w http.ResponseWriter, r *http.Request) (*models.ExtractionJob, bool) {
+	jobID := chi.URLParam(r, "id")
+	job, ok := a.getJob(jobID)
+	if !ok {
+		http.Error(w, "job não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+	if job.InputPath == "" {
+		http.Error(w, "upload ainda não foi concluído", http.StatusConflict)
+		return nil, false
+	}
+	if _, err := os.Stat(job.InputPath); err != nil {
+		http.Error(w, "arquivo enviado não encontrado", http.StatusConflict)
+		return nil, false
+	}
+	return job, true
+}
+
+// listTracks probes the uploaded file's audio streams so clients can offer
+// a track choice before extracting.
+func (a *App) listTracks(w http.ResponseWriter, r *http.Request) {
+	job, ok := a.uploadedJob(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	streams, err := a.extractor.ProbeAudioStreams(ctx, job.InputPath)
+	if err != nil {
+		a.logger.Warn("track probe failed", "job_id", job.ID, "error", err)
+		http.Error(w, "não foi possível ler as faixas de áudio", http.StatusUnprocessableEntity)
+		return
+	}
+	if streams == nil {
+		streams = []extractor.AudioStream{}
+	}
+
+	a.respondJSON(w, http.StatusOK, map[string]any{
+		"id":     job.ID,
+		"count":  len(streams),
+		"tracks": streams,
+	})
+}
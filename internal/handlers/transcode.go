@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// transcodeTimeout bounds a single conversion of an extracted output.
+const transcodeTimeout = 30 * time.Minute
+
+// losslessFormats are the outputs that re-encode without generation loss.
+var losslessFormats = map[string]bool{"wav": true, "flac": true}
+
+// transcodeOutput converts a completed job's extracted audio to another
+// format/quality without the source upload, which may already be gone. The
+// conversion runs in the background and is tracked in job.Conversions, one
+// per format; asking again for a format replaces its previous conversion.
+func (a *App) transcodeOutput(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+	format, ok := a.resolveFormat(r.FormValue("format"))
+	if !ok {
+		http.Error(w, "formato desativado neste servidor", http.StatusBadRequest)
+		return
+	}
+	if format == extractor.FormatHLS {
+		http.Error(w, "conversão para hls não é suportada", http.StatusBadRequest)
+		return
+	}
+	quality := sanitizeQuality(r.FormValue("quality"))
+	if quality == "original" {
+		http.Error(w, "qualidade original não se aplica a conversões", http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	job, ok := a.jobs[jobID]
+	if !ok {
+		a.mu.Unlock()
+		http.Error(w, "job não encontrado", http.StatusNotFound)
+		return
+	}
+	if job.Status != models.StatusCompleted || job.OutputPath == "" {
+		a.mu.Unlock()
+		http.Error(w, "extração ainda não foi concluída", http.StatusConflict)
+		return
+	}
+	if job.ArchivedAt != nil {
+		a.mu.Unlock()
+		http.Error(w, "arquivado", http.StatusGone)
+		return
+	}
+	if len(job.Outputs) > 0 || job.Format == extractor.FormatHLS {
+		a.mu.Unlock()
+		http.Error(w, "conversão só é suportada para jobs com um único arquivo", http.StatusConflict)
+		return
+	}
+	for _, c := range job.Conversions {
+		if c.Format == format && c.Status == models.StatusProcessing {
+			a.mu.Unlock()
+			a.respondJSON(w, http.StatusAccepted, map[string]string{"status": "conversion_already_processing"})
+			return
+		}
+	}
+	if a.queueFullLocked() {
+		a.mu.Unlock()
+		a.rejectQueueFull(w)
+		return
+	}
+
+	conv := models.Conversion{
+		Format:  format,
+		Quality: quality,
+		Name:    friendlyBaseName(job.InputFileName, "audio") + "." + format,
+		Path:    filepath.Join(a.jobOutputDir(job), extractor.OutputName(job.ID+"_"+format, format)),
+		Status:  models.StatusProcessing,
+	}
+	setConversion(job, conv)
+	job.UpdatedAt = time.Now()
+	sourceFormat := job.Format
+	a.mu.Unlock()
+
+	go a.runTranscode(jobID, conv)
+
+	resp := map[string]string{
+		"status":       "conversion_started",
+		"format":       format,
+		"quality":      quality,
+		"download_url": "/download/" + jobID + "?conversion=" + format,
+	}
+	if !losslessFormats[sourceFormat] && !losslessFormats[format] {
+		resp["warning"] = "conversão entre formatos com perdas reduz a qualidade em relação ao arquivo original"
+	}
+	a.respondJSON(w, http.StatusAccepted, resp)
+}
+
+func (a *App) runTranscode(jobID string, conv models.Conversion) {
+	job, ok := a.getJob(jobID)
+	if !ok {
+		return
+	}
+	// Conversions share the extraction pool as tasks, so they neither bypass
+	// MAX_CONCURRENT_JOBS nor take the job's queue position.
+	if !a.extractPool.acquireTask(context.Background(), jobID+"/transcode/"+conv.Format) {
+		return
+	}
+	defer a.extractPool.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), transcodeTimeout)
+	defer cancel()
+
+	err := func() error {
+		audioPath, cleanupAudio, err := a.localAudio(ctx, job)
+		if err != nil {
+			return fmt.Errorf("falha ao obter áudio: %w", err)
+		}
+		defer cleanupAudio()
+
+		opts := extractor.ExtractOptions{
+			Format:  conv.Format,
+			Quality: conv.Quality,
+			Threads: a.ffmpegThreads(job),
+		}
+		if err := a.extractor.ExtractAudio(ctx, audioPath, conv.Path, opts, nil); err != nil {
+			if errors.Is(err, extractor.ErrDiskFull) {
+				return errors.New("sem espaço em disco")
+			}
+			return err
+		}
+		return nil
+	}()
+
+	conv.Status = models.StatusCompleted
+	if err != nil {
+		_ = os.Remove(conv.Path)
+		conv.Status = models.StatusFailed
+		conv.Error = err.Error()
+		a.logger.Error("transcode failed", "job_id", jobID, "format", conv.Format, "error", err)
+	} else {
+		a.logger.Info("transcode completed", "job_id", jobID, "format", conv.Format, "quality", conv.Quality)
+	}
+	a.updateJob(jobID, func(j *models.ExtractionJob) {
+		setConversion(j, conv)
+		j.UpdatedAt = time.Now()
+	})
+}
+
+// setConversion stores conv in the job, replacing any conversion to the same
+// format. The slice is copied since getJob clones share it.
+func setConversion(job *models.ExtractionJob, conv models.Conversion) {
+	convs := make([]models.Conversion, 0, len(job.Conversions)+1)
+	for _, c := range job.Conversions {
+		if c.Format != conv.Format {
+			convs = append(convs, c)
+		}
+	}
+	job.Conversions = append(convs, conv)
+}
+
+// conversionView is a conversion as exposed by the JSON API, with its
+// download URL instead of the server path.
+type conversionView struct {
+	Format      string           `json:"format"`
+	Quality     string           `json:"quality"`
+	Status      models.JobStatus `json:"status"`
+	Error       string           `json:"error,omitempty"`
+	DownloadURL string           `json:"download_url,omitempty"`
+}
+
+func conversionViews(job *models.ExtractionJob) []conversionView {
+	views := make([]conversionView, 0, len(job.Conversions))
+	for _, c := range job.Conversions {
+		v := conversionView{Format: c.Format, Quality: c.Quality, Status: c.Status, Error: c.Error}
+		if c.Status == models.StatusCompleted {
+			v.DownloadURL = "/download/" + job.ID + "?conversion=" + c.Format
+		}
+		views = append(views, v)
+	}
+	return views
+}
+
+// downloadConversion serves the conversion of a job to format.
+func (a *App) downloadConversion(w http.ResponseWriter, r *http.Request, job *models.ExtractionJob, format string) {
+	for _, c := range job.Conversions {
+		if c.Format != format {
+			continue
+		}
+		if c.Status != models.StatusCompleted {
+			http.Error(w, "conversão ainda não está pronta", http.StatusConflict)
+			return
+		}
+		setContentType(w, c.Format, c.Name)
+		a.serveFile(w, r, c.Path, c.Name)
+		return
+	}
+	http.Error(w, "conversão não encontrada", http.StatusNotFound)
+}
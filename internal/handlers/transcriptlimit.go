@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/transcript"
+)
+
+// defaultMaxTranscriptBytes caps each transcript file when MaxTranscriptBytes
+// is unset; real speech stays far below it, runaway hallucinations do not.
+const defaultMaxTranscriptBytes = 50 * 1024 * 1024
+
+// maxTranscriptBytes returns the per-file transcript cap, 0 when disabled.
+func (a *App) maxTranscriptBytes() int64 {
+	switch limit := a.cfg.MaxTranscriptBytes; {
+	case limit < 0:
+		return 0
+	case limit == 0:
+		return defaultMaxTranscriptBytes
+	default:
+		return limit
+	}
+}
+
+// limitTranscriptSize truncates the TXT, SRT and VTT files of a run that
+// exceed the cap and drops an oversized words JSON, which cannot be cut
+// meaningfully. It reports whether anything was cut.
+func (a *App) limitTranscriptSize(jobID, base string) (bool, error) {
+	limit := a.maxTranscriptBytes()
+	if limit == 0 {
+		return false, nil
+	}
+
+	truncated := false
+	marker := fmt.Sprintf("[transcrição truncada: limite de %d bytes excedido]", limit)
+	for _, f := range []struct{ path, marker string }{
+		{base + ".txt", marker},
+		// Subtitles are cut on a cue boundary without a marker, which would
+		// not be a valid cue.
+		{base + ".srt", ""},
+		{base + ".vtt", ""},
+	} {
+		cut, err := transcript.TruncateFile(f.path, limit, f.marker)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Older jobs may lack the VTT.
+			continue
+		}
+		if err != nil {
+			return truncated, err
+		}
+		if cut {
+			a.logger.Warn("transcript exceeded size limit, truncated", "job_id", jobID, "path", f.path, "limit_bytes", limit)
+			truncated = true
+		}
+	}
+
+	if size := fileSize(extractor.WordsPath(base)); size > limit {
+		a.logger.Warn("word timestamps exceeded size limit, dropped", "job_id", jobID, "limit_bytes", limit)
+		_ = os.Remove(extractor.WordsPath(base))
+		truncated = true
+	}
+	return truncated, nil
+}
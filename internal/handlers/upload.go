@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"extratorDeAudio/internal/models"
+)
+
+const (
+	// maxFormValueBytes bounds the non-file fields of an upload; larger
+	// fields are rejected.
+	maxFormValueBytes = 64 * 1024
+	// statusClientClosedRequest marks uploads abandoned by the client
+	// (nginx's 499). No response is written for it: nobody is listening.
+	statusClientClosedRequest = 499
+)
+
+// reserveUpload creates an empty job so the client can subscribe to
+// /ws/{id} and watch its own upload progress before sending the file to
+// /upload?id={id}. The reservation expires after uploadIdleTimeout.
+func (a *App) reserveUpload(w http.ResponseWriter, r *http.Request) {
+	if a.queueFull() {
+		a.rejectQueueFull(w)
+		return
+	}
+	if err := a.checkFreeSpace(0); err != nil {
+		a.logger.Warn("upload reservation rejected, low disk space", "error", err)
+		http.Error(w, "espaço em disco insuficiente, tente novamente mais tarde", http.StatusInsufficientStorage)
+		return
+	}
+	jobID := a.registerUpload(true)
+	a.respondJSON(w, http.StatusCreated, map[string]string{
+		"id":         jobID,
+		"upload_url": "/upload?id=" + jobID,
+		"ws_url":     "/ws/" + jobID,
+	})
+}
+
+// upload streams the multipart body to disk instead of buffering it with
+// ParseMultipartForm, so the job exists while the file is still arriving and
+// "upload" progress events can be broadcast. Form fields may come before or
+// after the file; options are validated once the body has been read.
+func (a *App) upload(w http.ResponseWriter, r *http.Request) {
+	a.extendUploadDeadlines(w)
+	r.Body = http.MaxBytesReader(w, r.Body, a.maxUploadBytes+1024)
+
+	jobID := r.URL.Query().Get("id")
+	reserved := jobID != ""
+	if !reserved && a.queueFull() {
+		a.rejectQueueFull(w)
+		return
+	}
+	if reserved {
+		if status := a.claimReservation(jobID); status != 0 {
+			switch status {
+			case http.StatusTooManyRequests:
+				a.rejectQueueFull(w)
+			case http.StatusNotFound:
+				http.Error(w, "job não encontrado", status)
+			default:
+				http.Error(w, "upload já enviado para este job", status)
+			}
+			return
+		}
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		a.logger.Warn("invalid multipart upload", "error", err)
+		http.Error(w, "upload inválido ou maior que 500MB", http.StatusBadRequest)
+		return
+	}
+
+	if err := os.MkdirAll(a.uploadsDir, 0o755); err != nil {
+		a.logger.Error("failed to ensure uploads dir", "error", err)
+		http.Error(w, "erro interno ao preparar upload", http.StatusInternalServerError)
+		return
+	}
+	// Refuse up front rather than failing with ENOSPC halfway through.
+	if err := a.checkFreeSpace(r.ContentLength); err != nil {
+		a.logger.Warn("upload rejected, low disk space", "job_id", jobID, "error", err)
+		const message = "espaço em disco insuficiente, tente novamente mais tarde"
+		if reserved {
+			a.abortUpload(jobID, "", reserved, message)
+		}
+		http.Error(w, message, http.StatusInsufficientStorage)
+		return
+	}
+
+	values := make(map[string]string)
+	var inputPath, safeName string
+	abort := func(status int, message string) {
+		a.abortUpload(jobID, inputPath, reserved, message)
+		if status == statusClientClosedRequest {
+			a.logger.Info("upload aborted by client", "job_id", jobID)
+			return
+		}
+		http.Error(w, message, status)
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if clientGone(r.Context(), err) {
+				abort(statusClientClosedRequest, "upload cancelado pelo cliente")
+				return
+			}
+			a.logger.Warn("invalid multipart upload", "error", err)
+			abort(http.StatusBadRequest, uploadReadError(err))
+			return
+		}
+
+		switch {
+		case part.FormName() == "video" && part.FileName() != "" && inputPath == "":
+			// Check the magic bytes before anything touches the disk.
+			src := bufio.NewReaderSize(part, sniffLen)
+			head, err := src.Peek(sniffLen)
+			if err != nil && !errors.Is(err, io.EOF) {
+				if clientGone(r.Context(), err) {
+					abort(statusClientClosedRequest, "upload cancelado pelo cliente")
+					return
+				}
+				abort(http.StatusBadRequest, uploadReadError(err))
+				return
+			}
+			if !isMediaContainer(head) {
+				a.logger.Warn("upload rejected, unrecognized container", "job_id", jobID, "file", part.FileName(), "content_type", http.DetectContentType(head))
+				abort(http.StatusUnsupportedMediaType, "o arquivo não é um vídeo ou áudio reconhecido")
+				return
+			}
+			if !reserved {
+				jobID = a.registerUpload(false)
+			}
+			safeName = sanitizeFileName(part.FileName())
+			inputPath = filepath.Join(a.uploadsDir, jobID+"_"+safeName)
+			if status, message := a.receiveFile(r.Context(), jobID, inputPath, src, r.ContentLength); status != 0 {
+				abort(status, message)
+				return
+			}
+		case part.FileName() == "":
+			data, err := io.ReadAll(io.LimitReader(part, maxFormValueBytes+1))
+			if err != nil {
+				if clientGone(r.Context(), err) {
+					abort(statusClientClosedRequest, "upload cancelado pelo cliente")
+					return
+				}
+				abort(http.StatusBadRequest, uploadReadError(err))
+				return
+			}
+			if len(data) > maxFormValueBytes {
+				abort(http.StatusBadRequest, "campo "+part.FormName()+" excede 64KB")
+				return
+			}
+			values[part.FormName()] = string(data)
+		}
+		_ = part.Close()
+	}
+
+	if inputPath == "" {
+		abort(http.StatusBadRequest, "arquivo de vídeo é obrigatório")
+		return
+	}
+
+	opts, fieldErrs := a.parseUploadOptions(func(key string) string { return values[key] })
+	if len(fieldErrs) > 0 {
+		abort(http.StatusBadRequest, fieldErrs[0].Message)
+		return
+	}
+	if opts.OutputDir != "" && !a.isAdminRequest(r) {
+		abort(http.StatusForbidden, "diretório de saída exige autenticação")
+		return
+	}
+
+	a.commitUpload(w, r, jobID, safeName, inputPath, opts, wantsJSON(r))
+}
+
+// commitUpload marks a fully received file as uploaded, applies the upload
+// options and answers with the job (asJSON) or a redirect to its page.
+func (a *App) commitUpload(w http.ResponseWriter, r *http.Request, jobID, safeName, inputPath string, opts uploadOptions, asJSON bool) {
+	a.updateJob(jobID, func(j *models.ExtractionJob) {
+		j.InputFileName = safeName
+		j.InputPath = inputPath
+		j.Status = models.StatusUploaded
+		j.Progress = 0
+		j.UploadSize = 0
+		j.UploadOffset = 0
+		j.UpdatedAt = time.Now()
+		opts.apply(j)
+	})
+	a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "upload", Status: models.StatusUploaded, Progress: 100, Message: "upload concluído"})
+	a.prefetchMedia(jobID, inputPath)
+
+	job, _ := a.getJob(jobID)
+	a.logger.Info("upload saved", "job_id", jobID, "file", safeName, "format", job.Format, "quality", job.Quality)
+	if asJSON {
+		a.respondJobCreated(w, job)
+		return
+	}
+	http.Redirect(w, r, "/job/"+jobID, http.StatusSeeOther)
+}
+
+// registerUpload adds an empty uploading job under a fresh ID. A pending
+// job waits for its data; see trackPendingLocked.
+func (a *App) registerUpload(pending bool) string {
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	jobID := a.uniqueJobID()
+	a.jobs[jobID] = &models.ExtractionJob{
+		ID:               jobID,
+		Status:           models.StatusUploading,
+		TranscriptStatus: models.StatusNotStarted,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if pending {
+		a.trackPendingLocked(jobID)
+	}
+	return jobID
+}
+
+// claimReservation hands a reservation from /api/uploads to the request
+// sending its file. Checking and claiming happen under one lock, so only
+// one request wins; the job counts toward the queue limit from then on. It
+// returns 0 on success or the HTTP status explaining the refusal.
+func (a *App) claimReservation(jobID string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	job, ok := a.jobs[jobID]
+	if !ok {
+		return http.StatusNotFound
+	}
+	if _, pending := a.pending[jobID]; !pending || job.Status != models.StatusUploading || job.InputPath != "" {
+		return http.StatusConflict
+	}
+	if a.queueFullLocked() {
+		return http.StatusTooManyRequests
+	}
+	a.untrackPendingLocked(jobID)
+	return 0
+}
+
+// receiveFile writes the file part to inputPath, broadcasting progress
+// against the request's Content-Length. It returns a non-zero HTTP status and
+// message on failure.
+func (a *App) receiveFile(ctx context.Context, jobID, inputPath string, src io.Reader, total int64) (int, string) {
+	out, err := os.Create(inputPath)
+	if err != nil {
+		a.logger.Error("failed to create upload file", "error", err)
+		return http.StatusInternalServerError, "erro ao salvar upload"
+	}
+	defer out.Close()
+
+	return a.copyUpload(ctx, out, src, &uploadProgressWriter{app: a, jobID: jobID, total: total})
+}
+
+// copyUpload writes src to dst while reporting upload progress, mapping
+// failures to an HTTP status and message (0 on success).
+func (a *App) copyUpload(ctx context.Context, dst io.Writer, src io.Reader, progress *uploadProgressWriter) (int, string) {
+	if _, err := io.Copy(dst, io.TeeReader(src, progress)); err != nil {
+		var maxErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, syscall.ENOSPC):
+			a.logger.Error("disk full while persisting upload", "error", err)
+			go a.emergencyCleanup("")
+			return http.StatusInsufficientStorage, "disco cheio, tente novamente mais tarde"
+		case errors.As(err, &maxErr):
+			return http.StatusBadRequest, "arquivo excede o limite de 500MB"
+		case clientGone(ctx, err):
+			return statusClientClosedRequest, "upload cancelado pelo cliente"
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			// A body shorter than announced from a client that is still
+			// there: it gets an answer.
+			return http.StatusBadRequest, uploadReadError(err)
+		}
+		a.logger.Error("failed to persist upload", "error", err)
+		return http.StatusInternalServerError, "erro ao gravar arquivo"
+	}
+	return 0, ""
+}
+
+// abortUpload removes a partial upload. Reserved jobs are kept as failed so
+// subscribed clients learn why; others are dropped as if never created.
+func (a *App) abortUpload(jobID, inputPath string, reserved bool, message string) {
+	if inputPath != "" {
+		_ = os.Remove(inputPath)
+	}
+	a.mu.Lock()
+	a.untrackPendingLocked(jobID)
+	a.mu.Unlock()
+	if !reserved {
+		a.mu.Lock()
+		delete(a.jobs, jobID)
+		a.mu.Unlock()
+		a.progressSeries.forget(jobID)
+		a.media.forget(jobID)
+		return
+	}
+	a.updateJob(jobID, func(j *models.ExtractionJob) {
+		j.Status = models.StatusFailed
+		j.Error = message
+		j.UpdatedAt = time.Now()
+	})
+	a.broadcast(jobID, models.ProgressEvent{ID: jobID, Stage: "upload", Status: models.StatusFailed, Error: message})
+}
+
+// clientGone reports whether a body read failed because the client went
+// away rather than because of a server-side problem. A short body
+// (io.ErrUnexpectedEOF) alone doesn't count: the client may still be
+// waiting for an answer.
+func clientGone(ctx context.Context, err error) bool {
+	return ctx.Err() != nil ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
+
+func uploadReadError(err error) string {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return "arquivo excede o limite de 500MB"
+	}
+	return "upload inválido ou maior que 500MB"
+}
+
+// uploadProgressWriter counts received bytes and broadcasts whenever the
+// percentage changes. total is the whole request body, so the last percent
+// also covers the multipart framing.
+type uploadProgressWriter struct {
+	app     *App
+	jobID   string
+	total   int64
+	written int64
+	last    int
+}
+
+func (p *uploadProgressWriter) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if p.total <= 0 {
+		return len(b), nil
+	}
+	percent := clampPercent(int(p.written * 100 / p.total))
+	if percent > 99 {
+		percent = 99
+	}
+	if percent > p.last {
+		p.last = percent
+		p.app.updateJob(p.jobID, func(j *models.ExtractionJob) {
+			j.Progress = percent
+			j.UpdatedAt = time.Now()
+		})
+		p.app.broadcast(p.jobID, models.ProgressEvent{ID: p.jobID, Stage: "upload", Status: models.StatusUploading, Progress: percent, Message: "enviando arquivo"})
+	}
+	return len(b), nil
+}
+
+// uploadTimeout is the time allowed to receive an upload body. By default it
+// allows the largest accepted upload at minUploadBytesPerSecond.
+func (a *App) uploadTimeout() time.Duration {
+	if a.cfg.UploadTimeout > 0 {
+		return a.cfg.UploadTimeout
+	}
+	timeout := time.Duration(a.maxUploadBytes/minUploadBytesPerSecond) * time.Second
+	if timeout < minUploadTimeout {
+		return minUploadTimeout
+	}
+	return timeout
+}
+
+// extendUploadDeadlines replaces the server-wide read/write deadlines for
+// this connection so slow but legitimate uploads are not cut off. The body
+// size is still capped by MaxBytesReader, so a longer deadline cannot be
+// used to send more data than allowed.
+func (a *App) extendUploadDeadlines(w http.ResponseWriter) {
+	deadline := time.Now().Add(a.uploadTimeout())
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(deadline); err != nil {
+		a.logger.Warn("failed to extend upload read deadline", "error", err)
+	}
+	// The write deadline starts with the request, so the redirect sent after
+	// a long upload needs the same extension.
+	if err := rc.SetWriteDeadline(deadline.Add(10 * time.Second)); err != nil {
+		a.logger.Warn("failed to extend upload write deadline", "error", err)
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUploadTruncatedBodyGetsBadRequest(t *testing.T) {
+	app := newTestApp(t, Config{})
+	h := app.Router()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("video", "video.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write(mp4Bytes(4096))
+	_ = mw.Close()
+	// Cut the body inside the file part, before the closing boundary.
+	truncated := body.Bytes()[:body.Len()/2]
+
+	req := httptest.NewRequest(http.MethodPost, "/upload?json=1", bytes.NewReader(truncated))
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 for a short body from a live client", rec.Code)
+	}
+	if got := rec.Body.String(); got == "" {
+		t.Error("response body is empty, want the upload error message")
+	}
+}
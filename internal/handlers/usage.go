@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+)
+
+// storeUsage logs the stage's total resource usage and keeps it on the job
+// for the JSON API. Stages that ran no process leave the job untouched.
+func (a *App) storeUsage(jobID, stage string, recorder *extractor.UsageRecorder) {
+	// The two types share their fields, so the conversion is direct.
+	usage := models.ResourceUsage(recorder.Usage())
+	if usage.Processes == 0 {
+		return
+	}
+	a.logger.Info("stage resource usage",
+		"job_id", jobID,
+		"stage", stage,
+		"processes", usage.Processes,
+		"cpu_user_seconds", usage.UserSeconds,
+		"cpu_system_seconds", usage.SystemSeconds,
+		"peak_rss_bytes", usage.PeakRSSBytes,
+	)
+	a.updateJob(jobID, func(j *models.ExtractionJob) {
+		if stage == "transcription" {
+			j.TranscriptionUsage = &usage
+		} else {
+			j.ExtractionUsage = &usage
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"extratorDeAudio/internal/extractor"
+)
+
+// vacuumGrace protects files modified recently from vacuum, since running
+// jobs create temporary files (intermediates, chunks) that no job field
+// references yet.
+const vacuumGrace = 10 * time.Minute
+
+// vacuumReport summarizes a vacuum run.
+type vacuumReport struct {
+	DryRun         bool     `json:"dry_run"`
+	FilesRemoved   int      `json:"files_removed"`
+	BytesReclaimed int64    `json:"bytes_reclaimed"`
+	Files          []string `json:"files,omitempty"`
+}
+
+// vacuum deletes files in the uploads, outputs and transcripts directories
+// that no live job references, e.g. leftovers from crashes or restarts that
+// the TTL cleanup never sees. dry_run=true only reports what would be removed.
+func (a *App) vacuum(w http.ResponseWriter, r *http.Request) {
+	if !a.isAdminRequest(r) {
+		http.Error(w, "não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	report := vacuumReport{DryRun: parseBool(r.FormValue("dry_run"))}
+	files, dirs := a.referencedPaths()
+	cutoff := time.Now().Add(-vacuumGrace)
+
+	seen := make(map[string]struct{})
+	for _, root := range []string{a.uploadsDir, a.outputsDir, a.transcriptsDir} {
+		if root == "" {
+			continue
+		}
+		if _, ok := seen[filepath.Clean(root)]; ok {
+			continue
+		}
+		seen[filepath.Clean(root)] = struct{}{}
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			path = filepath.Clean(path)
+			if _, ok := files[path]; ok || underAny(path, dirs) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil || info.ModTime().After(cutoff) {
+				return nil
+			}
+			if !report.DryRun {
+				if err := os.Remove(path); err != nil {
+					a.logger.Warn("vacuum failed to remove file", "path", path, "error", err)
+					return nil
+				}
+			}
+			report.FilesRemoved++
+			report.BytesReclaimed += info.Size()
+			report.Files = append(report.Files, path)
+			return nil
+		})
+	}
+
+	a.logger.Info("vacuum completed", "dry_run", report.DryRun, "files_removed", report.FilesRemoved, "bytes_reclaimed", report.BytesReclaimed)
+	a.respondJSON(w, http.StatusOK, report)
+}
+
+// referencedPaths collects the files and directories owned by live jobs.
+func (a *App) referencedPaths() (map[string]struct{}, []string) {
+	files := make(map[string]struct{})
+	var dirs []string
+	add := func(path string) {
+		if path != "" {
+			files[filepath.Clean(path)] = struct{}{}
+		}
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, job := range a.jobs {
+		add(job.InputPath)
+		add(job.OutputPath)
+		if job.OutputPath != "" {
+			add(extractor.SettingsSidecar(job.OutputPath))
+		}
+		for _, out := range job.Outputs {
+			add(out.Path)
+		}
+		for _, c := range job.Conversions {
+			add(c.Path)
+		}
+		add(job.TranscriptTXTPath)
+		add(job.TranscriptSRTPath)
+		add(job.TranscriptVTTPath)
+		add(job.TranscriptWordsPath)
+		if job.TranscriptTXTPath != "" {
+			base := strings.TrimSuffix(job.TranscriptTXTPath, ".txt")
+			add(base + ".checkpoint.json")
+			dirs = append(dirs, filepath.Clean(base+"_chunks"))
+		}
+		if job.Format == extractor.FormatHLS && job.OutputPath != "" {
+			dirs = append(dirs, filepath.Dir(filepath.Clean(job.OutputPath)))
+		}
+		dirs = append(dirs, filepath.Clean(a.samplesDir(job.ID)))
+	}
+	for path := range a.localCopies {
+		add(path)
+	}
+	return files, dirs
+}
+
+func underAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"extratorDeAudio/internal/extractor"
+	"extratorDeAudio/internal/models"
+)
+
+// verifyOutputs runs the optional VERIFY_OUTPUT pass over a job's extracted
+// files: each must probe to roughly the expected length and, when
+// checkSilence is set, must not be silent. Split channels skip the silence
+// check since one side of a stereo mix can legitimately be empty.
+func (a *App) verifyOutputs(ctx context.Context, job *models.ExtractionJob, outputs []models.JobOutput, checkSilence bool) error {
+	if !a.cfg.VerifyOutput {
+		return nil
+	}
+	a.broadcast(job.ID, models.ProgressEvent{ID: job.ID, Stage: "extraction", Status: models.StatusProcessing, Progress: 99, Message: "verificando áudio gerado"})
+
+	// Without a source duration only the clip bounds can be checked, and an
+	// untrimmed job skips the length comparison.
+	total, err := a.sourceDuration(ctx, job)
+	if err != nil {
+		a.logger.Warn("could not probe source duration for verification", "job_id", job.ID, "error", err)
+		total = 0
+	}
+	expected := expectedDuration(job, total)
+
+	for _, out := range outputs {
+		if err := a.extractor.VerifyOutput(ctx, out.Path, expected, checkSilence); err != nil {
+			if len(outputs) > 1 {
+				return fmt.Errorf("%s: %w", out.Name, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// expectedDuration is the output length verifyOutputs checks for, given the
+// source duration (zero when unknown): the clip length, or TargetDuration.
+// Stream copy can cut to the target but not pad up to it, so a shorter clip
+// keeps its own length, and an unknown one skips the comparison.
+func expectedDuration(job *models.ExtractionJob, total float64) float64 {
+	expected := clipDuration(total, job.TrimStart, job.TrimEnd)
+	if job.TargetDuration <= 0 {
+		return expected
+	}
+	if extractor.StreamCopy(job.Format) && (expected == 0 || expected < job.TargetDuration) {
+		return expected
+	}
+	return job.TargetDuration
+}
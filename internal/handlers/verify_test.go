@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"testing"
+
+	"extratorDeAudio/internal/models"
+)
+
+func TestExpectedDuration(t *testing.T) {
+	tests := []struct {
+		name  string
+		job   models.ExtractionJob
+		total float64
+		want  float64
+	}{
+		{"whole source", models.ExtractionJob{Format: "mp3"}, 120, 120},
+		{"trim", models.ExtractionJob{Format: "mp3", TrimStart: 10, TrimEnd: 40}, 120, 30},
+		{"padded to target", models.ExtractionJob{Format: "mp3", TrimEnd: 20, TargetDuration: 60}, 120, 60},
+		{"cut to target", models.ExtractionJob{Format: "mp3", TargetDuration: 60}, 120, 60},
+		{"copy shorter than target", models.ExtractionJob{Format: "mka", TrimEnd: 20, TargetDuration: 60}, 120, 20},
+		{"copy cut to target", models.ExtractionJob{Format: "mka", TargetDuration: 60}, 120, 60},
+		{"copy with unknown source", models.ExtractionJob{Format: "mka", TargetDuration: 60}, 0, 0},
+		{"unknown source", models.ExtractionJob{Format: "mp3"}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expectedDuration(&tt.job, tt.total); got != tt.want {
+				t.Errorf("expectedDuration = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultWebhookWorkers     = 4
+	defaultWebhookQueueSize   = 256
+	defaultWebhookMaxAttempts = 5
+
+	webhookTimeout     = 10 * time.Second
+	webhookBaseBackoff = 2 * time.Second
+	webhookMaxBackoff  = 5 * time.Minute
+)
+
+// webhookPayload is the JSON body POSTed to a job's callback URL. Job is
+// the public view served by /api/job, without server paths.
+type webhookPayload struct {
+	Event  string         `json:"event"`
+	SentAt time.Time      `json:"sent_at"`
+	Job    map[string]any `json:"job"`
+}
+
+type webhookDelivery struct {
+	url     string
+	body    []byte
+	event   string
+	jobID   string
+	attempt int
+}
+
+// webhookDispatcher delivers callbacks through a fixed pool of workers fed by
+// a bounded queue, so bursts of completions neither spawn unbounded outbound
+// connections nor block job goroutines. Failed deliveries are re-queued with
+// exponential backoff until maxAttempts is reached.
+type webhookDispatcher struct {
+	app         *App
+	client      *http.Client
+	queue       chan webhookDelivery
+	workers     int
+	maxAttempts int
+}
+
+func newWebhookDispatcher(app *App, workers, queueSize, maxAttempts int) *webhookDispatcher {
+	if workers <= 0 {
+		workers = defaultWebhookWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = defaultWebhookQueueSize
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = defaultWebhookMaxAttempts
+	}
+	return &webhookDispatcher{
+		app:         app,
+		client:      &http.Client{Timeout: webhookTimeout, Transport: app.webhookTransport()},
+		queue:       make(chan webhookDelivery, queueSize),
+		workers:     workers,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// StartWebhookWorkers starts the webhook delivery pool until ctx is done.
+func (a *App) StartWebhookWorkers(ctx context.Context) {
+	for i := 0; i < a.webhooks.workers; i++ {
+		go a.webhooks.run(ctx)
+	}
+}
+
+func (d *webhookDispatcher) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case delivery := <-d.queue:
+			d.deliver(ctx, delivery)
+		}
+	}
+}
+
+// enqueue never blocks: when the queue is full the delivery is dropped.
+func (d *webhookDispatcher) enqueue(delivery webhookDelivery) {
+	select {
+	case d.queue <- delivery:
+	default:
+		d.app.logger.Warn("webhook queue full, dropping delivery", "job_id", delivery.jobID, "event", delivery.event)
+	}
+}
+
+func (d *webhookDispatcher) deliver(ctx context.Context, delivery webhookDelivery) {
+	delivery.attempt++
+	err := d.post(ctx, delivery)
+	if err == nil {
+		d.app.logger.Info("webhook delivered", "job_id", delivery.jobID, "event", delivery.event, "attempt", delivery.attempt)
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	if delivery.attempt >= d.maxAttempts {
+		d.app.logger.Error("webhook delivery abandoned", "job_id", delivery.jobID, "event", delivery.event, "attempts", delivery.attempt, "error", err)
+		return
+	}
+
+	backoff := webhookBaseBackoff << (delivery.attempt - 1)
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	d.app.logger.Warn("webhook delivery failed, retrying", "job_id", delivery.jobID, "event", delivery.event, "attempt", delivery.attempt, "retry_in", backoff.String(), "error", err)
+	time.AfterFunc(backoff, func() {
+		if ctx.Err() == nil {
+			d.enqueue(delivery)
+		}
+	})
+}
+
+func (d *webhookDispatcher) post(ctx context.Context, delivery webhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.url, bytes.NewReader(delivery.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", delivery.event)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// notifyWebhook queues a callback for the job's current state, if the job
+// registered a callback URL.
+func (a *App) notifyWebhook(jobID, event string) {
+	job, ok := a.getJob(jobID)
+	if !ok || job.CallbackURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(webhookPayload{Event: event, SentAt: time.Now(), Job: jobView(job)})
+	if err != nil {
+		a.logger.Error("failed to encode webhook payload", "job_id", jobID, "error", err)
+		return
+	}
+	a.webhooks.enqueue(webhookDelivery{url: job.CallbackURL, body: body, event: event, jobID: jobID})
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"os"
+
+	"extratorDeAudio/internal/models"
+)
+
+// whisperAudio returns the audio to transcribe. With TranscribeMono, stereo
+// (or wider) outputs are first downmixed to a temporary 16kHz mono WAV,
+// whisper's native input, which is faster and usually more accurate than
+// letting it convert on the fly. The returned cleanup removes the temporary
+// file; on any failure the extracted output is used as is.
+func (a *App) whisperAudio(ctx context.Context, job *models.ExtractionJob, base string) (string, func()) {
+	noop := func() {}
+	if !a.cfg.TranscribeMono {
+		return job.OutputPath, noop
+	}
+	streams, err := a.extractor.ProbeAudioStreams(ctx, job.OutputPath)
+	if err != nil || len(streams) == 0 || streams[0].Channels < 2 {
+		return job.OutputPath, noop
+	}
+
+	monoPath := base + ".mono.wav"
+	if err := a.extractor.ExtractClip(ctx, job.OutputPath, monoPath, 0, 0); err != nil {
+		a.logger.Warn("mono intermediate for transcription failed, using extracted audio", "job_id", job.ID, "error", err)
+		_ = os.Remove(monoPath)
+		return job.OutputPath, noop
+	}
+	a.logger.Info("transcribing from mono intermediate", "job_id", job.ID, "channels", streams[0].Channels)
+	return monoPath, func() { _ = os.Remove(monoPath) }
+}
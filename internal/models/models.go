@@ -7,45 +7,173 @@ type JobStatus string
 
 const (
 	StatusNotStarted JobStatus = "not_started"
+	StatusUploading  JobStatus = "uploading"
 	StatusUploaded   JobStatus = "uploaded"
 	StatusQueued     JobStatus = "queued"
+	// StatusScheduled is a queued heavy stage held until the configured
+	// off-peak window opens; ScheduledAt says when.
+	StatusScheduled  JobStatus = "scheduled"
 	StatusProcessing JobStatus = "processing"
 	StatusCompleted  JobStatus = "completed"
 	StatusFailed     JobStatus = "failed"
+	StatusCanceled   JobStatus = "canceled"
+	// StatusDeleted is only sent to subscribers when DELETE /job/{id}
+	// purges the job.
+	StatusDeleted JobStatus = "deleted"
 )
 
+// JobOutput is one file of a job that produces several outputs, such as one
+// file per channel.
+type JobOutput struct {
+	Label string `json:"label"`
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+}
+
+// Conversion is an extracted output re-encoded to another format after the
+// fact, from the output itself rather than the source upload.
+type Conversion struct {
+	Format  string    `json:"format"`
+	Quality string    `json:"quality"`
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Status  JobStatus `json:"status"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Chapter is a navigation marker generated from the transcript, in seconds.
+type Chapter struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Title string  `json:"title"`
+}
+
+// ErrorCodeDiskFull marks failures caused by the server running out of disk space.
+const ErrorCodeDiskFull = "disk_full"
+
+// ErrorCodeOutputInvalid marks extractions whose output failed VERIFY_OUTPUT.
+const ErrorCodeOutputInvalid = "output_invalid"
+
+// ErrorCodeOutputExists marks extractions refused by OVERWRITE_POLICY=fail.
+const ErrorCodeOutputExists = "output_exists"
+
 // ExtractionJob stores metadata and runtime state for a conversion request.
 type ExtractionJob struct {
-	ID                 string    `json:"id"`
-	InputFileName      string    `json:"input_file_name"`
-	InputPath          string    `json:"input_path"`
-	OutputPath         string    `json:"output_path"`
-	OutputName         string    `json:"output_name"`
-	Format             string    `json:"format"`
-	Quality            string    `json:"quality"`
-	Status             JobStatus `json:"status"`
-	Progress           int       `json:"progress"`
-	Error              string    `json:"error"`
-	TranscriptStatus   JobStatus `json:"transcript_status"`
-	TranscriptProgress int       `json:"transcript_progress"`
-	TranscriptError    string    `json:"transcript_error"`
-	TranscriptTXTPath  string    `json:"transcript_txt_path"`
-	TranscriptTXTName  string    `json:"transcript_txt_name"`
-	TranscriptSRTPath  string    `json:"transcript_srt_path"`
-	TranscriptSRTName  string    `json:"transcript_srt_name"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
+	ID            string      `json:"id"`
+	InputFileName string      `json:"input_file_name"`
+	InputPath     string      `json:"input_path"`
+	InputFormat   string      `json:"input_format,omitempty"`
+	OutputPath    string      `json:"output_path"`
+	OutputName    string      `json:"output_name"`
+	OutputDir     string      `json:"output_dir,omitempty"`
+	SplitChannels bool        `json:"split_channels,omitempty"`
+	AllTracks     bool        `json:"all_tracks,omitempty"`
+	TrackIndex    *int        `json:"track_index,omitempty"`
+	FFmpegThreads *int        `json:"ffmpeg_threads,omitempty"`
+	CallbackURL   string      `json:"callback_url,omitempty"`
+	Outputs       []JobOutput `json:"outputs,omitempty"`
+	// ParentID links a re-extraction to the job whose upload it reuses.
+	ParentID       string  `json:"parent_id,omitempty"`
+	Format         string  `json:"format"`
+	Quality        string  `json:"quality"`
+	Channels       string  `json:"channels,omitempty"`
+	SampleRate     int     `json:"sample_rate,omitempty"`
+	NoFaststart    bool    `json:"no_faststart,omitempty"`
+	TrimStart      float64 `json:"trim_start,omitempty"`
+	TrimEnd        float64 `json:"trim_end,omitempty"`
+	SeekMode       string  `json:"seek_mode,omitempty"`
+	CopyTimestamps bool    `json:"copy_timestamps,omitempty"`
+	GainDB         float64 `json:"gain_db,omitempty"`
+	// TargetDuration is the exact output length in seconds (0 = as is).
+	TargetDuration     float64    `json:"target_duration,omitempty"`
+	Normalize          bool       `json:"normalize,omitempty"`
+	SurroundDownmix    string     `json:"surround_downmix,omitempty"`
+	PreserveMetadata   bool       `json:"preserve_metadata,omitempty"`
+	NameTemplate       string     `json:"name_template,omitempty"`
+	Status             JobStatus  `json:"status"`
+	Progress           int        `json:"progress"`
+	Error              string     `json:"error"`
+	ErrorCode          string     `json:"error_code,omitempty"`
+	Fingerprint        string     `json:"fingerprint,omitempty"`
+	Model              string     `json:"model,omitempty"`
+	Language           string     `json:"language,omitempty"`
+	Translate          bool       `json:"translate,omitempty"`
+	Prompt             string     `json:"prompt,omitempty"`
+	NormalizeText      string     `json:"normalize_text,omitempty"`
+	WordTimestamps     bool       `json:"word_timestamps,omitempty"`
+	TranscriptHeader   bool       `json:"transcript_header,omitempty"`
+	EmbedChapters      bool       `json:"embed_chapters,omitempty"`
+	ChunkSeconds       float64    `json:"chunk_seconds,omitempty"`
+	DetectLanguage     bool       `json:"detect_language,omitempty"`
+	DetectedLanguage   string     `json:"detected_language,omitempty"`
+	TranscriptLanguage string     `json:"transcript_language,omitempty"`
+	ScheduledAt        *time.Time `json:"scheduled_at,omitempty"`
+	// UploadSize and UploadOffset track a chunked upload: the announced
+	// total and the bytes received so far.
+	UploadSize   int64 `json:"upload_size,omitempty"`
+	UploadOffset int64 `json:"upload_offset,omitempty"`
+	// QueuePosition is the 1-based place in line while waiting for a worker.
+	QueuePosition       int            `json:"queue_position,omitempty"`
+	ExtractionUsage     *ResourceUsage `json:"extraction_usage,omitempty"`
+	TranscriptionUsage  *ResourceUsage `json:"transcription_usage,omitempty"`
+	ExpiryWarnedAt      *time.Time     `json:"expiry_warned_at,omitempty"`
+	TranscriptStatus    JobStatus      `json:"transcript_status"`
+	TranscriptProgress  int            `json:"transcript_progress"`
+	TranscriptError     string         `json:"transcript_error"`
+	TranscriptTXTPath   string         `json:"transcript_txt_path"`
+	TranscriptTXTName   string         `json:"transcript_txt_name"`
+	TranscriptSRTPath   string         `json:"transcript_srt_path"`
+	TranscriptSRTName   string         `json:"transcript_srt_name"`
+	TranscriptVTTPath   string         `json:"transcript_vtt_path,omitempty"`
+	TranscriptVTTName   string         `json:"transcript_vtt_name,omitempty"`
+	TranscriptWordsPath string         `json:"transcript_words_path,omitempty"`
+	TranscriptWordsName string         `json:"transcript_words_name,omitempty"`
+	// TranscriptTruncated flags a transcript cut at MaxTranscriptBytes,
+	// usually a sign of whisper hallucinating on noisy audio.
+	TranscriptTruncated bool         `json:"transcript_truncated,omitempty"`
+	Conversions         []Conversion `json:"conversions,omitempty"`
+	Chapters            []Chapter    `json:"chapters,omitempty"`
+	CreatedAt           time.Time    `json:"created_at"`
+	UpdatedAt           time.Time    `json:"updated_at"`
+	// ArchivedAt is set when the cleanup TTL expired but the files are kept
+	// for a grace period before being purged.
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+// ResourceUsage is the CPU time and peak memory spent on one job stage,
+// summed over its ffmpeg/whisper processes.
+type ResourceUsage struct {
+	Processes     int     `json:"processes"`
+	UserSeconds   float64 `json:"cpu_user_seconds"`
+	SystemSeconds float64 `json:"cpu_system_seconds"`
+	PeakRSSBytes  int64   `json:"peak_rss_bytes,omitempty"`
+}
+
+// OutputProgress reports the progress of one file produced by a job.
+type OutputProgress struct {
+	Name     string    `json:"name"`
+	Status   JobStatus `json:"status"`
+	Progress int       `json:"progress"`
 }
 
 // ProgressEvent is sent to clients over WebSocket.
 type ProgressEvent struct {
-	ID               string    `json:"id"`
-	Stage            string    `json:"stage,omitempty"`
-	Status           JobStatus `json:"status"`
-	Progress         int       `json:"progress"`
-	Message          string    `json:"message,omitempty"`
-	DownloadURL      string    `json:"download_url,omitempty"`
-	TranscriptTXTURL string    `json:"transcript_txt_url,omitempty"`
-	TranscriptSRTURL string    `json:"transcript_srt_url,omitempty"`
-	Error            string    `json:"error,omitempty"`
+	ID                 string    `json:"id"`
+	Stage              string    `json:"stage,omitempty"`
+	Status             JobStatus `json:"status"`
+	Progress           int       `json:"progress"`
+	Message            string    `json:"message,omitempty"`
+	DownloadURL        string    `json:"download_url,omitempty"`
+	TranscriptTXTURL   string    `json:"transcript_txt_url,omitempty"`
+	TranscriptSRTURL   string    `json:"transcript_srt_url,omitempty"`
+	TranscriptVTTURL   string    `json:"transcript_vtt_url,omitempty"`
+	TranscriptWordsURL string    `json:"transcript_words_url,omitempty"`
+	Error              string    `json:"error,omitempty"`
+	// Warning flags advisory events that don't change the job state, e.g.
+	// "expiring" before the cleanup TTL removes the files at ExpiresAt.
+	Warning   string     `json:"warning,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Outputs details per-file progress for jobs producing several files;
+	// Progress is then the aggregate.
+	Outputs []OutputProgress `json:"outputs,omitempty"`
 }
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Local stores objects as files under Root. It is the default backend.
+type Local struct {
+	Root string
+}
+
+// NewLocal returns a Local storage rooted at dir.
+func NewLocal(dir string) *Local {
+	return &Local{Root: dir}
+}
+
+// LocalPath maps key to its file path under Root.
+func (l *Local) LocalPath(key string) string {
+	return filepath.Join(l.Root, filepath.FromSlash(key))
+}
+
+func (l *Local) Put(_ context.Context, key string, r io.Reader, _ int64) error {
+	path := l.LocalPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		_ = os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+func (l *Local) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(l.LocalPath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	err := os.Remove(l.LocalPath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (l *Local) Stat(_ context.Context, key string) (Info, error) {
+	info, err := os.Stat(l.LocalPath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Info{}, ErrNotFound
+	}
+	if err != nil {
+		return Info{}, err
+	}
+	return Info{Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// URL returns "": local files are served by the app (or the front proxy).
+func (l *Local) URL(context.Context, string, string) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPresignTTL = 15 * time.Minute
+	unsignedPayload   = "UNSIGNED-PAYLOAD"
+	amzDateFormat     = "20060102T150405Z"
+)
+
+// S3Config configures an S3-compatible bucket (AWS S3, MinIO, ...).
+type S3Config struct {
+	// Endpoint is the service URL, e.g. https://s3.us-east-1.amazonaws.com
+	// or http://minio:9000.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// Prefix is prepended to every key, e.g. "outputs/".
+	Prefix string
+	// PathStyle addresses the bucket as endpoint/bucket instead of
+	// bucket.endpoint; MinIO usually needs it.
+	PathStyle bool
+	// PresignTTL is how long download URLs stay valid.
+	PresignTTL time.Duration
+}
+
+// S3 stores objects in a bucket using signed (SigV4) REST requests.
+type S3 struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3 validates cfg and returns an S3 storage.
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("storage: s3 bucket and credentials are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	if cfg.PresignTTL <= 0 {
+		cfg.PresignTTL = defaultPresignTTL
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("storage: invalid s3 endpoint %q", cfg.Endpoint)
+	}
+	return &S3{cfg: cfg, endpoint: endpoint, client: &http.Client{Timeout: 10 * time.Minute}}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (Info, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return Info{}, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return Info{}, err
+	}
+	resp.Body.Close()
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return Info{Size: resp.ContentLength, ModTime: modTime}, nil
+}
+
+// URL returns a presigned GET URL that makes the browser save the object as
+// name.
+func (s *S3) URL(_ context.Context, key, name string) (string, error) {
+	u := s.objectURL(key)
+	now := time.Now().UTC()
+	scope := s.scope(now)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.cfg.AccessKey+"/"+scope)
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(s.cfg.PresignTTL.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if name != "" {
+		query.Set("response-content-disposition", "attachment; filename=\""+name+"\"")
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		u.RawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	u.RawQuery += "&X-Amz-Signature=" + s.signature(now, scope, canonical)
+	return u.String(), nil
+}
+
+func (s *S3) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req)
+	return req, nil
+}
+
+// do sends req and maps error statuses, closing the body on failure.
+func (s *S3) do(req *http.Request) (*http.Response, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return nil, fmt.Errorf("storage: s3 %s %s: %s %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+}
+
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	segments := strings.Split(strings.TrimPrefix(s.cfg.Prefix+key, "/"), "/")
+	if s.cfg.PathStyle {
+		segments = append([]string{s.cfg.Bucket}, segments...)
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+	}
+	escaped := make([]string, len(segments))
+	for i, seg := range segments {
+		escaped[i] = uriEncode(seg)
+	}
+	u.Path = "/" + strings.Join(segments, "/")
+	u.RawPath = "/" + strings.Join(escaped, "/")
+	return &u
+}
+
+// sign adds SigV4 header authentication. The payload is sent unsigned so
+// large files can be streamed without hashing them first.
+func (s *S3) sign(req *http.Request) {
+	now := time.Now().UTC()
+	scope := s.scope(now)
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + unsignedPayload + "\n" +
+			"x-amz-date:" + now.Format(amzDateFormat) + "\n",
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, scope, signedHeaders, s.signature(now, scope, canonical)))
+}
+
+func (s *S3) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.cfg.Region + "/s3/aws4_request"
+}
+
+func (s *S3) signature(now time.Time, scope, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + now.Format(amzDateFormat) + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query parameters sorted by key, as SigV4 requires.
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string(nil), values[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except the SigV4 unreserved set.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
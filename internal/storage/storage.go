@@ -0,0 +1,38 @@
+// Package storage abstracts where finished outputs live: the local outputs
+// directory or an S3-compatible bucket.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when a key does not exist.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Info describes a stored object.
+type Info struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage stores objects by slash-separated key, relative to the outputs
+// root.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	Stat(ctx context.Context, key string) (Info, error)
+	// URL returns a URL clients can download key from directly, with name
+	// as the attachment file name, or "" when the app must serve it itself.
+	URL(ctx context.Context, key, name string) (string, error)
+}
+
+// LocalPather is implemented by storages backed by the local filesystem.
+// Files already written under the root need no Put, and can be served with
+// the regular file handlers.
+type LocalPather interface {
+	LocalPath(key string) string
+}
@@ -0,0 +1,75 @@
+package transcript
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Chapter is a navigation marker derived from the transcript.
+type Chapter struct {
+	Start time.Duration
+	End   time.Duration
+	Title string
+}
+
+const (
+	// chapterGap is the pause between cues that may start a new chapter.
+	chapterGap = 2 * time.Second
+	// minChapterLength keeps chapters from being split on every pause.
+	minChapterLength = time.Minute
+	// chapterTitleWords is how many words of the first cue title a chapter.
+	chapterTitleWords = 6
+)
+
+// BuildChapters groups cues into chapters, starting a new one at a pause of
+// at least chapterGap once the current chapter is minChapterLength long.
+// Each chapter is titled with the first words spoken in it.
+func BuildChapters(segments []Segment) []Chapter {
+	var chapters []Chapter
+	for i, seg := range segments {
+		n := len(chapters)
+		if n == 0 {
+			chapters = append(chapters, Chapter{Start: 0, End: seg.End, Title: chapterTitle(seg.Text)})
+			continue
+		}
+		current := &chapters[n-1]
+		gap := seg.Start - segments[i-1].End
+		if gap >= chapterGap && seg.Start-current.Start >= minChapterLength {
+			current.End = seg.Start
+			chapters = append(chapters, Chapter{Start: seg.Start, End: seg.End, Title: chapterTitle(seg.Text)})
+			continue
+		}
+		if seg.End > current.End {
+			current.End = seg.End
+		}
+	}
+	return chapters
+}
+
+func chapterTitle(text string) string {
+	words := strings.Fields(text)
+	if len(words) > chapterTitleWords {
+		return strings.Join(words[:chapterTitleWords], " ") + "…"
+	}
+	return strings.Join(words, " ")
+}
+
+// WriteFFMetadata writes chapters in ffmpeg's FFMETADATA1 format, ready for
+// -map_chapters.
+func WriteFFMetadata(w io.Writer, chapters []Chapter) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, ";FFMETADATA1")
+	for _, ch := range chapters {
+		fmt.Fprintf(bw, "\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			ch.Start.Milliseconds(), ch.End.Milliseconds(), escapeFFMetadata(ch.Title))
+	}
+	return bw.Flush()
+}
+
+// escapeFFMetadata escapes the characters FFMETADATA treats specially.
+func escapeFFMetadata(v string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", `\`+"\n").Replace(v)
+}
@@ -0,0 +1,62 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats Write can produce.
+const (
+	FormatSRT  = "srt"
+	FormatVTT  = "vtt"
+	FormatText = "txt"
+	FormatJSON = "json"
+)
+
+// Write renders segments in the given format. Parsing the SRT with ParseSRT
+// and writing it back here is a pure conversion; no re-transcription needed.
+func Write(w io.Writer, segments []Segment, format string) error {
+	switch format {
+	case FormatSRT:
+		return WriteSRT(w, segments)
+	case FormatVTT:
+		return WriteVTT(w, segments)
+	case FormatText:
+		return WriteText(w, segments)
+	case FormatJSON:
+		if segments == nil {
+			segments = []Segment{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(segments)
+	}
+	return fmt.Errorf("unsupported transcript format %q", format)
+}
+
+// vttEscaper escapes the characters WebVTT cue text reserves; "-->" would
+// otherwise be read as a timing line.
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// WriteVTT writes segments as a WebVTT file with HH:MM:SS.mmm timings.
+func WriteVTT(w io.Writer, segments []Segment) error {
+	return WriteStyledVTT(w, segments, CueSettings{})
+}
+
+// WriteText writes the cue text only, one segment per line.
+func WriteText(w io.Writer, segments []Segment) error {
+	bw := bufio.NewWriter(w)
+	for _, seg := range segments {
+		if seg.Text == "" {
+			continue
+		}
+		if _, err := fmt.Fprintln(bw, seg.Text); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
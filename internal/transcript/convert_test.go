@@ -0,0 +1,84 @@
+package transcript
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleSRT = "\uFEFF1\n00:00:01,250 --> 00:00:03,999\nOlá,\nmundo\n\n2\n00:01:02,005 --> 01:00:00,000\nA < B & C\n\n"
+
+func TestParseTimecode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"00:00:01,250", 1250 * time.Millisecond},
+		{"01:02:03.004", time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond},
+		{"01:02.500", time.Minute + 2500*time.Millisecond},
+		{" 00:00:00,0005 ", time.Millisecond},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimecode(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseTimecode(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "12", "a:b:c", "00:-1:00", "1:2:3:4"} {
+		if _, err := ParseTimecode(in); err == nil {
+			t.Errorf("ParseTimecode(%q) accepted", in)
+		}
+	}
+}
+
+func TestFormatTimecode(t *testing.T) {
+	d := 25*time.Hour + 59*time.Minute + 58*time.Second + 7*time.Millisecond + 900*time.Microsecond
+	if got := FormatTimecode(d, ','); got != "25:59:58,007" {
+		t.Errorf("FormatTimecode = %q", got)
+	}
+	if got := FormatTimecode(-time.Second, '.'); got != "00:00:00.000" {
+		t.Errorf("negative FormatTimecode = %q", got)
+	}
+}
+
+func TestWriteConvertsSRT(t *testing.T) {
+	segments, err := ParseSRT(strings.NewReader(sampleSRT))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		format, want string
+	}{
+		{FormatSRT, "1\n00:00:01,250 --> 00:00:03,999\nOlá, mundo\n\n2\n00:01:02,005 --> 01:00:00,000\nA < B & C\n\n"},
+		{FormatVTT, "WEBVTT\n\n00:00:01.250 --> 00:00:03.999\nOlá, mundo\n\n00:01:02.005 --> 01:00:00.000\nA &lt; B &amp; C\n\n"},
+		{FormatText, "Olá, mundo\nA < B & C\n"},
+		{FormatJSON, "[\n  {\n    \"start\": 1.25,\n    \"end\": 3.999,\n    \"text\": \"Olá, mundo\"\n  },\n  {\n    \"start\": 62.005,\n    \"end\": 3600,\n    \"text\": \"A < B & C\"\n  }\n]\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Write(&buf, segments, tt.format); err != nil {
+			t.Fatalf("Write(%s): %v", tt.format, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("Write(%s) =\n%q\nwant\n%q", tt.format, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestWriteEdgeCases(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, nil, FormatJSON); err != nil || buf.String() != "[]\n" {
+		t.Errorf("empty JSON = %q, %v", buf.String(), err)
+	}
+
+	buf.Reset()
+	segments := []Segment{{End: time.Second}, {Start: time.Second, End: 2 * time.Second, Text: "fala"}}
+	if err := Write(&buf, segments, FormatText); err != nil || buf.String() != "fala\n" {
+		t.Errorf("text with an empty cue = %q, %v", buf.String(), err)
+	}
+
+	if err := Write(&buf, segments, "docx"); err == nil {
+		t.Error("unsupported format accepted")
+	}
+}
@@ -0,0 +1,51 @@
+package transcript
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Header documents where a standalone transcript file came from.
+type Header struct {
+	FileName    string
+	Duration    time.Duration
+	Language    string
+	Model       string
+	GeneratedAt time.Time
+}
+
+// headerRule separates the header block from the transcript text.
+const headerRule = "----------------------------------------"
+
+// String renders the header block, skipping unknown fields.
+func (h Header) String() string {
+	var b strings.Builder
+	if h.FileName != "" {
+		fmt.Fprintf(&b, "Arquivo: %s\n", h.FileName)
+	}
+	if h.Duration > 0 {
+		fmt.Fprintf(&b, "Duração: %s\n", strings.SplitN(FormatTimecode(h.Duration, '.'), ".", 2)[0])
+	}
+	if h.Language != "" {
+		fmt.Fprintf(&b, "Idioma: %s\n", h.Language)
+	}
+	if h.Model != "" {
+		fmt.Fprintf(&b, "Modelo: %s\n", h.Model)
+	}
+	if !h.GeneratedAt.IsZero() {
+		fmt.Fprintf(&b, "Gerado em: %s\n", h.GeneratedAt.UTC().Format("2006-01-02 15:04:05 UTC"))
+	}
+	b.WriteString(headerRule + "\n\n")
+	return b.String()
+}
+
+// PrependHeader writes h at the top of the text file at path.
+func PrependHeader(path string, h Header) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(h.String()), data...), 0o644)
+}
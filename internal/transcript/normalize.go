@@ -0,0 +1,93 @@
+package transcript
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Text normalization modes. Every mode collapses whitespace and repeated
+// punctuation; NormalizeLower and NormalizeSentence also adjust casing.
+const (
+	NormalizeBasic    = "basic"
+	NormalizeLower    = "lower"
+	NormalizeSentence = "sentence"
+)
+
+var (
+	spaceBeforePunct = regexp.MustCompile(`\s+([,.;:!?])`)
+	repeatedPunct    = regexp.MustCompile(`([,;:!?])[,;:!?]+`)
+	longEllipsis     = regexp.MustCompile(`\.{4,}`)
+	doubleDot        = regexp.MustCompile(`([^.])\.\.([^.]|$)`)
+)
+
+// NormalizeText cleans one line of transcript text according to mode.
+func NormalizeText(text, mode string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	text = spaceBeforePunct.ReplaceAllString(text, "$1")
+	text = repeatedPunct.ReplaceAllString(text, "$1")
+	text = longEllipsis.ReplaceAllString(text, "...")
+	text = doubleDot.ReplaceAllString(text, "$1.$2")
+
+	switch mode {
+	case NormalizeLower:
+		text = strings.ToLower(text)
+	case NormalizeSentence:
+		text = sentenceCase(text)
+	}
+	return text
+}
+
+// sentenceCase upper-cases the first letter of each sentence and leaves the
+// rest untouched so proper nouns survive.
+func sentenceCase(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	capitalize := true
+	for _, r := range text {
+		switch {
+		case capitalize && unicode.IsLetter(r):
+			r = unicode.ToUpper(r)
+			capitalize = false
+		case r == '.' || r == '!' || r == '?':
+			capitalize = true
+		case capitalize && unicode.IsDigit(r):
+			capitalize = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// NormalizeFile rewrites a plain-text transcript in place, normalizing each
+// line and dropping blank ones. Sentence casing carries across lines because
+// whisper often splits one sentence over several segments.
+func NormalizeFile(path, mode string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !utf8.Valid(data) {
+		return fmt.Errorf("transcript %s is not valid UTF-8", path)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = NormalizeText(line, mode)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	out := strings.Join(lines, "\n")
+	if mode == NormalizeSentence {
+		out = sentenceCase(out)
+	}
+	if out != "" {
+		out += "\n"
+	}
+	return os.WriteFile(path, []byte(out), 0o644)
+}
@@ -0,0 +1,200 @@
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Segment is a single timed piece of a transcript.
+type Segment struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// MarshalJSON encodes timestamps as seconds so API clients don't need to
+// parse SRT timecodes. The text is left unescaped; an encoder that wants
+// HTML escaping applies it to the result.
+func (s Segment) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	}{
+		Start: roundSeconds(s.Start),
+		End:   roundSeconds(s.End),
+		Text:  s.Text,
+	})
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), err
+}
+
+func roundSeconds(d time.Duration) float64 {
+	return math.Round(d.Seconds()*1000) / 1000
+}
+
+// ParseSRT reads SubRip cues. Cue numbers are ignored and multi-line cue text
+// is joined with a single space.
+func ParseSRT(r io.Reader) ([]Segment, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var segments []Segment
+	var current *Segment
+	var text []string
+
+	flush := func() {
+		if current != nil {
+			current.Text = strings.TrimSpace(strings.Join(text, " "))
+			segments = append(segments, *current)
+		}
+		current = nil
+		text = nil
+	}
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\uFEFF"))
+		switch {
+		case line == "":
+			flush()
+		case strings.Contains(line, "-->"):
+			flush()
+			start, end, err := parseCueTiming(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			current = &Segment{Start: start, End: end}
+		case current == nil:
+			// Cue index line.
+		default:
+			text = append(text, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return segments, nil
+}
+
+func parseCueTiming(line string) (time.Duration, time.Duration, error) {
+	from, to, _ := strings.Cut(line, "-->")
+	start, err := ParseTimecode(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	// VTT cue settings may follow the end timestamp.
+	fields := strings.Fields(to)
+	if len(fields) == 0 {
+		return 0, 0, fmt.Errorf("missing cue end time")
+	}
+	end, err := ParseTimecode(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// ParseTimecode parses SRT (`00:01:02,500`) and VTT (`01:02.500`) timecodes.
+func ParseTimecode(v string) (time.Duration, error) {
+	v = strings.ReplaceAll(strings.TrimSpace(v), ",", ".")
+	parts := strings.Split(v, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timecode %q", v)
+	}
+
+	var hours, minutes int
+	var err error
+	if len(parts) == 3 {
+		if hours, err = strconv.Atoi(parts[0]); err != nil {
+			return 0, fmt.Errorf("invalid timecode %q", v)
+		}
+		parts = parts[1:]
+	}
+	if minutes, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, fmt.Errorf("invalid timecode %q", v)
+	}
+	seconds, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || seconds < 0 || hours < 0 || minutes < 0 {
+		return 0, fmt.Errorf("invalid timecode %q", v)
+	}
+
+	total := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	return total + time.Duration(math.Round(seconds*1000))*time.Millisecond, nil
+}
+
+// WriteSRT writes segments as numbered SubRip cues.
+func WriteSRT(w io.Writer, segments []Segment) error {
+	bw := bufio.NewWriter(w)
+	for i, seg := range segments {
+		if _, err := fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n", i+1, FormatTimecode(seg.Start, ','), FormatTimecode(seg.End, ','), seg.Text); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// FormatTimecode renders d as HH:MM:SS<sep>mmm. SRT uses ',' and VTT '.'.
+func FormatTimecode(d time.Duration, sep byte) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3_600_000, ms/60_000%60, ms/1000%60, sep, ms%1000)
+}
+
+// Shift returns a copy of segments moved by offset, e.g. to place the cues of
+// a chunk at its position in the full audio.
+func Shift(segments []Segment, offset time.Duration) []Segment {
+	shifted := make([]Segment, len(segments))
+	for i, seg := range segments {
+		seg.Start += offset
+		seg.End += offset
+		shifted[i] = seg
+	}
+	return shifted
+}
+
+// Splice replaces [start, end) of segments with replacement, keeping the
+// result ordered by start time. Segments inside the window are dropped and
+// those crossing an edge are clipped to it, so no speech outside the window
+// is lost; one spanning the whole window keeps its longer side. Replacement
+// segments are clipped to the window too.
+func Splice(segments, replacement []Segment, start, end time.Duration) []Segment {
+	out := make([]Segment, 0, len(segments)+len(replacement))
+	for _, seg := range segments {
+		if seg.Start >= end || seg.End <= start {
+			out = append(out, seg)
+			continue
+		}
+		before, after := start-seg.Start, seg.End-end
+		switch {
+		case before <= 0 && after <= 0:
+			continue
+		case before >= after:
+			seg.End = start
+		default:
+			seg.Start = end
+		}
+		out = append(out, seg)
+	}
+	for _, seg := range replacement {
+		seg.Start = max(seg.Start, start)
+		seg.End = min(seg.End, end)
+		if seg.End > seg.Start {
+			out = append(out, seg)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start < out[j].Start })
+	return out
+}
@@ -0,0 +1,62 @@
+package transcript
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func seg(start, end int, text string) Segment {
+	return Segment{Start: time.Duration(start) * time.Second, End: time.Duration(end) * time.Second, Text: text}
+}
+
+func TestSplice(t *testing.T) {
+	tests := []struct {
+		name        string
+		start, end  int
+		segments    []Segment
+		replacement []Segment
+		want        []Segment
+	}{
+		{
+			name:        "inside window replaced",
+			start:       10,
+			end:         20,
+			segments:    []Segment{seg(0, 5, "a"), seg(10, 15, "b"), seg(20, 25, "c")},
+			replacement: []Segment{seg(10, 14, "B")},
+			want:        []Segment{seg(0, 5, "a"), seg(10, 14, "B"), seg(20, 25, "c")},
+		},
+		{
+			name:        "edges clipped",
+			start:       10,
+			end:         20,
+			segments:    []Segment{seg(5, 12, "a"), seg(12, 18, "b"), seg(18, 24, "c")},
+			replacement: []Segment{seg(10, 20, "X")},
+			want:        []Segment{seg(5, 10, "a"), seg(10, 20, "X"), seg(20, 24, "c")},
+		},
+		{
+			name:        "spanning cue keeps longer side",
+			start:       5,
+			end:         10,
+			segments:    []Segment{seg(0, 30, "long")},
+			replacement: []Segment{seg(5, 10, "X")},
+			want:        []Segment{seg(5, 10, "X"), seg(10, 30, "long")},
+		},
+		{
+			name:        "replacement clipped to window",
+			start:       10,
+			end:         20,
+			segments:    []Segment{seg(0, 10, "a"), seg(20, 30, "c")},
+			replacement: []Segment{seg(9, 21, "X"), seg(21, 22, "outside")},
+			want:        []Segment{seg(0, 10, "a"), seg(10, 20, "X"), seg(20, 30, "c")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Splice(tt.segments, tt.replacement, time.Duration(tt.start)*time.Second, time.Duration(tt.end)*time.Second)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Splice = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
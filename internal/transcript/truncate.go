@@ -0,0 +1,70 @@
+package transcript
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// TruncateFile cuts the transcript at path to at most maxBytes and reports
+// whether it did. The cut falls on a cue boundary (blank line) when there is
+// one, otherwise on a line boundary, so SRT/VTT stay parseable. A non-empty
+// marker is appended on its own line after the cut; it may push the file
+// slightly past maxBytes. Only the first maxBytes are read, so huge files
+// are cut in place without loading them.
+func TruncateFile(path string, maxBytes int64, marker string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if maxBytes <= 0 || info.Size() <= maxBytes {
+		return false, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxBytes))
+	if err != nil {
+		return false, err
+	}
+	cut := truncationPoint(data)
+	if err := f.Truncate(int64(cut)); err != nil {
+		return false, err
+	}
+	if marker != "" {
+		tail := marker + "\n"
+		if cut > 0 && data[cut-1] != '\n' {
+			tail = "\n" + tail
+		}
+		if _, err := f.WriteAt([]byte(tail), int64(cut)); err != nil {
+			return false, err
+		}
+	}
+	return true, f.Close()
+}
+
+// truncationPoint returns how much of data to keep: up to the last blank
+// line, else the last line, else the last whole rune.
+func truncationPoint(data []byte) int {
+	if i := bytes.LastIndex(data, []byte("\n\n")); i >= 0 {
+		return i + 2
+	}
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		return i + 1
+	}
+	// The limit may have split a multi-byte rune; back up to its start.
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(data)
+}
@@ -0,0 +1,42 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTruncateFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		maxBytes int64
+		marker   string
+		want     string
+		cut      bool
+	}{
+		{"fits", "1\nabc\n", 100, "[cortado]", "1\nabc\n", false},
+		{"cue boundary", "1\nabc\n\n2\ndef\n\n3\nghi\n", 12, "[cortado]", "1\nabc\n\n[cortado]\n", true},
+		{"line boundary", "linha um\nlinha dois\n", 14, "", "linha um\n", true},
+		{"split rune", "aaãb", 3, "[cortado]", "aa\n[cortado]\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "t.srt")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cut, err := TruncateFile(path, tt.maxBytes, tt.marker)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cut != tt.cut {
+				t.Errorf("cut = %v, want %v", cut, tt.cut)
+			}
+			got, _ := os.ReadFile(path)
+			if string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
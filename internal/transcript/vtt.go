@@ -0,0 +1,137 @@
+package transcript
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CueSettings are the WebVTT cue settings written after each timing line.
+// Empty fields are omitted and players use their defaults.
+type CueSettings struct {
+	Line     string // "85%", "-2" or "85%,end"
+	Position string // "50%" or "50%,center"
+	Size     string // "80%"
+	Align    string // start, center, end, left or right
+}
+
+// ParseCueSettings parses settings in cue syntax, e.g. "line:85% align:center".
+func ParseCueSettings(v string) (CueSettings, error) {
+	var c CueSettings
+	for _, field := range strings.Fields(v) {
+		name, value, ok := strings.Cut(field, ":")
+		if !ok || value == "" {
+			return CueSettings{}, fmt.Errorf("invalid cue setting %q", field)
+		}
+		if err := c.Set(name, value); err != nil {
+			return CueSettings{}, err
+		}
+	}
+	return c, nil
+}
+
+// Set validates and stores one setting by its cue name.
+func (c *CueSettings) Set(name, value string) error {
+	value = strings.TrimSpace(value)
+	switch name {
+	case "line":
+		num, anchor, _ := strings.Cut(value, ",")
+		if !(validPercent(num) || validLineNumber(num)) || !validAnchor(anchor, "start", "center", "end") {
+			return fmt.Errorf("invalid line %q", value)
+		}
+		c.Line = value
+	case "position":
+		num, anchor, _ := strings.Cut(value, ",")
+		if !validPercent(num) || !validAnchor(anchor, "line-left", "center", "line-right") {
+			return fmt.Errorf("invalid position %q", value)
+		}
+		c.Position = value
+	case "size":
+		if !validPercent(value) {
+			return fmt.Errorf("invalid size %q", value)
+		}
+		c.Size = value
+	case "align":
+		if value == "" || !validAnchor(value, "start", "center", "end", "left", "right") {
+			return fmt.Errorf("invalid align %q", value)
+		}
+		c.Align = value
+	default:
+		return fmt.Errorf("unknown cue setting %q", name)
+	}
+	return nil
+}
+
+// String renders the settings in a fixed order, ready for a timing line.
+func (c CueSettings) String() string {
+	var parts []string
+	for _, kv := range [][2]string{{"line", c.Line}, {"position", c.Position}, {"size", c.Size}, {"align", c.Align}} {
+		if kv[1] != "" {
+			parts = append(parts, kv[0]+":"+kv[1])
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func validPercent(v string) bool {
+	num, ok := strings.CutSuffix(v, "%")
+	if !ok {
+		return false
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	return err == nil && f >= 0 && f <= 100 && !strings.ContainsAny(num, "eE+")
+}
+
+func validLineNumber(v string) bool {
+	_, err := strconv.Atoi(v)
+	return err == nil
+}
+
+func validAnchor(v string, allowed ...string) bool {
+	if v == "" {
+		return true
+	}
+	for _, a := range allowed {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteStyledVTT writes segments as WebVTT with settings on every cue.
+// Segments without text are skipped, since an empty cue is invalid WebVTT.
+func WriteStyledVTT(w io.Writer, segments []Segment, settings CueSettings) error {
+	suffix := ""
+	if s := settings.String(); s != "" {
+		suffix = " " + s
+	}
+	bw := bufio.NewWriter(w)
+	if _, err := io.WriteString(bw, "WEBVTT\n\n"); err != nil {
+		return err
+	}
+	for _, seg := range segments {
+		text := cueText(seg.Text)
+		if text == "" {
+			continue
+		}
+		if _, err := fmt.Fprintf(bw, "%s --> %s%s\n%s\n\n", FormatTimecode(seg.Start, '.'), FormatTimecode(seg.End, '.'), suffix, vttEscaper.Replace(text)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// cueText drops the blank lines of a segment's text, which would end the
+// cue early.
+func cueText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,92 @@
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// validateVTT checks the WebVTT structure: the signature line, well-formed
+// and ordered cue timings, known cue settings and no empty cues.
+func validateVTT(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		return errors.New("empty file")
+	}
+	if first := strings.TrimPrefix(scanner.Text(), "\uFEFF"); first != "WEBVTT" && !strings.HasPrefix(first, "WEBVTT ") && !strings.HasPrefix(first, "WEBVTT\t") {
+		return errors.New("missing WEBVTT signature")
+	}
+
+	inCue, cueText := false, false
+	for lineNo := 2; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			if inCue && !cueText {
+				return fmt.Errorf("line %d: empty cue", lineNo)
+			}
+			inCue = false
+		case strings.Contains(line, "-->"):
+			if inCue {
+				return fmt.Errorf("line %d: timing line inside cue text", lineNo)
+			}
+			from, rest, _ := strings.Cut(line, "-->")
+			fields := strings.Fields(rest)
+			if len(fields) == 0 {
+				return fmt.Errorf("line %d: missing cue end time", lineNo)
+			}
+			start, err := ParseTimecode(from)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			end, err := ParseTimecode(fields[0])
+			if err != nil {
+				return fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if end < start {
+				return fmt.Errorf("line %d: cue ends before it starts", lineNo)
+			}
+			if _, err := ParseCueSettings(strings.Join(fields[1:], " ")); err != nil {
+				return fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			inCue, cueText = true, false
+		case inCue:
+			cueText = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if inCue && !cueText {
+		return errors.New("empty cue at end of file")
+	}
+	return nil
+}
+
+func TestWriteStyledVTTIsValid(t *testing.T) {
+	segments := []Segment{
+		{Start: 0, End: 2, Text: "olá"},
+		{Start: 2, End: 3, Text: ""},
+		{Start: 3, End: 4, Text: "  \n "},
+		{Start: 4, End: 6, Text: "primeira linha\n\nsegunda linha"},
+		{Start: 6, End: 8, Text: "a --> b & <c>"},
+	}
+	var buf bytes.Buffer
+	if err := WriteStyledVTT(&buf, segments, CueSettings{Line: "85%", Align: "center"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateVTT(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("invalid WebVTT: %v\n%s", err, buf.String())
+	}
+	if got := strings.Count(buf.String(), "-->"); got != 3 {
+		t.Errorf("%d timing lines, want 3 with the empty segments skipped:\n%s", got, buf.String())
+	}
+	if !strings.Contains(buf.String(), "primeira linha\nsegunda linha\n") {
+		t.Errorf("blank line inside the cue text was kept:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,141 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Word is a single word with its own timing, for karaoke-style highlighting.
+type Word struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// MarshalJSON encodes timestamps as seconds, like Segment.
+func (w Word) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Word  string  `json:"word"`
+	}{
+		Start: roundSeconds(w.Start),
+		End:   roundSeconds(w.End),
+		Word:  w.Text,
+	})
+}
+
+// UnmarshalJSON reads the format written by MarshalJSON.
+func (w *Word) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Word  string  `json:"word"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	w.Start = time.Duration(raw.Start * float64(time.Second))
+	w.End = time.Duration(raw.End * float64(time.Second))
+	w.Text = raw.Word
+	return nil
+}
+
+// whisperFullJSON is the subset of whisper.cpp's -ojf output we need.
+// Offsets are in milliseconds.
+type whisperFullJSON struct {
+	Transcription []struct {
+		Tokens []struct {
+			Text    string `json:"text"`
+			Offsets struct {
+				From int64 `json:"from"`
+				To   int64 `json:"to"`
+			} `json:"offsets"`
+		} `json:"tokens"`
+	} `json:"transcription"`
+}
+
+// ParseWhisperJSON extracts word timings from whisper.cpp's full JSON output.
+// Whisper emits sub-word tokens; a token starting with a space begins a new
+// word and the others are appended to the current one. Special tokens such as
+// [_BEG_] or <|endoftext|> are skipped.
+func ParseWhisperJSON(r io.Reader) ([]Word, error) {
+	var doc whisperFullJSON
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid whisper json: %w", err)
+	}
+
+	var words []Word
+	for _, segment := range doc.Transcription {
+		for _, token := range segment.Tokens {
+			if isSpecialToken(token.Text) {
+				continue
+			}
+			start := time.Duration(token.Offsets.From) * time.Millisecond
+			end := time.Duration(token.Offsets.To) * time.Millisecond
+			text := strings.TrimSpace(token.Text)
+			if text == "" {
+				continue
+			}
+
+			if n := len(words); n > 0 && !strings.HasPrefix(token.Text, " ") {
+				words[n-1].Text += text
+				words[n-1].End = end
+				continue
+			}
+			words = append(words, Word{Start: start, End: end, Text: text})
+		}
+	}
+	return words, nil
+}
+
+func isSpecialToken(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasPrefix(text, "[_") || strings.HasPrefix(text, "<|")
+}
+
+// WriteWords writes words as a JSON array.
+func WriteWords(w io.Writer, words []Word) error {
+	if words == nil {
+		words = []Word{}
+	}
+	return json.NewEncoder(w).Encode(words)
+}
+
+// ReadWords reads a JSON array written by WriteWords.
+func ReadWords(r io.Reader) ([]Word, error) {
+	var words []Word
+	if err := json.NewDecoder(r).Decode(&words); err != nil {
+		return nil, fmt.Errorf("invalid words json: %w", err)
+	}
+	return words, nil
+}
+
+// ShiftWords returns a copy of words moved by offset.
+func ShiftWords(words []Word, offset time.Duration) []Word {
+	shifted := make([]Word, len(words))
+	for i, w := range words {
+		w.Start += offset
+		w.End += offset
+		shifted[i] = w
+	}
+	return shifted
+}
+
+// SpliceWords is Splice for word timings.
+func SpliceWords(words, replacement []Word, start, end time.Duration) []Word {
+	out := make([]Word, 0, len(words)+len(replacement))
+	for _, w := range words {
+		if w.Start < end && w.End > start {
+			continue
+		}
+		out = append(out, w)
+	}
+	out = append(out, replacement...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start < out[j].Start })
+	return out
+}
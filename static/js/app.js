@@ -141,7 +141,7 @@
       }
     };
 
-    const renderTranscriptActions = (txtURL, srtURL) => {
+    const renderTranscriptActions = (txtURL, srtURL, vttURL) => {
       if (!resultSlot || (!txtURL && !srtURL)) return;
       if (document.getElementById("transcript-ready-card")) return;
       const pending = document.getElementById("transcription-pending-card");
@@ -153,11 +153,17 @@
           <div class="flex gap-2">
             ${txtURL ? `<a class="btn-secondary" href="${txtURL}">Baixar TXT</a>` : ""}
             ${srtURL ? `<a class="btn-secondary" href="${srtURL}">Baixar SRT</a>` : ""}
+            ${vttURL ? `<a class="btn-secondary" href="${vttURL}">Baixar VTT</a>` : ""}
           </div>
         </div>
       `;
     };
 
+    const scheduledMessage = (scheduledAt) => {
+      if (!scheduledAt) return "Agendado";
+      return `Agendado para ${new Date(scheduledAt).toLocaleString("pt-BR")}`;
+    };
+
     const applyJobSnapshot = (data) => {
       const extractionStatus = data.status;
       const extractionProgress = Number(data.progress || 0);
@@ -168,26 +174,38 @@
         updateProgress(0, data.error || "Falha na extração");
         return;
       }
+      if (extractionStatus === "canceled") {
+        updateProgress(0, "Extração cancelada");
+        return;
+      }
 
       if (extractionStatus === "completed") {
         updateProgress(100, "Extração concluída");
         renderExtractionActions(data.download_url);
       } else if (extractionStatus === "processing" || extractionStatus === "queued") {
         updateProgress(extractionProgress, "Extraindo áudio...");
+      } else if (extractionStatus === "scheduled") {
+        updateProgress(0, scheduledMessage(data.scheduled_at));
       }
 
       if (transcriptStatus === "queued" || transcriptStatus === "processing") {
         showTranscriptionPendingCard();
         updateProgress(transcriptProgress, "Transcrevendo áudio...");
+      } else if (transcriptStatus === "scheduled") {
+        showTranscriptionPendingCard();
+        updateProgress(0, scheduledMessage(data.scheduled_at));
       }
 
       if (transcriptStatus === "failed") {
         updateProgress(0, data.transcript_error || "Falha na transcrição");
       }
+      if (transcriptStatus === "canceled") {
+        updateProgress(0, "Transcrição cancelada");
+      }
 
       if (transcriptStatus === "completed") {
         updateProgress(100, "Transcrição concluída");
-        renderTranscriptActions(data.transcript_txt_url, data.transcript_srt_url);
+        renderTranscriptActions(data.transcript_txt_url, data.transcript_srt_url, data.transcript_vtt_url);
       }
     };
 
@@ -215,12 +233,26 @@
 
     const protocol = location.protocol === "https:" ? "wss" : "ws";
     const ws = new WebSocket(`${protocol}://${location.host}/ws/${jobID}`);
+    let jobDeleted = false;
 
     ws.onmessage = (event) => {
       try {
         const data = JSON.parse(event.data);
         const stage = data.stage || "extraction";
 
+        if (data.status === "deleted") {
+          jobDeleted = true;
+          clearInterval(pollTimer);
+          updateProgress(0, "Job removido");
+          showToast("Este job foi removido", "error");
+          return;
+        }
+
+        if (data.warning === "expiring") {
+          showToast(data.message || "Os arquivos deste job vão expirar em breve", "error");
+          return;
+        }
+
         if (stage === "transcription") {
           updateProgress(data.progress, data.message || "Transcrevendo áudio...");
 
@@ -230,9 +262,14 @@
             return;
           }
 
+          if (data.status === "canceled") {
+            showToast("Transcrição cancelada", "error");
+            return;
+          }
+
           if (data.status === "completed") {
             updateProgress(100, "Transcrição concluída");
-            renderTranscriptActions(data.transcript_txt_url, data.transcript_srt_url);
+            renderTranscriptActions(data.transcript_txt_url, data.transcript_srt_url, data.transcript_vtt_url);
             showToast("Transcrição concluída", "success");
           }
           return;
@@ -246,6 +283,11 @@
           return;
         }
 
+        if (data.status === "canceled") {
+          showToast("Extração cancelada", "error");
+          return;
+        }
+
         if (data.status === "completed") {
           updateProgress(100, "Extração concluída");
           renderExtractionActions(data.download_url);
@@ -261,12 +303,37 @@
     };
 
     ws.onclose = () => {
+      if (jobDeleted) return;
       showToast("Canal de progresso encerrado, usando atualização automática", "error");
     };
 
     window.addEventListener("beforeunload", () => clearInterval(pollTimer));
   };
 
+  // The server may restrict formats (ENABLED_FORMATS); rebuild the dropdown
+  // from /api/formats so it never offers one the upload would reject.
+  const setupFormatOptions = async () => {
+    const select = document.querySelector('#uploadForm select[name="format"]');
+    if (!select) return;
+    try {
+      const res = await fetch("/api/formats");
+      if (!res.ok) return;
+      const data = await res.json();
+      if (!Array.isArray(data.formats) || data.formats.length === 0) return;
+      select.innerHTML = "";
+      data.formats.forEach((format) => {
+        const option = document.createElement("option");
+        option.value = format.id;
+        option.textContent = format.label;
+        option.selected = format.id === data.default;
+        select.appendChild(option);
+      });
+    } catch {
+      // Keep the static list; the server still validates the choice.
+    }
+  };
+
   setupDropzone();
+  setupFormatOptions();
   setupJobPage();
 })();
@@ -31,7 +31,7 @@ func IndexPage(recent []*models.ExtractionJob) templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<!doctype html><html lang=\"pt-BR\"><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>Audio Extractor</title><script src=\"https://cdn.tailwindcss.com\"></script><link rel=\"stylesheet\" href=\"/static/css/style.css\"></head><body class=\"bg-slate-950 text-slate-100 min-h-screen\"><header class=\"border-b border-slate-800 bg-slate-900/80 backdrop-blur sticky top-0 z-20\"><div class=\"max-w-5xl mx-auto px-4 py-4 flex items-center justify-between\"><div><p class=\"text-cyan-400 text-sm font-semibold\">Audio Extractor</p><h1 class=\"text-xl md:text-2xl font-bold\">Extraia áudio de vídeos em segundos</h1></div><span class=\"text-xs text-slate-400\">Go + templ + ffmpeg</span></div></header><main class=\"max-w-5xl mx-auto px-4 py-10 space-y-8\"><section class=\"rounded-2xl bg-slate-900 border border-slate-800 shadow-xl p-6 md:p-8\"><form id=\"uploadForm\" class=\"space-y-6\" method=\"post\" action=\"/upload\" enctype=\"multipart/form-data\"><div id=\"dropzone\" class=\"dropzone rounded-xl border-2 border-dashed border-slate-700 bg-slate-950/60 p-10 text-center transition-all\"><p class=\"font-semibold text-lg\">Arraste e solte o vídeo aqui</p><p class=\"text-slate-400 mt-2\">ou clique para selecionar (máx. 500MB)</p><input id=\"video\" type=\"file\" name=\"video\" class=\"hidden\" accept=\"video/*\" required></div><div class=\"grid grid-cols-1 md:grid-cols-2 gap-4\"><label class=\"space-y-2\"><span class=\"text-sm text-slate-300\">Formato de saída</span> <select name=\"format\" class=\"input-field\" required><option value=\"mp3\">MP3</option> <option value=\"wav\">WAV</option> <option value=\"aac\">AAC</option> <option value=\"flac\">FLAC</option> <option value=\"ogg\">OGG</option></select></label> <label class=\"space-y-2\"><span class=\"text-sm text-slate-300\">Qualidade</span> <select name=\"quality\" class=\"input-field\" required><option value=\"low\">Baixa</option> <option value=\"medium\" selected>Média</option> <option value=\"high\">Alta</option> <option value=\"original\">Original</option></select></label></div><button type=\"submit\" class=\"btn-primary w-full md:w-auto\">Extrair Áudio</button></form></section><section class=\"rounded-2xl bg-slate-900 border border-slate-800 shadow-xl p-6 md:p-8\"><div class=\"flex items-center justify-between mb-4\"><h2 class=\"font-semibold text-lg\">Extrações recentes</h2><a href=\"/\" class=\"text-cyan-400 text-sm hover:text-cyan-300\">Atualizar</a></div>")
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<!doctype html><html lang=\"pt-BR\"><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>Audio Extractor</title><script src=\"https://cdn.tailwindcss.com\"></script><link rel=\"stylesheet\" href=\"/static/css/style.css\"></head><body class=\"bg-slate-950 text-slate-100 min-h-screen\"><header class=\"border-b border-slate-800 bg-slate-900/80 backdrop-blur sticky top-0 z-20\"><div class=\"max-w-5xl mx-auto px-4 py-4 flex items-center justify-between\"><div><p class=\"text-cyan-400 text-sm font-semibold\">Audio Extractor</p><h1 class=\"text-xl md:text-2xl font-bold\">Extraia áudio de vídeos em segundos</h1></div><span class=\"text-xs text-slate-400\">Go + templ + ffmpeg</span></div></header><main class=\"max-w-5xl mx-auto px-4 py-10 space-y-8\"><section class=\"rounded-2xl bg-slate-900 border border-slate-800 shadow-xl p-6 md:p-8\"><form id=\"uploadForm\" class=\"space-y-6\" method=\"post\" action=\"/upload\" enctype=\"multipart/form-data\"><div id=\"dropzone\" class=\"dropzone rounded-xl border-2 border-dashed border-slate-700 bg-slate-950/60 p-10 text-center transition-all\"><p class=\"font-semibold text-lg\">Arraste e solte o vídeo aqui</p><p class=\"text-slate-400 mt-2\">ou clique para selecionar (máx. 500MB)</p><input id=\"video\" type=\"file\" name=\"video\" class=\"hidden\" accept=\"video/*\" required></div><div class=\"grid grid-cols-1 md:grid-cols-4 gap-4\"><label class=\"space-y-2\"><span class=\"text-sm text-slate-300\">Formato de saída</span> <select name=\"format\" class=\"input-field\" required><option value=\"mp3\">MP3</option> <option value=\"wav\">WAV</option> <option value=\"aac\">AAC</option> <option value=\"m4a\">M4A (AAC)</option> <option value=\"flac\">FLAC</option> <option value=\"ogg\">OGG</option> <option value=\"opus\">Opus</option> <option value=\"hls\">HLS (streaming)</option></select></label> <label class=\"space-y-2\"><span class=\"text-sm text-slate-300\">Qualidade</span> <select name=\"quality\" class=\"input-field\" required><option value=\"low\">Baixa</option> <option value=\"medium\" selected>Média</option> <option value=\"high\">Alta</option> <option value=\"original\">Original</option></select></label> <label class=\"space-y-2\"><span class=\"text-sm text-slate-300\">Canais</span> <select name=\"channels\" class=\"input-field\"><option value=\"source\" selected>Como no vídeo</option> <option value=\"mono\">Mono</option> <option value=\"stereo\">Estéreo</option></select></label> <label class=\"space-y-2\"><span class=\"text-sm text-slate-300\">Taxa de amostragem</span> <select name=\"sample_rate\" class=\"input-field\"><option value=\"source\" selected>Como no vídeo</option> <option value=\"8000\">8 kHz</option> <option value=\"16000\">16 kHz (voz / transcrição)</option> <option value=\"22050\">22,05 kHz</option> <option value=\"44100\">44,1 kHz</option> <option value=\"48000\">48 kHz</option></select></label></div><div class=\"grid grid-cols-1 md:grid-cols-4 gap-4\"><label class=\"space-y-2\"><span class=\"text-sm text-slate-300\">Início do corte (opcional)</span> <input type=\"text\" name=\"start\" class=\"input-field\" placeholder=\"00:01:00\"></label> <label class=\"space-y-2\"><span class=\"text-sm text-slate-300\">Fim do corte (opcional)</span> <input type=\"text\" name=\"end\" class=\"input-field\" placeholder=\"00:02:30\"></label> <label class=\"space-y-2\"><span class=\"text-sm text-slate-300\">ou duração</span> <input type=\"text\" name=\"duration\" class=\"input-field\" placeholder=\"30\"></label> <label class=\"space-y-2\"><span class=\"text-sm text-slate-300\">Busca</span> <select name=\"seek\" class=\"input-field\"><option value=\"fast\" selected>Rápida</option> <option value=\"accurate\">Precisa (mais lenta)</option></select></label></div><label class=\"flex items-center gap-2 text-sm text-slate-300\"><input type=\"checkbox\" name=\"normalize\" value=\"1\" class=\"accent-cyan-500\"> Normalizar volume (loudnorm, -16 LUFS)</label> <button type=\"submit\" class=\"btn-primary w-full md:w-auto\">Extrair Áudio</button></form></section><section class=\"rounded-2xl bg-slate-900 border border-slate-800 shadow-xl p-6 md:p-8\"><div class=\"flex items-center justify-between mb-4\"><h2 class=\"font-semibold text-lg\">Extrações recentes</h2><a href=\"/\" class=\"text-cyan-400 text-sm hover:text-cyan-300\">Atualizar</a></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -53,7 +53,7 @@ func IndexPage(recent []*models.ExtractionJob) templ.Component {
 				var templ_7745c5c3_Var2 string
 				templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(item.InputFileName)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/index.templ`, Line: 71, Col: 53}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/index.templ`, Line: 118, Col: 53}
 				}
 				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
 				if templ_7745c5c3_Err != nil {
@@ -66,7 +66,7 @@ func IndexPage(recent []*models.ExtractionJob) templ.Component {
 				var templ_7745c5c3_Var3 string
 				templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(item.Format)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/index.templ`, Line: 72, Col: 57}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/index.templ`, Line: 119, Col: 57}
 				}
 				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
 				if templ_7745c5c3_Err != nil {
@@ -79,7 +79,7 @@ func IndexPage(recent []*models.ExtractionJob) templ.Component {
 				var templ_7745c5c3_Var4 string
 				templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(item.Quality)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/index.templ`, Line: 72, Col: 76}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/index.templ`, Line: 119, Col: 76}
 				}
 				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 				if templ_7745c5c3_Err != nil {
@@ -92,7 +92,7 @@ func IndexPage(recent []*models.ExtractionJob) templ.Component {
 				var templ_7745c5c3_Var5 string
 				templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(string(item.Status))
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/index.templ`, Line: 72, Col: 102}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/index.templ`, Line: 119, Col: 102}
 				}
 				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
 				if templ_7745c5c3_Err != nil {